@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/scanner"
+)
+
+// runAutofix applies the suggested fixes in results selected by opts, then
+// prints what changed as one diff. With opts.DryRun it only prints the diff
+// and the findings it would fix.
+func runAutofix(results []scanner.ScanResult, opts scanner.AutofixOptions) error {
+	rep, err := scanner.Autofix(results, opts)
+	if err != nil {
+		return fmt.Errorf("autofix failed: %w", err)
+	}
+
+	fmt.Println()
+	if len(rep.Applied) == 0 && len(rep.Failed) == 0 {
+		fmt.Printf("🔧 No suggested fixes for findings at or above %s with %s confidence\n", opts.MinSeverity, opts.MinConfidence)
+		return nil
+	}
+	if rep.Diff != "" {
+		if opts.DryRun {
+			fmt.Println("\033[38;5;208m━━━ Dry Run Diff (no files changed) ━━━\033[0m")
+		} else {
+			fmt.Println("\033[38;5;208m━━━ Autofix Diff ━━━\033[0m")
+		}
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(rep.Diff, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				fmt.Print(line)
+			case strings.HasPrefix(line, "+"):
+				fmt.Print("\033[38;5;82m" + strings.TrimSuffix(line, "\n") + "\033[0m\n")
+			case strings.HasPrefix(line, "-"):
+				fmt.Print("\033[38;5;203m" + strings.TrimSuffix(line, "\n") + "\033[0m\n")
+			case strings.HasPrefix(line, "@@"):
+				fmt.Print("\033[36m" + strings.TrimSuffix(line, "\n") + "\033[0m\n")
+			default:
+				fmt.Print(line)
+			}
+		}
+		fmt.Println()
+	}
+
+	if opts.DryRun {
+		fmt.Printf("🔍 Would apply %d fix(es) to %d file(s):\n", len(rep.Applied), len(rep.Files))
+	} else {
+		fmt.Printf("🔧 Applied %d fix(es) to %d file(s)\n", len(rep.Applied), len(rep.Files))
+	}
+	for _, fix := range rep.Applied {
+		fmt.Printf("   %s %s:%d %s\n", fix.Issue.Severity, fix.File, fix.Issue.LineStart, fix.Issue.Title)
+	}
+	for _, failed := range rep.Failed {
+		if opts.DryRun {
+			fmt.Fprintf(os.Stderr, "⚠️  Would not fix: %s\n", failed)
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠️  Not fixed: %s\n", failed)
+		}
+	}
+	switch {
+	case rep.Branch != "" && len(rep.Files) > 0:
+		fmt.Printf("🌿 Fixes committed on branch %s\n", rep.Branch)
+	case rep.Backup != "":
+		fmt.Printf("💾 Originals backed up (undo with: sidekick restore %s)\n", rep.Backup)
+	}
+	if rep.AuditLog != "" {
+		fmt.Printf("📝 Applied fixes logged to %s\n", rep.AuditLog)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pefman/sidekick/internal/badge"
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	badgeFormat string
+	badgeOutput string
+)
+
+var badgeCmd = &cobra.Command{
+	Use:   "badge [path]",
+	Short: "Generate a status badge from the latest scan",
+	Long: `Generate a small badge such as "security: 2 high" from the latest scan in
+the local history, for embedding in a README. With a path, the latest scan
+of that path is used. --format json writes a shields.io endpoint document.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBadge,
+}
+
+func init() {
+	badgeCmd.Flags().StringVarP(&badgeFormat, "format", "f", "svg", "Badge format: svg, json")
+	badgeCmd.Flags().StringVarP(&badgeOutput, "output", "o", "", "Write the badge to this file instead of stdout")
+}
+
+func runBadge(cmd *cobra.Command, args []string) error {
+	if badgeFormat != "svg" && badgeFormat != "json" {
+		return fmt.Errorf("unknown format %q (expected svg or json)", badgeFormat)
+	}
+
+	target := ""
+	if len(args) > 0 {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+		target = filepath.Clean(abs)
+	}
+
+	b, err := latestBadge(target)
+	if err != nil {
+		return err
+	}
+	data, err := renderBadge(b, badgeFormat)
+	if err != nil {
+		return err
+	}
+
+	if badgeOutput == "" {
+		ui.Stdout().Write(data)
+		return nil
+	}
+	if err := os.WriteFile(badgeOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	fmt.Printf("🏷️  Badge written to %s (%s: %s)\n", badgeOutput, b.Label, b.Message)
+	return nil
+}
+
+// latestBadge builds a badge from the most recent scan of target (any
+// target when empty), or an "unknown" badge when nothing was recorded.
+func latestBadge(target string) (badge.Badge, error) {
+	entry, err := history.Latest(target)
+	if err != nil {
+		return badge.Badge{}, fmt.Errorf("failed to read scan history: %w", err)
+	}
+	// Entries recorded before severity counts were kept can't be summarized
+	if entry == nil || (entry.BySeverity == nil && entry.FilesWithIssues > 0) {
+		return badge.Unknown(), nil
+	}
+	return badge.FromCounts(entry.BySeverity), nil
+}
+
+func renderBadge(b badge.Badge, format string) ([]byte, error) {
+	if format == "json" {
+		return b.JSON()
+	}
+	return b.SVG(), nil
+}
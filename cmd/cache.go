@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of scan results and context analyses",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete every cached scan result and context analysis",
+	Long: `Delete ~/.sidekick/cache, where scans keep the results and context analyses
+of files so unchanged files aren't sent to the model again. The next scan of
+every file calls the model.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheClear,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+
+	files := 0
+	var size int64
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if info, err := d.Info(); err == nil && !d.IsDir() {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		fmt.Println("Cache is already empty")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	fmt.Printf("🧹 Removed %d cached file(s) (%.1f MB) from %s\n", files, float64(size)/(1<<20), dir)
+	return nil
+}
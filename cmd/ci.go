@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/history"
+)
+
+// ciFence opens the summary block printed by --ci. Scripts can extract it
+// with e.g. sed -n '/^```sidekick-summary$/,/^```$/{//!p}'.
+const ciFence = "```sidekick-summary"
+
+// ciSummary is the machine-readable summary printed by --ci.
+type ciSummary struct {
+	Target          string         `json:"target"`
+	Model           string         `json:"model"`
+	ScanType        string         `json:"scan_type"`
+	DurationMS      int64          `json:"duration_ms"`
+	FilesScanned    int            `json:"files_scanned"`
+	FilesWithIssues int            `json:"files_with_issues"`
+	Findings        int            `json:"findings"`
+	BySeverity      map[string]int `json:"by_severity"`
+	Threshold       *ciThreshold   `json:"threshold,omitempty"`
+	Reports         ciReports      `json:"reports"`
+}
+
+// ciThreshold reports the --fail-on check.
+type ciThreshold struct {
+	FailOn    string `json:"fail_on"`
+	Exceeded  int    `json:"exceeded"`            // Findings at or above FailOn
+	Generated int    `json:"generated,omitempty"` // Findings at or above FailOn in generated code, not counted
+	Passed    bool   `json:"passed"`
+}
+
+// ciReports lists the report files written by the scan.
+type ciReports struct {
+	HTML string `json:"html,omitempty"`
+	JSON string `json:"json,omitempty"`
+}
+
+// buildCISummary summarizes a scan from its history entry. Findings in
+// generated code, counted by severity in generated, don't fail the
+// threshold.
+func buildCISummary(entry history.Entry, failOn string, generated map[string]int, reports ciReports) ciSummary {
+	summary := ciSummary{
+		Target:          entry.Target,
+		Model:           entry.Model,
+		ScanType:        entry.ScanType,
+		DurationMS:      entry.DurationMS,
+		FilesScanned:    entry.FilesScanned,
+		FilesWithIssues: entry.FilesWithIssues,
+		BySeverity:      entry.BySeverity,
+		Reports:         reports,
+	}
+	for _, n := range entry.BySeverity {
+		summary.Findings += n
+	}
+	if failOn != "" {
+		t := &ciThreshold{FailOn: strings.ToUpper(failOn)}
+		for sev, n := range entry.BySeverity {
+			if config.SeverityRank(sev) >= config.SeverityRank(failOn) {
+				t.Exceeded += n - generated[sev]
+				t.Generated += generated[sev]
+			}
+		}
+		t.Passed = t.Exceeded == 0
+		summary.Threshold = t
+	}
+	return summary
+}
+
+// writeCISummary prints summary as a fenced JSON block.
+func writeCISummary(w io.Writer, summary ciSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal CI summary: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "\n%s\n%s\n```\n", ciFence, data)
+	return err
+}
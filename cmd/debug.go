@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/debuglog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	debugFile     string
+	debugStage    string
+	debugResponse bool
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Inspect the model calls of --debug scans",
+}
+
+var debugLastCmd = &cobra.Command{
+	Use:   "last",
+	Short: "Show the prompts and responses of the most recent --debug scan",
+	Long: `Show every prompt sent to the model and every response received during the
+most recent scan run with --debug, read from the structured debug log in
+~/.sidekick/debug. JSON responses are indented.
+
+Stages are "context", "scan", "json-repair", "custom", "triad-attacker",
+"triad-defender", "triad-auditor" and "triad-fix".`,
+	Example: `  sidekick debug last --file handlers/login.go --stage scan
+  sidekick debug last --stage json-repair --responses-only`,
+	Args: cobra.NoArgs,
+	RunE: runDebugLast,
+}
+
+func init() {
+	debugLastCmd.Flags().StringVar(&debugFile, "file", "", "Only show calls for files whose path contains this text")
+	debugLastCmd.Flags().StringVar(&debugStage, "stage", "", "Only show calls of this stage")
+	debugLastCmd.Flags().BoolVar(&debugResponse, "responses-only", false, "Omit the prompts")
+	debugCmd.AddCommand(debugLastCmd)
+}
+
+func runDebugLast(cmd *cobra.Command, args []string) error {
+	path, err := debuglog.Latest()
+	if err != nil {
+		return err
+	}
+	entries, err := debuglog.Read(path)
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for _, e := range entries {
+		if debugFile != "" && !strings.Contains(e.File, debugFile) {
+			continue
+		}
+		if debugStage != "" && !strings.EqualFold(e.Stage, debugStage) {
+			continue
+		}
+		shown++
+		printDebugEntry(shown, e)
+	}
+
+	if shown == 0 {
+		fmt.Printf("No model calls in %s match the filters (%d recorded).\n", path, len(entries))
+		return nil
+	}
+	fmt.Printf("%d of %d model call(s) from %s\n", shown, len(entries), path)
+	return nil
+}
+
+func printDebugEntry(n int, e debuglog.Entry) {
+	rule := strings.Repeat("=", 80)
+	header := fmt.Sprintf("#%d %s · %s · %s · %s", n, e.Stage, e.File, e.Model, (time.Duration(e.DurationMS) * time.Millisecond).Round(10*time.Millisecond))
+	if e.PromptTokens+e.CompletionTokens > 0 {
+		header += fmt.Sprintf(" · %d→%d tokens", e.PromptTokens, e.CompletionTokens)
+	}
+	fmt.Printf("%s\n%s\n%s\n", rule, header, rule)
+
+	if !debugResponse {
+		fmt.Printf("\n--- PROMPT ---\n%s\n", strings.TrimRight(e.Prompt, "\n"))
+	}
+	fmt.Printf("\n--- RESPONSE ---\n%s\n", prettyResponse(e.Response))
+	if e.Error != "" {
+		fmt.Printf("\n--- ERROR ---\n%s\n", e.Error)
+	}
+	fmt.Println()
+}
+
+// prettyResponse indents responses that are JSON, possibly inside a
+// markdown code fence, and returns others unchanged.
+func prettyResponse(response string) string {
+	trimmed := strings.TrimSpace(response)
+	body := trimmed
+	if strings.HasPrefix(body, "```") {
+		body = strings.TrimPrefix(body, "```json")
+		body = strings.TrimPrefix(body, "```")
+		body = strings.TrimSuffix(strings.TrimSpace(body), "```")
+	}
+	var buf bytes.Buffer
+	if json.Indent(&buf, []byte(strings.TrimSpace(body)), "", "  ") == nil {
+		return buf.String()
+	}
+	return trimmed
+}
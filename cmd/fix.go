@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/report"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixDryRun     bool
+	fixSeverity   string
+	fixConfidence string
+	fixBranch     string
+	fixTrust      bool
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix <report.json>",
+	Short: "Apply the suggested fixes from a JSON scan report",
+	Long: `Apply the suggested fixes of an earlier scan, saved with --format json, as
+scan --autofix does: every fix for a finding at or above --min-severity with at
+least --confidence is applied as a patch, the originals are backed up (or the
+fixes committed on --branch) and one diff of all changes is printed. A fix is
+refused if the code around it changed since the scan.
+
+With --dry-run nothing is touched: the combined diff of every fix that would
+be applied is printed with the findings they address.
+
+Fixes are only applied in trusted workspaces; pass --trust to trust the
+scanned one (see "sidekick trust").`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFix,
+}
+
+func init() {
+	fixCmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "Print the combined diff and the findings it fixes without changing any file")
+	fixCmd.Flags().StringVar(&fixSeverity, "min-severity", "low", "Only fix findings at or above this severity (low, medium, high, critical)")
+	fixCmd.Flags().StringVar(&fixConfidence, "confidence", "high", "Only fix findings with at least this confidence (low, medium, high)")
+	fixCmd.Flags().StringVar(&fixBranch, "branch", "", "Commit the fixes on this new git branch instead of backing up the files")
+	fixCmd.Flags().BoolVar(&fixTrust, "trust", false, "Trust the scanned workspace (remembered) so the fixes may be applied")
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	if config.SeverityRank(fixSeverity) == 0 {
+		return fmt.Errorf("unknown --min-severity %q (expected low, medium, high or critical)", fixSeverity)
+	}
+	if config.SeverityRank(fixConfidence) == 0 || strings.EqualFold(fixConfidence, "critical") {
+		return fmt.Errorf("unknown --confidence %q (expected low, medium or high)", fixConfidence)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+	rep, err := report.ReadJSON(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	fmt.Printf("📄 Fixes from the scan of %s (%s)\n", rep.Scan.Target, rep.Scan.Model)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	trusted, err := applyTrust(cfg, rep.Scan.Target, fixTrust)
+	if err != nil {
+		return err
+	}
+	if !trusted && !fixDryRun {
+		return fmt.Errorf("%s is not a trusted workspace; pass --trust to apply the fixes, or --dry-run to preview them", config.Workspace(rep.Scan.Target))
+	}
+
+	results := rep.ScanResults()
+	if err := checkFixPaths(cfg, rep.Scan.Target, results); err != nil {
+		return err
+	}
+
+	return runAutofix(results, scanner.AutofixOptions{
+		MinSeverity:   strings.ToUpper(fixSeverity),
+		MinConfidence: strings.ToUpper(fixConfidence),
+		Branch:        fixBranch,
+		DryRun:        fixDryRun,
+	})
+}
+
+// checkFixPaths refuses a report naming files outside its scan target or
+// outside trusted workspaces, since the fixes are written to every file it
+// names: a crafted or stale report must not rewrite other files.
+func checkFixPaths(cfg *config.Config, target string, results []scanner.ScanResult) error {
+	root := resolvePath(target)
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			file := cmp.Or(issue.File, result.FilePath)
+			path := resolvePath(file)
+			if path != root && !config.Within(root, path) {
+				return fmt.Errorf("%s is outside the scanned %s; refusing to apply fixes from this report", file, target)
+			}
+			if !fixDryRun && !cfg.Trusted(file) {
+				return fmt.Errorf("%s is not in a trusted workspace; pass --trust to apply the fixes, or --dry-run to preview them", file)
+			}
+		}
+	}
+	return nil
+}
+
+// resolvePath returns path made absolute, with symlinks resolved when it
+// exists.
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return path
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/github"
+	"github.com/pefman/sidekick/internal/knowledge"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
+)
+
+// githubToken returns the token for the GitHub API from GITHUB_TOKEN or
+// GH_TOKEN.
+func githubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// postPRReview posts the findings as one review on pr. Findings on lines in
+// the pull request's diff become comments on those lines, with the
+// suggested fix as a suggestion block; the others are listed in the review
+// body. root is the repository root that GitHub paths are relative to.
+func postPRReview(pr github.PullRequest, root string, results []scanner.ScanResult) error {
+	client := github.NewClient(os.Getenv("GITHUB_API_URL"), githubToken())
+	commit, err := client.HeadCommit(pr)
+	if err != nil {
+		return fmt.Errorf("failed to read pull request %s: %w", pr, err)
+	}
+	diff, err := client.CommentableLines(pr)
+	if err != nil {
+		return fmt.Errorf("failed to read pull request %s files: %w", pr, err)
+	}
+
+	var comments []github.ReviewComment
+	var outside []string
+	total := 0
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			total++
+			path := result.FilePath
+			if issue.File != "" {
+				path = issue.File
+			}
+			rel := relativeTo(root, path)
+
+			comment, ok := reviewComment(issue, rel, diff[rel])
+			if !ok {
+				outside = append(outside, fmt.Sprintf("- %s %s **%s** — `%s:%d`",
+					ui.SeverityEmoji(issue.Severity), strings.ToUpper(issue.Severity), issue.Title, rel, issue.LineStart))
+				continue
+			}
+			comments = append(comments, comment)
+		}
+	}
+
+	var body strings.Builder
+	if total == 0 {
+		body.WriteString("✅ Sidekick found no issues.")
+	} else {
+		fmt.Fprintf(&body, "Sidekick found %d issue(s), %d on lines changed in this pull request.", total, len(comments))
+	}
+	if len(outside) > 0 {
+		body.WriteString("\n\n**Outside the diff:**\n")
+		body.WriteString(strings.Join(outside, "\n"))
+	}
+
+	url, err := client.CreateReview(pr, commit, body.String(), comments)
+	if err != nil {
+		return fmt.Errorf("failed to post review on %s: %w", pr, err)
+	}
+	fmt.Printf("💬 Posted %d review comment(s) on %s: %s\n", len(comments), pr, url)
+	return nil
+}
+
+// relativeTo returns path relative to root with forward slashes, as
+// GitHub reports file names.
+func relativeTo(root, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// reviewComment places issue on the diff lines of path. A finding spanning
+// several lines is commented on all of them when they are in the same hunk,
+// otherwise on its first line; a finding whose first line isn't in the diff
+// can't be commented on.
+func reviewComment(issue scanner.SecurityIssue, path string, hunks []github.LineRange) (github.ReviewComment, bool) {
+	for _, h := range hunks {
+		if issue.LineStart < h.Start || issue.LineStart > h.End {
+			continue
+		}
+		comment := github.ReviewComment{Path: path, Line: issue.LineStart}
+		whole := issue.LineEnd <= issue.LineStart
+		if issue.LineEnd > issue.LineStart && issue.LineEnd <= h.End {
+			comment.StartLine = issue.LineStart
+			comment.Line = issue.LineEnd
+			whole = true
+		}
+		// A suggestion replaces the lines commented on, so it is only
+		// offered when those are exactly the flagged lines
+		comment.Body = reviewCommentBody(issue, whole)
+		return comment, true
+	}
+	return github.ReviewComment{}, false
+}
+
+// reviewCommentBody renders a finding as Markdown. With suggest, the
+// suggested fix becomes a GitHub suggestion block.
+func reviewCommentBody(issue scanner.SecurityIssue, suggest bool) string {
+	sev := strings.ToUpper(issue.Severity)
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s %s: %s**", ui.SeverityEmoji(sev), sev, issue.Title)
+	if ref, ok := knowledge.Lookup(issue.IssueID); ok {
+		label := ref.ID
+		if ref.Name != "" {
+			label += " " + ref.Name
+		}
+		fmt.Fprintf(&b, " · [%s](%s)", label, ref.URL)
+	} else if issue.IssueID != "" {
+		fmt.Fprintf(&b, " · %s", issue.IssueID)
+	}
+	b.WriteString("\n\n")
+	if issue.Description != "" {
+		b.WriteString(issue.Description + "\n\n")
+	}
+	if issue.Recommendation != "" {
+		b.WriteString("**Recommendation:** " + issue.Recommendation + "\n\n")
+	}
+	if fix := strings.TrimRight(issue.SuggestedFix, "\n"); fix != "" {
+		fence := "```"
+		if strings.Contains(fix, fence) {
+			fence = "````"
+		}
+		if suggest {
+			fmt.Fprintf(&b, "%ssuggestion\n%s\n%s\n", fence, fix, fence)
+		} else {
+			fmt.Fprintf(&b, "**Suggested fix:**\n%s\n%s\n%s\n", fence, fix, fence)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/scanner"
+)
+
+// findingGroup is a set of findings sharing a CWE, file or severity.
+type findingGroup struct {
+	Key    string
+	Label  string
+	Issues []scanner.SecurityIssue
+}
+
+// groupFindings groups all findings by "cwe", "file" or "severity".
+// Severity groups are ordered CRITICAL to LOW; other groups by size.
+func groupFindings(results []scanner.ScanResult, by string) ([]findingGroup, error) {
+	byKey := make(map[string]*findingGroup)
+	var order []string
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			var key, label string
+			switch by {
+			case "cwe":
+				key = issue.IssueID
+				if key == "" {
+					key = "(no ID) " + issue.Title
+				}
+				label = issue.Title
+			case "file":
+				key = issue.File
+				if key == "" {
+					key = result.FilePath
+				}
+			case "severity":
+				key = strings.ToUpper(issue.Severity)
+			default:
+				return nil, fmt.Errorf("unknown --group-by %q (expected cwe, file or severity)", by)
+			}
+			g, ok := byKey[key]
+			if !ok {
+				g = &findingGroup{Key: key, Label: label}
+				byKey[key] = g
+				order = append(order, key)
+			}
+			g.Issues = append(g.Issues, issue)
+		}
+	}
+
+	groups := make([]findingGroup, 0, len(order))
+	for _, key := range order {
+		groups = append(groups, *byKey[key])
+	}
+
+	if by == "severity" {
+		rank := map[string]int{"CRITICAL": 0, "HIGH": 1, "MEDIUM": 2, "LOW": 3}
+		sort.SliceStable(groups, func(i, j int) bool {
+			ri, ok := rank[groups[i].Key]
+			if !ok {
+				ri = len(rank)
+			}
+			rj, ok := rank[groups[j].Key]
+			if !ok {
+				rj = len(rank)
+			}
+			return ri < rj
+		})
+	} else {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return len(groups[i].Issues) > len(groups[j].Issues)
+		})
+	}
+	return groups, nil
+}
+
+// displayGroups prints one line per group, e.g.
+// "12 × CWE-798 Hardcoded Credentials".
+func displayGroups(groups []findingGroup, by string) {
+	if len(groups) == 0 {
+		return
+	}
+	title := map[string]string{"cwe": "CWE", "file": "file", "severity": "severity"}[by]
+	fmt.Printf("\n\033[38;5;208m📚 Findings by %s\033[0m\n", title)
+	for _, g := range groups {
+		line := g.Key
+		if g.Label != "" && !strings.HasSuffix(g.Key, g.Label) {
+			line += " " + g.Label
+		}
+		fmt.Printf("   %3d × %s\n", len(g.Issues), line)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/lsp"
+	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lspModel    string
+	lspScanType string
+	lspBackend  string
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server that scans files on save for editors",
+	Long: `Speak the Language Server Protocol on stdin and stdout so editors can show
+findings inline. Every saved file is scanned; findings are published as
+diagnostics on the flagged lines, and suggested fixes are offered as quick
+fixes. Progress and errors go to stderr.`,
+	Args: cobra.NoArgs,
+	RunE: runLSP,
+}
+
+func init() {
+	cfg, _ := config.Load()
+	if cfg == nil {
+		cfg = config.GetDefault()
+	}
+	lspCmd.Flags().StringVarP(&lspModel, "model", "m", cfg.DefaultModel, "Ollama model to use")
+	lspCmd.Flags().StringVarP(&lspScanType, "scan-type", "t", cfg.ScanType(), "Scan type: "+strings.Join(scanner.EngineNames(), ", "))
+	lspCmd.Flags().StringVar(&lspBackend, "backend", "ollama", "Model backend: ollama, mock (canned findings, no Ollama needed)")
+}
+
+func runLSP(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		cfg = config.GetDefault()
+	}
+	// The flag default was read before --profile was parsed
+	if !cmd.Flags().Changed("model") {
+		lspModel = cfg.DefaultModel
+	}
+	if _, ok := scanner.LookupEngine(lspScanType); !ok {
+		return fmt.Errorf("unknown scan type %q (available: %s)", lspScanType, strings.Join(scanner.EngineNames(), ", "))
+	}
+
+	var client *ollama.Client
+	switch lspBackend {
+	case "ollama":
+		client = ollama.NewClient(cfg.OllamaURL)
+	case "mock":
+		client = ollama.NewMockClient()
+	default:
+		return fmt.Errorf("unknown backend %q (expected ollama or mock)", lspBackend)
+	}
+	client.SetOptions(configGenerationOptions(cfg))
+	client.SetMaxInFlight(cfg.MaxInFlight)
+	if err := client.CheckModel(lspModel); err != nil {
+		return fmt.Errorf("model check failed: %w\nMake sure Ollama is running and the model is installed", err)
+	}
+
+	// stdout carries the protocol, unfiltered by plain output; everything
+	// the scanner prints goes to stderr, which editors keep as the server's
+	// log
+	protocol := ui.Stdout()
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	scan := func(ctx context.Context, path string) ([]lsp.Diagnostic, error) {
+		return lspScan(ctx, cfg, client, path)
+	}
+	fmt.Fprintf(os.Stderr, "sidekick lsp: scanning saved files with %s (%s)\n", lspModel, lspScanType)
+	return lsp.NewServer(scan, "sidekick").Serve(os.Stdin, protocol)
+}
+
+// lspScan scans one saved file and returns its findings as diagnostics.
+func lspScan(ctx context.Context, cfg *config.Config, client *ollama.Client, path string) ([]lsp.Diagnostic, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := scanner.NewScanner(client, lspModel, cfg.Debug, lspScanType, "")
+	defer s.Close()
+	s.SetSeverityOverrides(cfg.SeverityOverrides)
+	s.SetOrgContext(cfg.Context)
+	s.SetChunking(cfg.ChunkSize, cfg.ChunkOverlap)
+	s.SetContextCache(lspBackend == "ollama")
+	s.SetResultCache(lspBackend == "ollama")
+	s.SetStructuredOutput(cfg.StructuredOutputs())
+	s.SetIncludeGenerated(cfg.IncludeGenerated)
+	s.SetQuarantine(cfg.Quarantines())
+	if cfg.JSONRetries != nil {
+		s.SetJSONRetries(*cfg.JSONRetries)
+	}
+	root, err := scanner.RepoRoot(filepath.Dir(path))
+	if err != nil {
+		root = filepath.Dir(path)
+	}
+	s.SetPolicies(root, cfg.Policies)
+
+	results, err := s.ScanFiles(ctx, []string{path})
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if content, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(string(content), "\n")
+	}
+	diagnostics := []lsp.Diagnostic{}
+	for _, result := range results {
+		for _, line := range result.Quarantined {
+			diagnostics = append(diagnostics, lsp.Diagnostic{
+				Range:    lsp.Range{Start: lsp.Position{Line: line - 1}, End: lsp.Position{Line: line}},
+				Severity: lsp.SeverityWarning,
+				Code:     "prompt-injection",
+				Source:   "sidekick",
+				Message:  "Possible prompt injection: text addressed to the AI reviewer, neutralized before scanning",
+			})
+		}
+		for _, issue := range result.Issues {
+			if issue.File != "" && issue.File != path {
+				continue // Reported on another file of a triad scan
+			}
+			diagnostics = append(diagnostics, issueDiagnostic(issue, lines))
+		}
+	}
+	return diagnostics, nil
+}
+
+// issueDiagnostic turns a finding into a diagnostic covering its lines,
+// with its suggested fix, if any, as a quick fix.
+func issueDiagnostic(issue scanner.SecurityIssue, lines []string) lsp.Diagnostic {
+	start, end := issue.LineStart, issue.LineEnd
+	if start < 1 {
+		start = 1
+	}
+	if end < start {
+		end = start
+	}
+
+	message := issue.Title
+	if issue.Description != "" {
+		message += ": " + issue.Description
+	}
+	if issue.Recommendation != "" {
+		message += "\n\nRecommendation: " + issue.Recommendation
+	}
+	d := lsp.Diagnostic{
+		Range: lsp.Range{
+			Start: lsp.Position{Line: start - 1},
+			End:   lsp.Position{Line: end},
+		},
+		Severity: diagnosticSeverity(issue.Severity),
+		Code:     issue.IssueID,
+		Source:   "sidekick",
+		Message:  message,
+	}
+
+	if issue.FixAvailable && issue.SuggestedFix != "" && len(lines) > 0 {
+		fixStart, fixEnd, fixLines := scanner.FixEdit(lines, issue)
+		fix := &lsp.QuickFix{
+			Title: "Apply Sidekick fix: " + issue.Title,
+			Range: lsp.Range{
+				Start: lsp.Position{Line: fixStart - 1},
+				End:   lsp.Position{Line: fixEnd},
+			},
+			NewText: strings.Join(fixLines, "\n") + "\n",
+		}
+		if fixEnd == len(lines) {
+			// The last line has no newline to replace
+			last := lines[fixEnd-1]
+			fix.Range.End = lsp.Position{Line: fixEnd - 1, Character: len(utf16.Encode([]rune(last)))}
+			fix.NewText = strings.TrimSuffix(fix.NewText, "\n")
+		}
+		d.Data = fix
+	}
+	return d
+}
+
+// diagnosticSeverity maps a finding's severity to how prominently editors
+// show it.
+func diagnosticSeverity(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL", "HIGH":
+		return lsp.SeverityError
+	case "MEDIUM":
+		return lsp.SeverityWarning
+	case "LOW":
+		return lsp.SeverityInformation
+	}
+	return lsp.SeverityHint
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// policyGroup is a set of files scanned with the same scan type.
+type policyGroup struct {
+	ScanType string
+	Files    []string
+}
+
+// applyPolicies drops files in excluded subtrees and groups the rest by the
+// scan type their policy selects (defaultType when none does). Groups keep
+// the order in which their scan type first appears.
+func applyPolicies(root string, files []string, policies []config.Policy, defaultType string) []policyGroup {
+	if len(policies) == 0 {
+		return []policyGroup{{ScanType: defaultType, Files: files}}
+	}
+
+	var groups []policyGroup
+	index := make(map[string]int)
+	for _, file := range files {
+		scanType := defaultType
+		if p := config.PolicyFor(policies, root, file); p != nil {
+			if p.Exclude {
+				continue
+			}
+			if p.ScanType != "" {
+				scanType = p.ScanType
+			}
+		}
+		i, ok := index[scanType]
+		if !ok {
+			i = len(groups)
+			index[scanType] = i
+			groups = append(groups, policyGroup{ScanType: scanType})
+		}
+		groups[i].Files = append(groups[i].Files, file)
+	}
+	return groups
+}
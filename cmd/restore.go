@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pefman/sidekick/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [session|latest] [file]",
+	Short: "List or restore files backed up before fixes were applied",
+	Long: `Every review session that applies a fix first saves the original files under
+~/.sidekick/backups/<session>. Without arguments, list the sessions and their
+files. With a session ID (or "latest"), restore every file from it; add a file
+path to restore just that file.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runRestore,
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return listBackups()
+	}
+
+	session, err := backup.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	entries := session.Files
+	if len(args) == 2 {
+		target, err := filepath.Abs(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+		entries = nil
+		for _, e := range session.Files {
+			if e.Original == target {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("session %s has no backup of %s", session.ID, target)
+		}
+	}
+
+	for _, e := range entries {
+		if err := session.Restore(e); err != nil {
+			return err
+		}
+		fmt.Printf("↩️  Restored %s\n", e.Original)
+	}
+	return nil
+}
+
+func listBackups() error {
+	sessions, err := backup.List()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No backups yet. Files are backed up when review mode applies a fix.")
+		return nil
+	}
+
+	for _, s := range sessions {
+		fmt.Printf("%s  (%s, %d files)\n", s.ID, s.Created.Format("2006-01-02 15:04"), len(s.Files))
+		for _, e := range s.Files {
+			fmt.Printf("   %s\n", e.Original)
+		}
+	}
+	fmt.Println("\nRestore with: sidekick restore <session> [file]")
+	return nil
+}
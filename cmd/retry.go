@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/pefman/sidekick/internal/report"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// loadRetry loads the last scan for --retry-failed. It returns nil, after
+// saying so, when that scan has no failed files.
+func loadRetry(args []string) (*history.Session, error) {
+	switch {
+	case len(args) > 0:
+		return nil, fmt.Errorf("--retry-failed scans the failed files of the last scan again; drop the path argument")
+	case diffRef != "" || imageRef != "":
+		return nil, fmt.Errorf("--retry-failed cannot be combined with --diff or --image")
+	}
+	session, err := history.LoadSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no scan recorded yet; run a scan first")
+	}
+	if len(session.Failed) == 0 {
+		fmt.Printf("✅ No files failed in the last scan of %s\n", session.Target)
+		return nil, nil
+	}
+	return session, nil
+}
+
+// applyRetryDefaults makes a --retry-failed scan use the scan type, model
+// and report of the last scan, unless given as flags.
+func applyRetryDefaults(cmd *cobra.Command, session *history.Session) error {
+	if !cmd.Flags().Changed("scan-type") && session.ScanType != "" {
+		scanType = session.ScanType
+	}
+	if !cmd.Flags().Changed("model") && session.Model != "" {
+		modelName = session.Model
+	}
+	if !cmd.Flags().Changed("format") && session.Format != "" {
+		format = session.Format
+	}
+	if !cmd.Flags().Changed("output") {
+		outputPath = session.Output
+	}
+	if scanType == "custom" && customPrompt == "" {
+		return fmt.Errorf("the last scan used a custom prompt; pass it again with --prompt")
+	}
+	return nil
+}
+
+// retryGroups keeps the files of groups that failed in the last scan. It
+// also returns the failed files that are no longer collected, with why they
+// failed: still present but left out by the current policies or filters.
+// Failed files that no longer exist are dropped, and both are reported.
+func retryGroups(groups []policyGroup, failed map[string]string) ([]policyGroup, map[string]string) {
+	retried := make(map[string]bool, len(failed))
+	kept := groups[:0]
+	for _, g := range groups {
+		files := g.Files[:0]
+		for _, f := range g.Files {
+			if _, ok := failed[f]; ok {
+				files = append(files, f)
+				retried[f] = true
+			}
+		}
+		if len(files) > 0 {
+			g.Files = files
+			kept = append(kept, g)
+		}
+	}
+
+	unretried := make(map[string]string)
+	removed := 0
+	for f, why := range failed {
+		switch _, err := os.Stat(f); {
+		case retried[f]:
+		case os.IsNotExist(err):
+			removed++
+		default:
+			unretried[f] = why
+		}
+	}
+	if n := len(unretried); n > 0 {
+		fmt.Printf("⏭️  Skipping %d failed file(s) left out by the current policies or filters; they stay listed as failed\n", n)
+	}
+	if removed > 0 {
+		fmt.Printf("🗑️  Dropping %d failed file(s) that no longer exist\n", removed)
+	}
+	return kept, unretried
+}
+
+// mergeRetry adds the results of the files scanned again to the results of
+// the last scan, replacing any earlier result of the same file. It also
+// returns the last scan's report, for the metadata of the merged one.
+func mergeRetry(session *history.Session, results []scanner.ScanResult) ([]scanner.ScanResult, *report.JSONReport, error) {
+	prev, err := report.ReadJSON(session.Report)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the last scan's results: %w", err)
+	}
+	rescanned := make(map[string]bool, len(results))
+	for _, r := range results {
+		rescanned[r.FilePath] = true
+	}
+	merged := make([]scanner.ScanResult, 0, len(prev.Results)+len(results))
+	for _, r := range prev.ScanResults() {
+		if !rescanned[r.FilePath] {
+			merged = append(merged, r)
+		}
+	}
+	merged = append(merged, results...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].FilePath < merged[j].FilePath })
+	return merged, prev, nil
+}
+
+// saveSession records the scan, with every result before --min-severity
+// and the files that failed, for a later --retry-failed. Failures are
+// reported but never fail the scan.
+func saveSession(results []scanner.ScanResult, failed map[string]string, meta report.Metadata, output string, started time.Time) {
+	data, err := json.Marshal(report.BuildJSON(results, report.JSONMetadata{
+		Metadata:    meta,
+		ToolVersion: updater.Version,
+		ScanType:    scanType,
+		StartedAt:   started,
+		FinishedAt:  time.Now(),
+	}))
+	if err == nil {
+		err = history.SaveSession(history.Session{
+			Time:     started,
+			Target:   targetPath,
+			Model:    modelName,
+			ScanType: scanType,
+			Format:   format,
+			Output:   output,
+			Failed:   failed,
+			Report:   data,
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to save the scan session: %v\n", err)
+	}
+}
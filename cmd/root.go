@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"errors"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
 	"github.com/pefman/sidekick/internal/interactive"
+	"github.com/pefman/sidekick/internal/ui"
 	"github.com/pefman/sidekick/internal/updater"
 	"github.com/spf13/cobra"
 )
@@ -14,6 +19,21 @@ your codebase for security vulnerabilities and provide insights.
 
 Run without arguments to launch interactive mode.`,
 	Version: updater.Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		config.SelectProfile(profile)
+		if _, err := config.Load(); errors.Is(err, config.ErrUnknownProfile) {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if statusRefresh > 0 {
+			ui.SetRefreshInterval(statusRefresh)
+		}
+		if !plain {
+			return nil
+		}
+		ui.SetPlain(true)
+		return ui.StartPlainOutput()
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no subcommand, run interactive mode
 		im := interactive.New()
@@ -21,12 +41,47 @@ Run without arguments to launch interactive mode.`,
 	},
 }
 
+var (
+	plain         bool
+	statusRefresh time.Duration
+	profile       string
+)
+
 func Execute() error {
+	defer ui.StopPlainOutput()
 	return rootCmd.Execute()
 }
 
 func init() {
+	cfg, _ := config.Load()
+	if cfg == nil {
+		cfg = config.GetDefault()
+	}
+	for sev, style := range cfg.SeverityStyles {
+		ui.SetSeverityStyle(sev, style.Emoji, style.Color)
+	}
+	ui.SetEditorURL(cfg.EditorURL) // Invalid templates are reported by "sidekick validate"
+	rootCmd.PersistentFlags().BoolVar(&plain, "plain", cfg.Plain, "Screen-reader-friendly output: no spinners, colors, emoji or screen clearing")
+	refreshDefault, err := time.ParseDuration(cfg.StatusRefresh)
+	if err != nil {
+		refreshDefault = ui.DefaultRefreshInterval
+	}
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Use this config profile's Ollama URL, model and options (default: $SIDEKICK_PROFILE, then the saved profile)")
+	rootCmd.PersistentFlags().DurationVar(&statusRefresh, "status-refresh", refreshDefault, "How often the progress spinner redraws, e.g. 250ms (slow terminals, SSH)")
+
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(webCmd)
+	rootCmd.AddCommand(validateReportCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(badgeCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(lspCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(suppressionsCmd)
+	rootCmd.AddCommand(fixCmd)
+	rootCmd.AddCommand(trustCmd)
 }
@@ -1,22 +1,94 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/github"
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/pefman/sidekick/internal/image"
+	"github.com/pefman/sidekick/internal/notify"
 	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/prompts"
+	"github.com/pefman/sidekick/internal/report"
 	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/pefman/sidekick/internal/updater"
+	"github.com/pefman/sidekick/internal/walker"
 	"github.com/spf13/cobra"
 )
 
 var (
-	targetPath string
-	modelName  string
-	debug      bool
-	scanType   string
+	targetPath   string
+	modelName    string
+	debug        bool
+	scanType     string
+	blame        bool
+	emailTo      []string
+	githubPR     string
+	recordPath   string
+	replayPath   string
+	backend      string
+	samples      int
+	models       []string
+	ensemble     string
+	temperature  float64
+	seed         int
+	topP         float64
+	numCtx       int
+	numPredict   int
+	maxInFlight  int
+	concurrency  int
+	autoTune     bool
+	jsonRetries  int
+	schema       bool
+	quarantine   bool
+	crossFile    bool
+	format       string
+	outputPath   string
+	groupBy      string
+	includeTests bool
+	includeGen   bool
+	customPrompt string
+	fields       []string
+	diffRef      string
+	diffFull     bool
+	noCache      bool
+	includeGlobs []string
+	excludeGlobs []string
+	ciMode       bool
+	failOn       string
+	minSeverity  string
+	reviewAfter  bool
+	fastScan     bool
+	imageRef     string
+	assumeYes    bool
+	trustFlag    bool
+	retryFailed  bool
+
+	autofix           string
+	autofixConfidence string
+	autofixBranch     string
+
+	staticGate   string
+	gateModel    string
+	gateSeverity string
+
+	scanTimeout time.Duration
+	fileTimeout time.Duration
+
+	triadMaxRounds int
+	triadMaxTokens int
+	triadTimeout   time.Duration
 )
 
 var scanCmd = &cobra.Command{
@@ -35,10 +107,247 @@ func init() {
 
 	scanCmd.Flags().StringVarP(&modelName, "model", "m", cfg.DefaultModel, "Ollama model to use")
 	scanCmd.Flags().BoolVarP(&debug, "debug", "d", cfg.Debug, "Enable debug logging to file")
-	scanCmd.Flags().StringVarP(&scanType, "scan-type", "t", "security", "Scan type: security, custom, triad")
+	scanCmd.Flags().StringVarP(&scanType, "scan-type", "t", cfg.ScanType(), "Scan type: "+strings.Join(scanner.EngineNames(), ", "))
+	scanCmd.Flags().StringVarP(&format, "format", "f", cfg.OutputFormat(), "Output format: text, or a report format: "+strings.Join(report.FormatterNames(), ", "))
+	scanCmd.Flags().StringVar(&customPrompt, "prompt", "", "Run this custom prompt against each file (implies --scan-type custom)")
+	scanCmd.Flags().StringSliceVar(&fields, "fields", nil, "With --prompt, ask for a structured answer with these fields and show it as a table")
+	scanCmd.Flags().BoolVar(&includeTests, "include-tests", cfg.IncludeTests, "Also scan test files and directories (skipped by default)")
+	scanCmd.Flags().BoolVar(&includeGen, "include-generated", cfg.IncludeGenerated, "Also scan generated code: lockfiles, protobuf output, files marked DO NOT EDIT (skipped by default; findings are tagged generated-code and don't count for --fail-on)")
+	scanCmd.Flags().StringSliceVar(&includeGlobs, "include", nil, "Only scan files matching these globs (gitignore syntax, e.g. 'src/**/*.go')")
+	scanCmd.Flags().StringSliceVar(&excludeGlobs, "exclude", nil, "Skip files and directories matching these globs (gitignore syntax, e.g. 'legacy/,*.min.js')")
+	scanCmd.Flags().StringVar(&groupBy, "group-by", "", "Summarize findings by cwe, file or severity")
+	scanCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Report file for --format html (default: sidekick-report-<target>-<time>.html) or json and other report formats (default: stdout)")
+	scanCmd.Flags().StringVar(&imageRef, "image", "", "Scan the application files of this container image (pulled with docker or podman) or `docker save` archive instead of a path")
+	scanCmd.Flags().StringVar(&diffRef, "diff", "", "Only scan files changed against this git ref (default HEAD), annotating findings on changed lines")
+	scanCmd.Flags().Lookup("diff").NoOptDefVal = "HEAD"
+	scanCmd.Flags().BoolVar(&diffFull, "diff-full", false, "With --diff, scan whole changed files instead of only the changed functions (Go, TypeScript, JavaScript, Python)")
+	scanCmd.Flags().BoolVar(&blame, "blame", false, "Annotate findings with the last author and commit (git blame)")
+	scanCmd.Flags().BoolVar(&fastScan, "fast", false, "Quick risk overview: one lightweight pass per file, without context analysis or suggested fixes (lower fidelity)")
+	scanCmd.Flags().IntVar(&samples, "samples", 1, "Run the security scan N times per file and keep findings reported by a majority")
+	scanCmd.Flags().StringSliceVar(&models, "models", nil, "Scan with several models and merge their findings (e.g. qwen2.5-coder:14b,deepseek-r1:14b)")
+	scanCmd.Flags().StringVar(&ensemble, "ensemble", "union", "How to merge findings from --models: union, intersection")
+	scanCmd.Flags().Float64Var(&temperature, "temperature", 0, "Sampling temperature for security scans (0 = deterministic)")
+	scanCmd.Flags().IntVar(&seed, "seed", config.DefaultSeed, "Random seed for reproducible security scans")
+	scanCmd.Flags().Float64Var(&topP, "top-p", 0, "Nucleus sampling threshold, e.g. 0.9 (default: the model's)")
+	scanCmd.Flags().IntVar(&numCtx, "num-ctx", config.DefaultNumCtx, "Context window in tokens; larger windows fit bigger files but need more memory")
+	scanCmd.Flags().IntVar(&numPredict, "num-predict", 0, "Maximum tokens per model answer, -1 for no limit (default: the model's)")
+	scanCmd.Flags().StringVar(&staticGate, "static-gate", cfg.StaticGate.Mode, "Check files against static patterns first; files without matches are skipped (skip) or scanned with --gate-model (fast)")
+	scanCmd.Flags().StringVar(&gateModel, "gate-model", cfg.StaticGate.Model, "Cheaper model for files without static matches with --static-gate fast")
+	scanCmd.Flags().StringVar(&gateSeverity, "gate-severity", cfg.StaticGate.MinSeverity, "Static matches below this severity don't count for --static-gate (default low)")
+	scanCmd.Flags().DurationVar(&scanTimeout, "timeout", 0, "Stop the whole scan after this long, e.g. 30m (0 = no limit)")
+	scanCmd.Flags().DurationVar(&fileTimeout, "file-timeout", 0, "Give up on a file after this long, e.g. 5m, and go on with the others (0 = no limit)")
+	triadTimeoutDefault, _ := time.ParseDuration(cfg.Triad.Timeout)
+	scanCmd.Flags().IntVar(&triadMaxRounds, "triad-max-rounds", cfg.Triad.MaxRounds, "Maximum attacker/defender/auditor rounds for triad scans (0 = 3)")
+	scanCmd.Flags().IntVar(&triadMaxTokens, "triad-max-tokens", cfg.Triad.MaxTokens, "Stop a triad scan once it has used this many tokens (0 = no limit)")
+	scanCmd.Flags().DurationVar(&triadTimeout, "triad-timeout", triadTimeoutDefault, "Stop a triad scan after this long, e.g. 10m (0 = no limit)")
+	concurrencyDefault := cfg.Concurrency
+	if concurrencyDefault == 0 {
+		concurrencyDefault = scanner.DefaultConcurrency
+	}
+	scanCmd.Flags().IntVar(&concurrency, "concurrency", concurrencyDefault, fmt.Sprintf("Files scanned at once per stage (1-%d)", scanner.MaxConcurrency))
+	scanCmd.Flags().IntVar(&maxInFlight, "max-in-flight", cfg.MaxInFlight, "Maximum concurrent requests to Ollama (0 = no limit)")
+	scanCmd.Flags().BoolVar(&autoTune, "autotune", cfg.AutoTune, "Start with one request to Ollama and adapt concurrency to its latency and errors")
+	jsonRetriesDefault := scanner.DefaultJSONRetries
+	if cfg.JSONRetries != nil {
+		jsonRetriesDefault = *cfg.JSONRetries
+	}
+	scanCmd.Flags().IntVar(&jsonRetries, "json-retries", jsonRetriesDefault, "Times to ask the model to correct a response that isn't valid JSON (0 = don't)")
+	scanCmd.Flags().BoolVar(&schema, "schema", cfg.StructuredOutputs(), "Constrain security scan answers to the findings JSON schema (needs Ollama 0.5+; --schema=false for older servers)")
+	scanCmd.Flags().BoolVar(&quarantine, "quarantine", cfg.Quarantines(), "Neutralize comments and strings addressed to the AI reviewer (\"ignore previous instructions\") before scanning")
+	scanCmd.Flags().BoolVar(&crossFile, "cross-file", cfg.CrossFileContexts(), "Add each file's cross-file context (calls between files, imports, signatures) to security scan prompts, to trace input across files")
+	scanCmd.Flags().BoolVar(&noCache, "no-cache", false, "Scan every file again instead of reusing cached results and analyses of unchanged files")
+	scanCmd.Flags().StringVar(&backend, "backend", "ollama", "Model backend: ollama, mock (canned findings, no Ollama needed)")
+	scanCmd.Flags().StringVar(&recordPath, "record", "", "Record all model requests and responses to this session file")
+	scanCmd.Flags().StringVar(&replayPath, "replay", "", "Replay model responses from a recorded session file instead of calling Ollama")
+	scanCmd.Flags().StringSliceVar(&emailTo, "email", nil, "Email the HTML report to these addresses (requires smtp in config)")
+	scanCmd.Flags().StringVar(&githubPR, "github-pr", "", "Post findings as review comments on this pull request, e.g. owner/repo#123 (token from GITHUB_TOKEN)")
+	scanCmd.Flags().BoolVar(&ciMode, "ci", false, "Print a fenced JSON summary block (counts, threshold, report paths) at the end for CI scripts")
+	scanCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only show and report findings at or above this severity (low, medium, high, critical); the summary still counts all")
+	scanCmd.Flags().BoolVar(&reviewAfter, "review", false, "Review the findings after the scan: show diffs, apply suggested fixes, mark false positives")
+	scanCmd.Flags().StringVar(&autofix, "autofix", "", "Apply every suggested fix for findings at or above this severity (default low when given without a value)")
+	scanCmd.Flags().Lookup("autofix").NoOptDefVal = "low"
+	scanCmd.Flags().StringVar(&autofixConfidence, "autofix-confidence", "high", "With --autofix, only apply fixes for findings with at least this confidence (low, medium, high)")
+	scanCmd.Flags().StringVar(&autofixBranch, "autofix-branch", "", "With --autofix, commit the fixes on this new git branch instead of backing up the files")
+	scanCmd.Flags().BoolVar(&trustFlag, "trust", false, "Trust the scanned workspace (remembered) so --review and --autofix may modify its files; untrusted workspaces are scanned in safe mode")
+	scanCmd.Flags().BoolVar(&retryFailed, "retry-failed", false, "Scan only the files that failed in the last scan (timeouts, invalid JSON) again, merging the results into its report")
+	scanCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation before scanning more than max_files files (default 500)")
+	scanCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit non-zero if there are findings at or above this severity (low, medium, high, critical)")
 }
 
 func runScan(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		cfg = config.GetDefault()
+	}
+	// --retry-failed scans files of the last scan's target
+	var retry *history.Session
+	if retryFailed {
+		if retry, err = loadRetry(args); err != nil || retry == nil {
+			return err
+		}
+		args = []string{retry.Target}
+	}
+	// The scanned project's .sidekick.yaml overrides the config file
+	projectCfg, err := findProject(args)
+	if err != nil {
+		return err
+	}
+	if projectCfg != nil {
+		problems := append(projectCfg.Validate(), scanTypeProblems("scan_type", projectCfg.ScanType)...)
+		if len(problems) > 0 {
+			return fmt.Errorf("%s: %s", projectCfg.Path, strings.Join(problems, "; "))
+		}
+		if err := prompts.SetTemplates(projectCfg.PromptTemplates); err != nil {
+			return fmt.Errorf("%s: prompt_templates: %w", projectCfg.Path, err)
+		}
+		projectCfg.Apply(cfg)
+		if !cmd.Flags().Changed("min-severity") && projectCfg.MinSeverity != "" {
+			minSeverity = projectCfg.MinSeverity
+		}
+		if !cmd.Flags().Changed("fail-on") && projectCfg.FailOn != "" {
+			failOn = projectCfg.FailOn
+		}
+	}
+	// Flag defaults were read before --profile and the project file were
+	// parsed
+	if !cmd.Flags().Changed("model") {
+		modelName = cfg.DefaultModel
+	}
+	if !cmd.Flags().Changed("scan-type") {
+		scanType = cfg.ScanType()
+	}
+	if !cmd.Flags().Changed("max-in-flight") {
+		maxInFlight = cfg.MaxInFlight
+	}
+	if retry != nil {
+		if err := applyRetryDefaults(cmd, retry); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := report.LookupFormatter(format); !ok && format != "text" {
+		return fmt.Errorf("unknown format %q (expected text, %s)", format, strings.Join(report.FormatterNames(), ", "))
+	}
+	if failOn != "" && config.SeverityRank(failOn) == 0 {
+		return fmt.Errorf("unknown --fail-on severity %q (expected low, medium, high or critical)", failOn)
+	}
+	if minSeverity != "" && config.SeverityRank(minSeverity) == 0 {
+		return fmt.Errorf("unknown --min-severity %q (expected low, medium, high or critical)", minSeverity)
+	}
+	minSeverity = strings.ToUpper(minSeverity)
+	if ciMode && reportOnStdout() {
+		return fmt.Errorf("--ci cannot be combined with --format %s on stdout; write the report with -o", format)
+	}
+	if reviewAfter && (ciMode || reportOnStdout()) {
+		return fmt.Errorf("--review needs a terminal; it cannot be combined with --ci or --format %s on stdout", format)
+	}
+	if autofix != "" {
+		switch {
+		case config.SeverityRank(autofix) == 0:
+			return fmt.Errorf("unknown --autofix severity %q (expected low, medium, high or critical)", autofix)
+		case autofixConfidence != "" && config.SeverityRank(autofixConfidence) == 0 || strings.EqualFold(autofixConfidence, "critical"):
+			return fmt.Errorf("unknown --autofix-confidence %q (expected low, medium or high)", autofixConfidence)
+		case reviewAfter:
+			return fmt.Errorf("--autofix cannot be combined with --review")
+		}
+	} else if autofixBranch != "" || cmd.Flags().Changed("autofix-confidence") {
+		return fmt.Errorf("--autofix-branch and --autofix-confidence require --autofix")
+	}
+	genOpts, err := generationOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	var pr github.PullRequest
+	if githubPR != "" {
+		if pr, err = github.ParsePullRequest(githubPR); err != nil {
+			return err
+		}
+		if githubToken() == "" {
+			return fmt.Errorf("--github-pr requires a token in GITHUB_TOKEN or GH_TOKEN")
+		}
+	}
+
+	// A report on stdout must not be mixed with progress output, so send
+	// that to stderr. Reports bypass the plain output filter
+	jsonOut := ui.Stdout()
+	if reportOnStdout() {
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+	}
+	if len(fields) > 0 && customPrompt == "" {
+		return fmt.Errorf("--fields requires --prompt")
+	}
+	if customPrompt != "" {
+		if cmd.Flags().Changed("scan-type") && scanType != "custom" {
+			return fmt.Errorf("--prompt cannot be used with --scan-type %s", scanType)
+		}
+		scanType = "custom"
+	}
+
+	if cmd.Flags().Changed("static-gate") && staticGate != "" && scanType != "security" {
+		return fmt.Errorf("--static-gate only applies to security scans")
+	}
+	if err := scanner.ValidateStaticGate(staticGate, gateModel, gateSeverity); err != nil {
+		return fmt.Errorf("--static-gate: %w", err)
+	}
+	if fastScan {
+		switch {
+		case scanType != "security":
+			return fmt.Errorf("--fast only applies to security scans")
+		case samples > 1 || len(models) > 0:
+			return fmt.Errorf("--fast runs a single pass; it cannot be combined with --samples or --models")
+		}
+	}
+
+	if concurrency < 1 || concurrency > scanner.MaxConcurrency {
+		return fmt.Errorf("--concurrency must be between 1 and %d", scanner.MaxConcurrency)
+	}
+
+	switch groupBy {
+	case "", "cwe", "file", "severity":
+	default:
+		return fmt.Errorf("unknown --group-by %q (expected cwe, file or severity)", groupBy)
+	}
+
+	// "--diff main" reads as a bare --diff followed by the path "main"; take
+	// it as the ref when no such path exists
+	if diffRef == "HEAD" && len(args) == 1 {
+		if _, err := os.Stat(args[0]); os.IsNotExist(err) {
+			diffRef, args = args[0], nil
+		}
+	}
+	if diffFull && diffRef == "" {
+		return fmt.Errorf("--diff-full requires --diff")
+	}
+	if imageRef != "" {
+		switch {
+		case len(args) > 0:
+			return fmt.Errorf("--image scans the image instead of a path; drop the path argument")
+		case diffRef != "":
+			return fmt.Errorf("--diff cannot be combined with --image")
+		case reviewAfter || autofix != "":
+			return fmt.Errorf("--review and --autofix cannot be combined with --image; the extracted files are deleted after the scan")
+		}
+	}
+
+	// Extract the image and scan its files like a directory
+	var img *image.Image
+	var imageDir string
+	if imageRef != "" {
+		if imageDir, err = os.MkdirTemp("", "sidekick-image-"); err != nil {
+			return fmt.Errorf("failed to create extraction directory: %w", err)
+		}
+		defer os.RemoveAll(imageDir)
+		ui.SetHyperlinks(false) // The extracted files are gone after the scan
+		fmt.Printf("📦 Extracting image: %s\n", imageRef)
+		if img, err = image.Extract(imageRef, imageDir); err != nil {
+			return err
+		}
+		fmt.Printf("📦 %d application files from %d layers\n", img.Files(), len(img.Layers))
+		args = []string{imageDir}
+	}
+
 	// Determine target path
 	if len(args) > 0 {
 		targetPath = args[0]
@@ -51,7 +360,6 @@ func runScan(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate and clean path to prevent directory traversal
-	var err error
 	targetPath, err = filepath.Abs(targetPath)
 	if err != nil {
 		return fmt.Errorf("invalid path: %w", err)
@@ -69,94 +377,737 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("path does not exist: %w", err)
 	}
 
-	fmt.Printf("🔍 Scanning: %s\n", targetPath)
-	fmt.Printf("🤖 Using model: %s\n\n", modelName)
+	// Files are only modified in trusted workspaces
+	trusted, err := applyTrust(cfg, targetPath, trustFlag && img == nil)
+	if err != nil {
+		return err
+	}
+	if autofix != "" && !trusted {
+		return fmt.Errorf("--autofix modifies files, but %s is not a trusted workspace; pass --trust or run \"sidekick trust %s\"", config.Workspace(targetPath), config.Workspace(targetPath))
+	}
+
+	if img != nil {
+		fmt.Printf("🔍 Scanning: %s\n", imageRef)
+	} else {
+		fmt.Printf("🔍 Scanning: %s\n", targetPath)
+	}
+	if projectCfg != nil {
+		fmt.Printf("📋 Project config: %s\n", projectCfg.Path)
+	}
+	if reviewAfter && !trusted {
+		fmt.Printf("🔒 Safe mode: %s is not a trusted workspace, so fixes won't be applied (--trust to allow)\n", config.Workspace(targetPath))
+	}
 
 	// Initialize Ollama client
-	client := ollama.NewClient("http://localhost:11434")
+	var client *ollama.Client
+	switch backend {
+	case "ollama":
+		client = ollama.NewClient(cfg.OllamaURL)
+	case "mock":
+		client = ollama.NewMockClient()
+		fmt.Println("🧪 Using mock backend (canned findings, Ollama is not called)")
+	default:
+		return fmt.Errorf("unknown backend %q (expected ollama or mock)", backend)
+	}
 
-	// Check if model is available
-	if err := client.CheckModel(modelName); err != nil {
-		return fmt.Errorf("model check failed: %w\nMake sure Ollama is running and the model is installed", err)
+	if recordPath != "" && replayPath != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+	if replayPath != "" {
+		if err := client.LoadReplay(replayPath); err != nil {
+			return err
+		}
+		fmt.Printf("⏪ Replaying session: %s\n\n", replayPath)
+	}
+	if recordPath != "" {
+		client.StartRecording()
+		defer func() {
+			if err := client.SaveRecording(recordPath); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Failed to save recording: %v\n", err)
+			} else {
+				fmt.Printf("⏺  Session recorded: %s\n", recordPath)
+			}
+		}()
+	}
+
+	client.SetOptions(genOpts)
+	client.SetMaxInFlight(maxInFlight)
+	if autoTune {
+		// --concurrency (or --max-in-flight) becomes the ceiling; without a
+		// configured concurrency the tuner may go up to the maximum
+		if !cmd.Flags().Changed("concurrency") && cfg.Concurrency == 0 {
+			concurrency = scanner.MaxConcurrency
+		}
+		ceiling := concurrency
+		if maxInFlight > 0 {
+			ceiling = maxInFlight
+		}
+		client.SetAutoTune(ceiling)
+	}
+	if fastScan {
+		fmt.Printf("⚡ Fast scan: one pass per file without context analysis or fixes; lower fidelity than a full scan\n\n")
 	}
 
 	// Initialize scanner
-	s := scanner.NewScanner(client, modelName, debug, scanType, "")
+	s := scanner.NewScanner(client, modelName, debug, scanType, scanner.CustomPrompt("", fields, customPrompt))
 	defer s.Close()
 
+	s.SetSeverityOverrides(cfg.SeverityOverrides)
+	s.SetOrgContext(cfg.Context)
+	s.SetChunking(cfg.ChunkSize, cfg.ChunkOverlap)
+	// Mock answers must not be cached, and sessions must record every call
+	useCache := !noCache && backend == "ollama" && recordPath == "" && replayPath == ""
+	s.SetContextCache(useCache)
+	s.SetResultCache(useCache)
+	s.SetConcurrency(concurrency)
+	s.SetJSONRetries(jsonRetries)
+	s.SetStructuredOutput(schema)
+	s.SetQuarantine(quarantine)
+	s.SetIncludeGenerated(includeGen)
+	s.SetFileTimeout(fileTimeout)
+	if err := s.SetStaticGate(staticGate, gateModel, gateSeverity); err != nil {
+		return err
+	}
+	s.SetBlame(blame)
+	s.SetSamples(samples)
+	s.SetFast(fastScan)
+	s.SetTriadBudget(triadMaxRounds, triadMaxTokens, triadTimeout)
+	if len(models) > 0 {
+		if err := s.SetEnsemble(models, ensemble); err != nil {
+			return err
+		}
+	}
+
+	ownersRoot := targetPath
+	if !info.IsDir() {
+		ownersRoot = filepath.Dir(targetPath)
+	}
+	var repoRoot string
+	if githubPR != "" {
+		if repoRoot, err = scanner.RepoRoot(ownersRoot); err != nil {
+			return fmt.Errorf("--github-pr: %w", err)
+		}
+	}
+	if co, err := scanner.LoadCodeOwners(ownersRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to read CODEOWNERS: %v\n", err)
+	} else if co != nil {
+		s.SetCodeOwners(co)
+	}
+
 	// Scan files
-	var files []string
-	if info.IsDir() {
-		files, err = collectFiles(targetPath)
+	opts := walker.Options{IncludeTests: includeTests, Include: includeGlobs, Exclude: excludeGlobs}
+	if projectCfg != nil {
+		opts.Ignore, opts.IgnoreBase = projectCfg.Ignore, projectCfg.Dir()
+	}
+	files, err := walker.Collect(targetPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to collect files: %w", err)
+	}
+
+	// Only keep files changed against the diff ref
+	if diffRef != "" {
+		changes, err := scanner.LoadDiff(ownersRoot, diffRef)
 		if err != nil {
-			return fmt.Errorf("failed to collect files: %w", err)
+			return err
 		}
-	} else {
-		files = []string{targetPath}
+		changed := files[:0]
+		for _, f := range files {
+			if changes.Contains(f) {
+				changed = append(changed, f)
+			}
+		}
+		files = changed
+		s.SetDiff(changes)
+		s.SetDiffFunctions(!diffFull)
+		fmt.Printf("🔀 %d files changed against %s\n", len(files), diffRef)
+	}
+
+	// Apply per-directory policies: excludes, scan types and severity floors
+	s.SetPolicies(ownersRoot, cfg.Policies)
+	groups := applyPolicies(ownersRoot, files, cfg.Policies, scanType)
+	var unretried map[string]string
+	if retry != nil {
+		groups, unretried = retryGroups(groups, retry.Failed)
+	}
+	files = nil
+	for _, g := range groups {
+		files = append(files, g.Files...)
 	}
 
 	if len(files) == 0 {
 		fmt.Println("No files to scan")
+		if retry != nil && len(unretried) < len(retry.Failed) {
+			// Forget the failed files that no longer exist
+			retry.Failed = unretried
+			if err := history.SaveSession(*retry); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Failed to save the scan session: %v\n", err)
+			}
+		}
+		if ciMode {
+			entry := historyEntry(targetPath, modelName, scanType, nil, ollama.TokenUsage{}, time.Now())
+			return writeCISummary(jsonOut, buildCISummary(entry, failOn, nil, ciReports{}))
+		}
 		return nil
 	}
 
-	fmt.Printf("📁 Found %d files to analyze\n\n", len(files))
+	if limit := cfg.FileLimit(); limit > 0 && len(files) > limit && !assumeYes {
+		ok, err := confirmLargeScan(len(files), limit)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if !ok {
+			fmt.Println("Scan cancelled")
+			return nil
+		}
+	}
 
-	// Scan each file
-	results, err := s.ScanFiles(files)
-	if err != nil {
-		return fmt.Errorf("scan failed: %w", err)
+	// Check the models are available, unless no scan type calls one (e.g.
+	// secrets and static scans)
+	usesModel := false
+	for _, g := range groups {
+		usesModel = usesModel || scanner.UsesModel(g.ScanType)
+	}
+	if usesModel {
+		fmt.Printf("🤖 Using model: %s\n\n", modelName)
+		fmt.Printf("🎛  Generation: %s\n\n", client.Options())
+		if err := client.CheckModel(modelName); err != nil {
+			return fmt.Errorf("model check failed: %w\nMake sure Ollama is running and the model is installed", err)
+		}
+		checkModels := append([]string(nil), models...)
+		if staticGate == scanner.StaticGateFast && scanType == "security" {
+			checkModels = append(checkModels, gateModel)
+		}
+		for _, m := range checkModels {
+			if err := client.CheckModel(m); err != nil {
+				return fmt.Errorf("model check failed: %w\nMake sure Ollama is running and the model is installed", err)
+			}
+		}
 	}
 
+	// Security scans see how each of their files connects to the others
+	if crossFile {
+		for _, g := range groups {
+			if g.ScanType == "security" {
+				s.SetProjectContext(scanner.BuildProjectContext(g.Files))
+				break
+			}
+		}
+	}
+
+	languages := walker.Languages(files)
+	if retry != nil {
+		fmt.Printf("🔁 Retrying %d of %d file(s) that failed in the last scan\n", len(files), len(retry.Failed))
+	}
+	fmt.Printf("📁 Found %d files to analyze\n", len(files))
+	if line := languageLine(languages); line != "" {
+		fmt.Printf("🗂️  %s\n", line)
+	}
+	fmt.Println()
+
+	// The first Ctrl-C stops queuing files and lets the ones in flight
+	// finish; the second cancels their model calls instead of leaving them
+	// running on the server. Either way the results so far are reported.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+	go func() {
+		select {
+		case <-interrupts:
+		case <-ctx.Done():
+			return
+		}
+		s.Stop()
+		fmt.Fprintf(os.Stderr, "\n⏹  Stopping: finishing the files in flight (Ctrl-C again to cancel them)\n")
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	if scanTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, scanTimeout)
+		defer cancelTimeout()
+	}
+
+	// Scan each group of files with its scan type
+	started := time.Now()
+	var results []scanner.ScanResult
+	var incomplete string
+	for _, g := range groups {
+		if len(groups) > 1 {
+			fmt.Printf("🧭 %s scan: %d files\n", g.ScanType, len(g.Files))
+		}
+		s.SetScanType(g.ScanType)
+		groupResults, err := s.ScanFiles(ctx, g.Files)
+		results = append(results, groupResults...)
+		switch {
+		case errors.Is(err, scanner.ErrStopped), errors.Is(err, context.Canceled):
+			incomplete = "interrupted"
+		case errors.Is(err, context.DeadlineExceeded):
+			incomplete = fmt.Sprintf("timed out after %s", scanTimeout)
+		case err != nil:
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		if incomplete != "" {
+			incomplete += fmt.Sprintf(": %d of %d files scanned", len(results), len(files))
+			break
+		}
+	}
+	if limit := client.AutoTuneLimit(); limit > 0 && backend == "ollama" && replayPath == "" {
+		fmt.Printf("🎛  Auto-tuned in-flight limit: %d\n", limit)
+	}
+	if img != nil {
+		imageResults(results, img, imageDir)
+		targetPath = imageRef
+	}
+
+	// Findings marked false positive in review mode stay hidden until
+	// their re-check date
+	suppressed := 0
+	if list, err := history.LoadSuppressions(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	} else {
+		results, suppressed = scanner.ApplySuppressions(results, list, time.Now())
+	}
+
+	// A retry completes the last scan's results and report; the failed files
+	// it skipped still have no result, so they stay failed
+	totalFiles := len(files)
+	failed := s.Failed()
+	for f, why := range unretried {
+		failed[f] = why
+	}
+	if retry != nil {
+		merged, prev, err := mergeRetry(retry, results)
+		if err != nil {
+			return err
+		}
+		results = merged
+		suppressed += prev.Scan.Suppressed
+		languages = prev.Scan.Languages
+		totalFiles = len(results) + len(failed)
+	}
+
+	// Display and report only the findings at or above --min-severity;
+	// history, notifications and --fail-on still count all of them
+	shown := scanner.FilterSeverity(results, minSeverity)
+	var totals map[string]int
+	if minSeverity != "" {
+		totals = scanner.CountSeverities(results)
+	}
+
+	// The project summary rolls up every finding, including those below
+	// --min-severity
+	project := scanner.SummarizeFindings(results)
+
 	// Display results
-	displayResults(results, client, modelName)
+	displayResults(shown, totals, suppressed, client, modelName)
+	displayProjectSummary(project)
+	displayLicenseSummary(scanner.SummarizeLicenses(results))
+	if groupBy != "" {
+		groups, err := groupFindings(shown, groupBy)
+		if err != nil {
+			return err
+		}
+		displayGroups(groups, groupBy)
+	}
+
+	if incomplete != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  Scan incomplete (%s); the results above are partial\n", incomplete)
+	}
+	if n := len(failed); n > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  %d file(s) failed to scan; scan them again with: sidekick scan --retry-failed\n", n)
+	}
+
+	var reports ciReports
+	if format == "html" {
+		path := outputPath
+		if path == "" {
+			path = cfg.ArtifactPath(report.GetDefaultReportPath(targetPath), targetPath)
+		}
+		if err := report.GenerateHTML(shown, report.Metadata{
+			ScanPath:       targetPath,
+			Model:          modelName,
+			TotalFiles:     totalFiles,
+			Generation:     client.Options().String(),
+			Incomplete:     incomplete,
+			Fast:           fastScan,
+			Suppressed:     suppressed,
+			MinSeverity:    minSeverity,
+			SeverityTotals: totals,
+			Summary:        &project,
+			Languages:      languages,
+		}, path); err != nil {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+		fmt.Printf("📄 Report saved: %s\n", path)
+		reports.HTML = path
+	}
+
+	if format != "text" && format != "html" {
+		if err := writeReport(jsonOut, shown, totals, &project, suppressed, totalFiles, languages, incomplete, client.Options(), started); err != nil {
+			return err
+		}
+		if format == "json" {
+			reports.JSON = outputPath
+		}
+	}
+
+	// Extracted image files are gone after the scan, so can't be retried
+	if img == nil {
+		output := outputPath
+		if format == "html" {
+			output = reports.HTML
+		}
+		saveSession(results, failed, report.Metadata{
+			ScanPath:   targetPath,
+			Model:      modelName,
+			TotalFiles: totalFiles,
+			Generation: client.Options().String(),
+			Fast:       fastScan,
+			Suppressed: suppressed,
+			Languages:  languages,
+		}, output, started)
+	}
+
+	if autofix != "" {
+		opts := scanner.AutofixOptions{
+			MinSeverity:   strings.ToUpper(autofix),
+			MinConfidence: strings.ToUpper(autofixConfidence),
+			Branch:        autofixBranch,
+		}
+		if err := runAutofix(shown, opts); err != nil {
+			return err
+		}
+	}
+	if reviewAfter {
+		// Custom prompts in edit mode answer with diffs rather than findings
+		review := scanner.ReviewSession
+		if scanType == "custom" {
+			review = scanner.ReviewEdits
+		}
+		if err := review(shown); err != nil {
+			return fmt.Errorf("review failed: %w", err)
+		}
+	}
+
+	// A partial scan isn't recorded, sent or posted as if it were complete
+	if incomplete != "" {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("scan %s", incomplete)
+	}
+
+	entry := recordHistory(results, client.TotalUsage(), started)
+	sendNotifications(cfg, results)
+
+	if len(emailTo) > 0 {
+		if err := emailReport(cfg, shown, totals, &project, suppressed, totalFiles, languages, client.Options().String()); err != nil {
+			return err
+		}
+	}
+
+	if githubPR != "" {
+		if err := postPRReview(pr, repoRoot, shown); err != nil {
+			return err
+		}
+	}
+
+	summary := buildCISummary(entry, failOn, scanner.CountGenerated(results), reports)
+	if ciMode {
+		if err := writeCISummary(jsonOut, summary); err != nil {
+			return err
+		}
+	}
+	if summary.Threshold != nil && !summary.Threshold.Passed {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d findings at or above %s (--fail-on)", summary.Threshold.Exceeded, summary.Threshold.FailOn)
+	}
 
 	return nil
 }
 
-func collectFiles(root string) ([]string, error) {
-	var files []string
+// reportOnStdout reports whether --format writes its report to stdout:
+// every format but text and html, unless --output is given.
+func reportOnStdout() bool {
+	return format != "text" && format != "html" && outputPath == ""
+}
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// writeReport writes the --format report to --output, or to stdout.
+// totals counts the findings before --min-severity filtered results,
+// summary rolls up all of them, suppressed counts those left out as false
+// positives, languages the collected files per language, and incomplete,
+// when set, says why the scan ended early.
+func writeReport(stdout *os.File, results []scanner.ScanResult, totals map[string]int, summary *scanner.ProjectSummary, suppressed, totalFiles int, languages []walker.LanguageStats, incomplete string, opts *ollama.Options, started time.Time) error {
+	meta := report.JSONMetadata{
+		Metadata: report.Metadata{
+			ScanPath:       targetPath,
+			Model:          modelName,
+			TotalFiles:     totalFiles,
+			Generation:     opts.String(),
+			Incomplete:     incomplete,
+			Fast:           fastScan,
+			Suppressed:     suppressed,
+			MinSeverity:    minSeverity,
+			SeverityTotals: totals,
+			Summary:        summary,
+			Languages:      languages,
+		},
+		ToolVersion: updater.Version,
+		ScanType:    scanType,
+		StartedAt:   started,
+		FinishedAt:  time.Now(),
+	}
+	if opts != nil {
+		meta.Temperature = opts.Temperature
+		meta.TopP = opts.TopP
+		meta.Seed = opts.Seed
+		meta.NumCtx = opts.NumCtx
+		meta.NumPredict = opts.NumPredict
+	}
+
+	data, err := report.Format(format, results, meta)
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("📄 Report saved: %s\n", outputPath)
+	return nil
+}
+
+// generationOptions resolves the generation options from flags, then
+// config, then defaults (temperature 0, fixed seed, DefaultNumCtx).
+func generationOptions(cmd *cobra.Command, cfg *config.Config) (*ollama.Options, error) {
+	opts := configGenerationOptions(cfg)
+	if cmd.Flags().Changed("temperature") {
+		if temperature < 0 {
+			return nil, fmt.Errorf("--temperature %g must not be negative", temperature)
+		}
+		opts.Temperature = &temperature
+	}
+	if cmd.Flags().Changed("seed") {
+		opts.Seed = &seed
+	}
+	if cmd.Flags().Changed("top-p") {
+		if topP <= 0 || topP > 1 {
+			return nil, fmt.Errorf("--top-p %g must be greater than 0 and at most 1", topP)
+		}
+		opts.TopP = &topP
+	}
+	if cmd.Flags().Changed("num-ctx") {
+		if numCtx < config.MinNumCtx {
+			return nil, fmt.Errorf("--num-ctx %d must be at least %d tokens", numCtx, config.MinNumCtx)
+		}
+		opts.NumCtx = &numCtx
+	}
+	if cmd.Flags().Changed("num-predict") {
+		if numPredict < -1 || numPredict == 0 {
+			return nil, fmt.Errorf("--num-predict %d must be positive, or -1 for no limit", numPredict)
 		}
+		opts.NumPredict = &numPredict
+	}
+	return opts, nil
+}
 
-		// Skip hidden directories and common ignore patterns
-		if info.IsDir() {
-			name := info.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" ||
-				name == "vendor" || name == "dist" || name == "build" {
-				return filepath.SkipDir
-			}
-			return nil
+// findProject returns the .sidekick.yaml governing the scan path argument,
+// or the working directory when there is none (or it names a git ref, as
+// in "--diff main"). Extracted images have no project file.
+func findProject(args []string) (*config.Project, error) {
+	if imageRef != "" {
+		return nil, nil
+	}
+	start := "."
+	if len(args) > 0 {
+		if _, err := os.Stat(args[0]); err == nil {
+			start = args[0]
 		}
+	}
+	return config.FindProject(start)
+}
+
+// configGenerationOptions returns the configured generation options, with
+// deterministic defaults and a large context window for anything unset.
+func configGenerationOptions(cfg *config.Config) *ollama.Options {
+	t := 0.0
+	if cfg.Temperature != nil {
+		t = *cfg.Temperature
+	}
+	sd := config.DefaultSeed
+	if cfg.Seed != nil {
+		sd = *cfg.Seed
+	}
+	nc := config.DefaultNumCtx
+	if cfg.NumCtx != nil {
+		nc = *cfg.NumCtx
+	}
+	return &ollama.Options{Temperature: &t, TopP: cfg.TopP, Seed: &sd, NumCtx: &nc, NumPredict: cfg.NumPredict}
+}
 
-		// Skip sensitive files
-		name := info.Name()
-		sensitiveFiles := []string{".env", ".env.local", ".env.production", "id_rsa", "id_ed25519", ".pem", ".key", ".pfx", ".p12"}
-		for _, sensitive := range sensitiveFiles {
-			if name == sensitive || strings.HasSuffix(name, sensitive) {
-				return nil // Skip this file
+// recordHistory appends this scan, including its token usage, to the local
+// history file and returns the entry. Failures are reported but never fail
+// the scan.
+func recordHistory(results []scanner.ScanResult, usage ollama.TokenUsage, started time.Time) history.Entry {
+	entry := historyEntry(targetPath, modelName, scanType, results, usage, started)
+	if err := history.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to record scan history: %v\n", err)
+	}
+	return entry
+}
+
+// historyEntry summarizes a finished scan for the history file.
+func historyEntry(target, model, scanType string, results []scanner.ScanResult, usage ollama.TokenUsage, started time.Time) history.Entry {
+	entry := history.Entry{
+		Time:             time.Now(),
+		Target:           target,
+		Model:            model,
+		ScanType:         scanType,
+		FilesScanned:     len(results),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		DurationMS:       time.Since(started).Milliseconds(),
+		BySeverity:       make(map[string]int),
+		ByCWE:            make(map[string]int),
+	}
+	for _, result := range results {
+		if result.HasIssues {
+			entry.FilesWithIssues++
+		}
+		for _, issue := range result.Issues {
+			entry.BySeverity[strings.ToUpper(issue.Severity)]++
+			if issue.IssueID != "" {
+				entry.ByCWE[issue.IssueID]++
 			}
 		}
+	}
+	return entry
+}
 
-		files = append(files, path)
+// sendNotifications posts a scan summary to every configured webhook.
+// Delivery failures are reported but never fail the scan.
+func sendNotifications(cfg *config.Config, results []scanner.ScanResult) {
+	notifiers := notify.FromConfig(cfg.Notifications)
+	if len(notifiers) == 0 {
+		return
+	}
 
-		return nil
-	})
+	summary := notify.Summary{
+		Target:       targetPath,
+		Model:        modelName,
+		FilesScanned: len(results),
+		BySeverity:   make(map[string]int),
+	}
+	for _, result := range results {
+		if result.HasIssues {
+			summary.FilesWithIssues++
+		}
+		for _, issue := range result.Issues {
+			summary.BySeverity[strings.ToUpper(issue.Severity)]++
+		}
+	}
+
+	for _, n := range notifiers {
+		if err := n.Notify(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %s notification failed: %v\n", n.Name(), err)
+		}
+	}
+}
+
+// emailReport writes an HTML report to the reports directory and mails it.
+func emailReport(cfg *config.Config, results []scanner.ScanResult, totals map[string]int, summary *scanner.ProjectSummary, suppressed, totalFiles int, languages []walker.LanguageStats, generation string) error {
+	reportsDir, err := config.GetReportsDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate reports directory: %w", err)
+	}
+	if err := os.MkdirAll(reportsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	reportPath := filepath.Join(reportsDir, report.GetDefaultReportPath(targetPath))
+	if err := report.GenerateHTML(results, report.Metadata{
+		ScanPath:       targetPath,
+		Model:          modelName,
+		TotalFiles:     totalFiles,
+		Generation:     generation,
+		Fast:           fastScan,
+		Suppressed:     suppressed,
+		MinSeverity:    minSeverity,
+		SeverityTotals: totals,
+		Summary:        summary,
+		Languages:      languages,
+	}, reportPath); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	filesWithIssues := 0
+	for _, result := range results {
+		if result.HasIssues {
+			filesWithIssues++
+		}
+	}
+
+	subject := fmt.Sprintf("Sidekick scan: %s (%d files with findings)", filepath.Base(targetPath), filesWithIssues)
+	body := fmt.Sprintf("Scan of %s with %s finished.\n\nFiles scanned: %d\nFiles with findings: %d\nRisk score: %d/100 (%s)\n\nThe full report is attached.\n",
+		targetPath, modelName, len(results), filesWithIssues, summary.RiskScore, summary.RiskLevel)
+
+	if err := report.SendEmail(cfg.SMTP, emailTo, subject, body, reportPath); err != nil {
+		return fmt.Errorf("failed to email report: %w", err)
+	}
+
+	fmt.Printf("📧 Report emailed to %s\n", strings.Join(emailTo, ", "))
+	return nil
+}
 
-	return files, err
+// imageResults names the files of results by their path inside img, which
+// was extracted to dir, and records the layer each came from.
+func imageResults(results []scanner.ScanResult, img *image.Image, dir string) {
+	inImage := func(path string) (string, string) {
+		if path == "" {
+			return "", ""
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return path, ""
+		}
+		return "/" + filepath.ToSlash(rel), img.Provenance(rel)
+	}
+	for i := range results {
+		r := &results[i]
+		r.FilePath, r.Layer = inImage(r.FilePath)
+		r.DuplicateOf, _ = inImage(r.DuplicateOf)
+		for j := range r.Issues {
+			r.Issues[j].File, _ = inImage(r.Issues[j].File)
+		}
+	}
 }
 
-func displayResults(results []scanner.ScanResult, client *ollama.Client, modelName string) {
+// displayResults prints the findings of results and a summary. totals, when
+// set, counts the findings before --min-severity filtered results;
+// suppressed is the number left out as false positives.
+func displayResults(results []scanner.ScanResult, totals map[string]int, suppressed int, client *ollama.Client, modelName string) {
 	filesWithIssues := 0
 
 	for _, result := range results {
 		if result.HasIssues {
 			filesWithIssues++
-			fmt.Printf("\n\033[38;5;208m━━━ %s ━━━\033[0m\n", filepath.Base(result.FilePath))
-			fmt.Println(result.RawFindings)
+			name := ui.Hyperlink(ui.EditorURL(result.FilePath, 0), filepath.Base(result.FilePath))
+			if result.Layer != "" {
+				// Image files are named by their path inside the image
+				name = result.FilePath
+			}
+			fmt.Printf("\n\033[38;5;208m━━━ %s ━━━\033[0m\n", name)
+			if result.Layer != "" {
+				fmt.Printf("📦 From %s\n", result.Layer)
+			}
+			fmt.Println(result.Findings())
 			fmt.Println()
 
 		}
@@ -167,10 +1118,165 @@ func displayResults(results []scanner.ScanResult, client *ollama.Client, modelNa
 	fmt.Printf("\033[38;5;208m📊 Scan Summary\033[0m\n")
 	fmt.Printf("   Files scanned: %d\n", len(results))
 	fmt.Printf("   Files with findings: %d\n", filesWithIssues)
-	if filesWithIssues == 0 {
+	shown := scanner.CountSeverities(results)
+	if totals == nil {
+		totals = shown
+	}
+	if counts := scanner.SeveritySummary(totals); counts != "" {
+		fmt.Printf("   Findings: %s\n", counts)
+	}
+	hidden := countFindings(totals) - countFindings(shown)
+	if hidden > 0 {
+		fmt.Printf("   Below %s (not shown): %d\n", minSeverity, hidden)
+	}
+	if suppressed > 0 {
+		fmt.Printf("   Suppressed as false positives: %d (sidekick suppressions)\n", suppressed)
+	}
+	if n := scanner.Resurfaced(results); n > 0 {
+		fmt.Printf("   ⏰ Resurfaced for re-review: %d (false positive suppression expired)\n", n)
+	}
+	if n := countFindings(scanner.CountGenerated(results)); n > 0 {
+		fmt.Printf("   🏭 In generated code: %d (not counted by --fail-on)\n", n)
+	}
+	if skipped := scanner.SkipSummary(results); skipped != "" {
+		fmt.Printf("   Skipped: %s\n", skipped)
+	}
+	if warnings := scanner.InjectionWarnings(results); len(warnings) > 0 {
+		note := "neutralized before scanning"
+		if !quarantine {
+			note = "sent to the model as is"
+		}
+		fmt.Printf("   🛡️  Possible prompt injection (%s; review by hand):\n", note)
+		for _, w := range warnings {
+			fmt.Printf("      %s\n", w)
+		}
+	}
+	if diffRef != "" {
+		onChanged := 0
+		for _, result := range results {
+			for _, issue := range result.Issues {
+				if issue.ChangedLines != "" {
+					onChanged++
+				}
+			}
+		}
+		fmt.Printf("   Findings on changed lines: %d\n", onChanged)
+	}
+	if usage := client.TotalUsage(); usage.Total() > 0 {
+		fmt.Printf("   Tokens: %d prompt + %d completion = %d\n", usage.PromptTokens, usage.CompletionTokens, usage.Total())
+	}
+	if fastScan {
+		fmt.Println("   ⚡ Fast scan: lower fidelity; run a full scan before relying on it")
+	}
+	if filesWithIssues == 0 && hidden > 0 {
+		fmt.Printf("   \033[38;5;82m✓\033[0m No issues at or above %s\n", minSeverity)
+	} else if filesWithIssues == 0 {
 		fmt.Println("   \033[38;5;82m✓\033[0m No issues detected!")
 	}
 	fmt.Println("\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m")
+	if !reviewAfter && autofix == "" && imageRef == "" && countFindings(shown) > 0 {
+		fmt.Println("   Run with --review to go through the findings and apply fixes")
+	}
+}
+
+// maxSummaryCategories is how many categories the project summary lists.
+const maxSummaryCategories = 5
 
-	// Review mode is no longer offered from scan output.
+// displayProjectSummary prints the risk score, the most frequent categories
+// and the files with the most findings.
+func displayProjectSummary(summary scanner.ProjectSummary) {
+	if summary.Findings == 0 {
+		return
+	}
+	fmt.Printf("\n\033[38;5;208m📈 Project Summary\033[0m\n")
+	fmt.Printf("   Risk score: %s%d/100 %s\033[0m\n", ui.SeverityColor(summary.RiskLevel), summary.RiskScore, summary.RiskLevel)
+	if len(summary.ByCategory) > 0 {
+		fmt.Println("   Categories:")
+		for i, c := range summary.ByCategory {
+			if i == maxSummaryCategories {
+				fmt.Printf("      ... and %d more\n", len(summary.ByCategory)-i)
+				break
+			}
+			label := c.ID
+			if label == "" {
+				label = "Uncategorized"
+			} else if c.Title != "" {
+				label += " " + c.Title
+			}
+			fmt.Printf("      %3d × %s\n", c.Count, label)
+		}
+	}
+	fmt.Println("   Top files:")
+	for _, f := range summary.TopFiles {
+		name := f.File
+		if rel, err := filepath.Rel(targetPath, f.File); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+			name = rel
+		}
+		fmt.Printf("      %3d × %s (worst: %s%s\033[0m)\n", f.Findings, name, ui.SeverityColor(f.Worst), f.Worst)
+	}
+}
+
+// displayLicenseSummary prints the license compliance summary of a license
+// scan.
+func displayLicenseSummary(summary *scanner.LicenseSummary) {
+	if summary == nil {
+		return
+	}
+	fmt.Printf("\n\033[38;5;208m⚖️  License Compliance\033[0m\n")
+	if len(summary.Projects) > 0 {
+		fmt.Printf("   Project license: %s\n", strings.Join(summary.Projects, ", "))
+	} else {
+		fmt.Println("   Project license: none found (no LICENSE or COPYING file)")
+	}
+	fmt.Printf("   Files: %d compliant, %d incompatible, %d unidentified, %d missing a header, %d exempt\n",
+		summary.Compliant, summary.Incompatible, summary.Unknown, summary.Missing, summary.Exempt)
+	if len(summary.Licenses) > 0 {
+		var parts []string
+		for _, l := range summary.Licenses {
+			parts = append(parts, fmt.Sprintf("%s (%d)", l.Name, l.Files))
+		}
+		fmt.Printf("   Licenses: %s\n", strings.Join(parts, ", "))
+	}
+}
+
+// confirmLargeScan asks whether to go ahead with a scan of more files than
+// max_files. Without a terminal to ask on, --yes is required instead.
+func confirmLargeScan(files, limit int) (bool, error) {
+	needYes := fmt.Errorf("scan would cover %d files, more than max_files (%d); pass --yes to scan them all, or narrow it with a path, --include or --exclude", files, limit)
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, needYes
+	}
+	fmt.Printf("⚠️  This scan covers %d files (more than %d) and may take hours.\n", files, limit)
+	fmt.Print("Continue? (y/N): ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		// e.g. /dev/null, which is a character device but can't answer
+		fmt.Println()
+		return false, needYes
+	}
+	answer = strings.TrimSpace(answer)
+	return answer == "y" || answer == "Y", nil
+}
+
+// countFindings sums counts per severity.
+func countFindings(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// languageLine summarizes the languages of the collected files, e.g.
+// "Go 12 files, 3400 lines · YAML 2 files, 40 lines", listing at most five.
+func languageLine(languages []walker.LanguageStats) string {
+	parts := make([]string, 0, 6)
+	for i, lang := range languages {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("+%d more", len(languages)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %d files, %d lines", lang.Language, lang.Files, lang.Lines))
+	}
+	return strings.Join(parts, " · ")
 }
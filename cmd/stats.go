@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsWeeks int
+	statsTop   int
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize local scan history",
+	Long: `Summarize the local scan history (~/.sidekick/history.jsonl): scans run,
+average duration, findings by severity over time, the most common CWEs and
+how each model performed. Everything is computed locally; nothing is sent
+over the network.`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().IntVar(&statsWeeks, "weeks", 0, "Only include scans from the last N weeks (0 = all)")
+	statsCmd.Flags().IntVar(&statsTop, "top", 5, "Number of CWEs to list")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	entries, err := history.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read scan history: %w", err)
+	}
+	if statsWeeks > 0 {
+		cutoff := time.Now().AddDate(0, 0, -7*statsWeeks)
+		kept := entries[:0]
+		for _, e := range entries {
+			if !e.Time.Before(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	if len(entries) == 0 {
+		fmt.Println("No scans recorded yet. Run `sidekick scan` first.")
+		return nil
+	}
+
+	st := history.Summarize(entries)
+	severities := []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+	fmt.Printf("\n📊 Scan statistics (%s to %s)\n\n",
+		entries[0].Time.Format("2006-01-02"), entries[len(entries)-1].Time.Format("2006-01-02"))
+	fmt.Printf("Scans run:        %d\n", st.Scans)
+	fmt.Printf("Files scanned:    %d\n", st.FilesScanned)
+	if st.AvgDuration > 0 {
+		fmt.Printf("Average duration: %s\n", st.AvgDuration.Round(time.Second))
+	}
+	fmt.Printf("Findings:         %s\n", severityCounts(st.BySeverity, severities))
+
+	fmt.Printf("\nFindings by week:\n")
+	for _, p := range st.Periods {
+		fmt.Printf("  %s  %3d scans  %s\n", p.Period, p.Scans, severityCounts(p.BySeverity, severities))
+	}
+
+	if len(st.TopCWEs) > 0 {
+		fmt.Printf("\nMost common CWEs:\n")
+		for i, c := range st.TopCWEs {
+			if i == statsTop {
+				break
+			}
+			fmt.Printf("  %s %d\n", ui.PadRight(c.Key, 12), c.Count)
+		}
+	}
+
+	fmt.Printf("\nModels:\n")
+	for _, m := range st.Models {
+		line := fmt.Sprintf("  %s %3d scans  %.1f findings/scan  %d tokens/scan", ui.PadRight(m.Model, 28), m.Scans, m.FindingsPerScan, m.TokensPerScan)
+		if m.AvgDuration > 0 {
+			line += fmt.Sprintf("  avg %s", m.AvgDuration.Round(time.Second))
+		}
+		if m.FilesPerMinute > 0 {
+			line += fmt.Sprintf("  %.1f files/min", m.FilesPerMinute)
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+	return nil
+}
+
+// severityCounts renders counts as "CRITICAL 1, HIGH 3", or "none".
+func severityCounts(counts map[string]int, severities []string) string {
+	var parts []string
+	for _, sev := range severities {
+		if n := counts[sev]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", sev, n))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var suppressionsExpired bool
+
+var suppressionsCmd = &cobra.Command{
+	Use:   "suppressions",
+	Short: "List findings suppressed as false positives and when they resurface",
+	Long: `Findings marked false positive in review mode are left out of later scans
+until their re-check date (recheck_days in the config, 90 by default). After it
+they show up again, marked for re-review; marking them again sets a new date.
+List the suppressions, most recent last, or remove some to see those findings
+in the next scan.`,
+	Args: cobra.NoArgs,
+	RunE: runSuppressions,
+}
+
+var suppressionsRemoveCmd = &cobra.Command{
+	Use:   "remove <fingerprint>...",
+	Short: "Stop suppressing findings",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := history.RemoveSuppressions(args)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d suppression(s)\n", removed)
+		return nil
+	},
+}
+
+func init() {
+	suppressionsCmd.Flags().BoolVar(&suppressionsExpired, "expired", false, "Only list suppressions past their re-check date")
+	suppressionsCmd.AddCommand(suppressionsRemoveCmd)
+}
+
+func runSuppressions(cmd *cobra.Command, args []string) error {
+	list, err := history.LoadSuppressions()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("No suppressions. Findings are suppressed when review mode marks them false positive.")
+		return nil
+	}
+
+	now := time.Now()
+	shown := 0
+	for _, s := range list {
+		if suppressionsExpired && !s.Expired(now) {
+			continue
+		}
+		shown++
+		status := "never resurfaces"
+		switch {
+		case s.Expired(now):
+			status = fmt.Sprintf("⏰ expired %s, due for re-review", s.Expires.Format("2006-01-02"))
+		case s.Expires != nil:
+			status = fmt.Sprintf("until %s", s.Expires.Format("2006-01-02"))
+		}
+		fmt.Printf("%s  %s %s (%s)\n", s.Fingerprint, s.Severity, s.Title, status)
+		fmt.Printf("   %s, marked %s %s\n", s.File, s.Reason, s.Created.Format("2006-01-02"))
+	}
+	if shown == 0 {
+		fmt.Println("No expired suppressions.")
+		return nil
+	}
+	fmt.Println("\nRemove with: sidekick suppressions remove <fingerprint>")
+	return nil
+}
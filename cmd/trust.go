@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust [path]",
+	Short: "Trust a workspace so fixes can be applied in it, or list trusted workspaces",
+	Long: `Sidekick runs in safe mode in workspaces (git repositories, or directories
+outside one) you haven't trusted: it never writes to the scanned files, so
+review mode, --autofix and "sidekick fix" don't apply fixes, and the default
+HTML report and debug log go to ~/.sidekick instead of the working directory.
+
+Trust the workspace of a path to allow fixes there, or pass --trust to a scan
+or fix, which trusts it too. Without a path, the trusted workspaces are
+listed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTrust,
+}
+
+var trustRemoveCmd = &cobra.Command{
+	Use:   "remove <path>...",
+	Short: "Stop trusting workspaces",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		removed := 0
+		for _, path := range args {
+			if cfg.Untrust(path) {
+				removed++
+			}
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d trusted workspace(s)\n", removed)
+		return nil
+	},
+}
+
+func init() {
+	trustCmd.AddCommand(trustRemoveCmd)
+}
+
+func runTrust(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return listTrusted(cfg)
+	}
+	path := args[0]
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot access path: %w", err)
+	}
+	root := cfg.Trust(path)
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("🔓 Trusted workspace: %s\n", root)
+	return nil
+}
+
+func listTrusted(cfg *config.Config) error {
+	if len(cfg.TrustedWorkspaces) == 0 {
+		fmt.Println("No trusted workspaces. Sidekick runs in safe mode everywhere.")
+		return nil
+	}
+	for _, root := range cfg.TrustedWorkspaces {
+		fmt.Println(root)
+	}
+	return nil
+}
+
+// applyTrust decides whether sidekick may modify files under path: with
+// trust (--trust) the workspace is trusted and remembered; otherwise safe
+// mode is enabled unless it was trusted before. It reports whether the
+// workspace is trusted.
+func applyTrust(cfg *config.Config, path string, trust bool) (bool, error) {
+	if trust && !cfg.Trusted(path) {
+		root := cfg.Trust(path)
+		if err := cfg.Save(); err != nil {
+			return false, fmt.Errorf("failed to save trusted workspace: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "🔓 Trusted workspace: %s\n", root)
+	}
+	trusted := cfg.Trusted(path)
+	scanner.SetSafeMode(!trusted)
+	return trusted, nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/prompts"
+	"github.com/pefman/sidekick/internal/report"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config files and prompt templates for errors",
+	Long: `Lint the sidekick config file, the .sidekick.yaml of the project in the
+current directory (if any) and the custom prompt templates, reporting
+actionable errors before a scan starts.`,
+	Args: cobra.NoArgs,
+	RunE: runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	problems := 0
+
+	cfg, configPath, err := config.LoadStrict()
+	switch {
+	case err != nil:
+		fmt.Printf("✗ Config %s: %v\n", configPath, err)
+		problems++
+	default:
+		issues := append(cfg.Validate(), outputFormatProblems(cfg)...)
+		issues = append(issues, scanTypeProblems("default_scan_type", cfg.ScanType())...)
+		if len(issues) == 0 {
+			fmt.Printf("✅ Config %s\n", configPath)
+		} else {
+			fmt.Printf("✗ Config %s:\n", configPath)
+			for _, issue := range issues {
+				fmt.Printf("   • %s\n", issue)
+			}
+			problems += len(issues)
+		}
+	}
+
+	project, err := config.FindProject(".")
+	switch {
+	case err != nil:
+		fmt.Printf("✗ Project config: %v\n", err)
+		problems++
+	case project != nil:
+		issues := append(project.Validate(), scanTypeProblems("scan_type", project.ScanType)...)
+		for mode, text := range project.PromptTemplates {
+			for _, err := range prompts.CheckTemplate("prompt_templates."+mode, text) {
+				issues = append(issues, err.Error())
+			}
+		}
+		if len(issues) == 0 {
+			fmt.Printf("✅ Project config %s\n", project.Path)
+		} else {
+			fmt.Printf("✗ Project config %s:\n", project.Path)
+			for _, issue := range issues {
+				fmt.Printf("   • %s\n", issue)
+			}
+			problems += len(issues)
+		}
+	}
+
+	if errs := prompts.ValidateTemplates(); len(errs) > 0 {
+		fmt.Println("✗ Prompt templates:")
+		for _, err := range errs {
+			fmt.Printf("   • %v\n", err)
+		}
+		problems += len(errs)
+	} else {
+		fmt.Println("✅ Prompt templates")
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	return nil
+}
+
+// outputFormatProblems checks default_output_format against the registered
+// report formats, as scan checks --format, so formats added with
+// report.RegisterFormatter are accepted by both.
+func outputFormatProblems(cfg *config.Config) []string {
+	format := cfg.OutputFormat()
+	if _, ok := report.LookupFormatter(format); ok || format == "text" {
+		return nil
+	}
+	return []string{fmt.Sprintf("default_output_format %q must be text, %s", cfg.DefaultOutputFormat, strings.Join(report.FormatterNames(), ", "))}
+}
+
+// scanTypeProblems checks a configured scan type against the registered
+// engines, as scan and lsp check --scan-type. An empty name means the
+// default.
+func scanTypeProblems(field, name string) []string {
+	if _, ok := scanner.LookupEngine(name); ok || name == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s %q must be one of %s", field, name, strings.Join(scanner.EngineNames(), ", "))}
+}
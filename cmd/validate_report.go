@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pefman/sidekick/internal/report"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var printSchema bool
+
+var validateReportCmd = &cobra.Command{
+	Use:   "validate-report [file]",
+	Short: "Validate a JSON report against the published schema",
+	Long: `Validate a JSON scan report against the report schema bundled with this
+version of sidekick. Use --schema to print the schema itself.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runValidateReport,
+}
+
+func init() {
+	validateReportCmd.Flags().BoolVar(&printSchema, "schema", false, "Print the JSON report schema and exit")
+}
+
+func runValidateReport(cmd *cobra.Command, args []string) error {
+	if printSchema {
+		ui.Stdout().Write(report.Schema)
+		return nil
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("report file required (or use --schema)")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+
+	problems, err := report.ValidateJSON(data)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		fmt.Printf("✗ %s does not match report schema v%s:\n", args[0], report.SchemaVersion)
+		for _, p := range problems {
+			fmt.Printf("   • %s\n", p)
+		}
+		return fmt.Errorf("%d schema violation(s)", len(problems))
+	}
+
+	fmt.Printf("✅ %s is a valid report (schema v%s)\n", args[0], report.SchemaVersion)
+	return nil
+}
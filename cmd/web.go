@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/report"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/walker"
+	"github.com/spf13/cobra"
+)
+
+var webAddr string
+
+var webCmd = &cobra.Command{
+	Use:   "web",
+	Short: "Serve a local dashboard for scan history and reports",
+	Long: `Start a local web dashboard that lists previous scan reports, renders them
+in the browser, and lets you start scans of configured paths.`,
+	Args: cobra.NoArgs,
+	RunE: runWeb,
+}
+
+func init() {
+	webCmd.Flags().StringVar(&webAddr, "addr", "127.0.0.1:7878", "Address to listen on")
+}
+
+type webServer struct {
+	cfg        *config.Config
+	reportsDir string
+
+	mu      sync.Mutex
+	running map[string]time.Time
+	errors  map[string]string
+}
+
+type webReport struct {
+	Name    string
+	ModTime string
+}
+
+const webIndexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>Sidekick Dashboard</title>
+    <style>
+        body { font-family: Arial, sans-serif; background: #0a0a0a; color: #e0e0e0; margin: 0; padding: 24px; }
+        .container { max-width: 1100px; margin: 0 auto; background: #111; border: 1px solid #222; border-radius: 6px; padding: 20px 24px; }
+        h2, h3 { color: #ff7e00; }
+        a { color: #ff7e00; }
+        input, button { background: #151515; color: #e0e0e0; border: 1px solid #333; padding: 6px 10px; }
+        li { margin: 6px 0; }
+        .muted { color: #777; }
+    </style>
+</head>
+<body>
+  <div class="container">
+    <h2>Sidekick Dashboard</h2>
+    <h3>Start a scan</h3>
+    {{range .Paths}}
+    <form method="post" action="/scan"><input type="hidden" name="path" value="{{.}}"><button type="submit">Scan {{.}}</button></form>
+    {{end}}
+    <form method="post" action="/scan">
+      <input type="text" name="path" size="60" placeholder="/path/to/project">
+      <button type="submit">Scan</button>
+    </form>
+    {{if .Running}}
+    <h3>Running</h3>
+    <ul>{{range $path, $started := .Running}}<li>{{$path}} <span class="muted">started {{$started.Format "15:04:05"}}</span></li>{{end}}</ul>
+    {{end}}
+    {{if .Errors}}
+    <h3>Failed</h3>
+    <ul>{{range $path, $err := .Errors}}<li>{{$path}}: {{$err}}</li>{{end}}</ul>
+    {{end}}
+    <h3>Reports</h3>
+    <ul>
+      {{range .Reports}}<li><a href="/reports/{{.Name}}">{{.Name}}</a> <span class="muted">{{.ModTime}}</span></li>{{else}}<li class="muted">No reports yet</li>{{end}}
+    </ul>
+  </div>
+</body>
+</html>`
+
+func runWeb(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		cfg = config.GetDefault()
+	}
+
+	reportsDir, err := config.GetReportsDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate reports directory: %w", err)
+	}
+	if err := os.MkdirAll(reportsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	ws := &webServer{
+		cfg:        cfg,
+		reportsDir: reportsDir,
+		running:    make(map[string]time.Time),
+		errors:     make(map[string]string),
+	}
+
+	tmpl, err := template.New("index").Parse(webIndexTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		ws.mu.Lock()
+		data := struct {
+			Paths   []string
+			Running map[string]time.Time
+			Errors  map[string]string
+			Reports []webReport
+		}{
+			Paths:   cfg.WebScanPaths,
+			Running: copyTimes(ws.running),
+			Errors:  copyStrings(ws.errors),
+			Reports: ws.listReports(),
+		}
+		ws.mu.Unlock()
+		if err := tmpl.Execute(w, data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/reports/", func(w http.ResponseWriter, r *http.Request) {
+		// Only serve plain report file names from the reports directory
+		name := filepath.Base(strings.TrimPrefix(r.URL.Path, "/reports/"))
+		if !strings.HasSuffix(name, ".html") || name != strings.TrimPrefix(r.URL.Path, "/reports/") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(reportsDir, name))
+	})
+	mux.HandleFunc("/badge.svg", ws.serveBadge("svg"))
+	mux.HandleFunc("/badge.json", ws.serveBadge("json"))
+	mux.HandleFunc("/scan", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		path := strings.TrimSpace(r.FormValue("path"))
+		if path == "" {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			http.Error(w, "invalid path", http.StatusBadRequest)
+			return
+		}
+		ws.startScan(filepath.Clean(path))
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	})
+
+	fmt.Printf("🌐 Sidekick dashboard: http://%s\n", webAddr)
+	fmt.Printf("📁 Reports: %s\n\n", reportsDir)
+	return http.ListenAndServe(webAddr, mux)
+}
+
+func (ws *webServer) listReports() []webReport {
+	entries, err := os.ReadDir(ws.reportsDir)
+	if err != nil {
+		return nil
+	}
+
+	type entry struct {
+		name string
+		mod  time.Time
+	}
+	var found []entry
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".html") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, entry{e.Name(), info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].mod.After(found[j].mod) })
+
+	reports := make([]webReport, len(found))
+	for i, f := range found {
+		reports[i] = webReport{Name: f.name, ModTime: f.mod.Format("2006-01-02 15:04:05")}
+	}
+	return reports
+}
+
+// startScan runs a security scan of path in the background and writes an
+// HTML report into the reports directory when it finishes.
+func (ws *webServer) startScan(path string) {
+	ws.mu.Lock()
+	if _, busy := ws.running[path]; busy {
+		ws.mu.Unlock()
+		return
+	}
+	ws.running[path] = time.Now()
+	delete(ws.errors, path)
+	ws.mu.Unlock()
+
+	go func() {
+		err := ws.scan(path)
+
+		ws.mu.Lock()
+		delete(ws.running, path)
+		if err != nil {
+			ws.errors[path] = err.Error()
+		}
+		ws.mu.Unlock()
+	}()
+}
+
+func (ws *webServer) scan(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot access path: %w", err)
+	}
+
+	client := ollama.NewClient(ws.cfg.OllamaURL)
+	client.SetOptions(configGenerationOptions(ws.cfg))
+	client.SetMaxInFlight(ws.cfg.MaxInFlight)
+	if err := client.CheckModel(ws.cfg.DefaultModel); err != nil {
+		return fmt.Errorf("model check failed: %w", err)
+	}
+
+	files, err := walker.Collect(path, walker.Options{IncludeTests: ws.cfg.IncludeTests})
+	if err != nil {
+		return fmt.Errorf("failed to collect files: %w", err)
+	}
+
+	s := scanner.NewScanner(client, ws.cfg.DefaultModel, ws.cfg.Debug, "security", "")
+	defer s.Close()
+	s.SetSeverityOverrides(ws.cfg.SeverityOverrides)
+	s.SetOrgContext(ws.cfg.Context)
+	s.SetChunking(ws.cfg.ChunkSize, ws.cfg.ChunkOverlap)
+	s.SetContextCache(true)
+	s.SetResultCache(true)
+	s.SetQuarantine(ws.cfg.Quarantines())
+	s.SetConcurrency(ws.cfg.Concurrency)
+	if ws.cfg.CrossFileContexts() {
+		s.SetProjectContext(scanner.BuildProjectContext(files))
+	}
+
+	started := time.Now()
+	results, err := s.ScanFiles(context.Background(), files)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+
+	entry := historyEntry(path, ws.cfg.DefaultModel, "security", results, client.TotalUsage(), started)
+	if err := history.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to record scan history: %v\n", err)
+	}
+
+	outputPath := filepath.Join(ws.reportsDir, report.GetDefaultReportPath(path))
+	return report.GenerateHTML(results, report.Metadata{
+		ScanPath:   path,
+		Model:      ws.cfg.DefaultModel,
+		TotalFiles: len(files),
+		Generation: client.Options().String(),
+		Languages:  walker.Languages(files),
+	}, outputPath)
+}
+
+// serveBadge serves the badge for the latest scan, or for the latest scan
+// of the "path" query parameter when given.
+func (ws *webServer) serveBadge(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("path")
+		if target != "" {
+			target = filepath.Clean(target)
+		}
+		b, err := latestBadge(target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data, err := renderBadge(b, format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if format == "json" {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "image/svg+xml")
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(data)
+	}
+}
+
+func copyTimes(m map[string]time.Time) map[string]time.Time {
+	out := make(map[string]time.Time, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
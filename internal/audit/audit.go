@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// Decisions recorded for a finding in review mode.
+const (
+	Applied       = "applied"
+	Ignored       = "ignored"
+	FalsePositive = "false_positive"
+	Edited        = "edited"
+)
+
+// Decision is one review-mode decision about a finding.
+type Decision struct {
+	Time        time.Time `json:"time"`
+	Decision    string    `json:"decision"`
+	Fingerprint string    `json:"fingerprint"` // Stable across line shifts, see scanner.SecurityIssue.Fingerprint
+	File        string    `json:"file"`
+	LineStart   int       `json:"line_start"`
+	LineEnd     int       `json:"line_end"`
+	Severity    string    `json:"severity"`
+	Title       string    `json:"title"`
+	IssueID     string    `json:"issue_id,omitempty"`
+	Backup      string    `json:"backup,omitempty"` // Backup session holding the file from before the change
+}
+
+// Log is the audit trail of one review session. It is rewritten after
+// every decision, so quitting or a crash never loses earlier ones.
+type Log struct {
+	Session   string     `json:"session"`
+	User      string     `json:"user,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	Started   time.Time  `json:"started"`
+	Decisions []Decision `json:"decisions"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// New starts the audit log for the review session id. Nothing is written
+// until the first decision.
+func New(id string) *Log {
+	l := &Log{Session: id, Started: time.Now()}
+	if u, err := user.Current(); err == nil {
+		l.User = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		l.Host = host
+	}
+	return l
+}
+
+// Record appends d and saves the log, returning the file it was saved to.
+func (l *Log) Record(d Decision) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+	l.Decisions = append(l.Decisions, d)
+
+	if l.path == "" {
+		dir, err := config.GetAuditDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate audit directory: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create audit directory: %w", err)
+		}
+		l.path = filepath.Join(dir, l.Session+".json")
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit log: %w", err)
+	}
+	if err := os.WriteFile(l.path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return l.path, nil
+}
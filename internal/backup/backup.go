@@ -0,0 +1,176 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+const manifestName = "manifest.json"
+
+// Entry is one file saved before a fix modified it.
+type Entry struct {
+	Original string    `json:"original"` // Absolute path of the modified file
+	Backup   string    `json:"backup"`   // File name inside the session directory
+	Time     time.Time `json:"time"`
+}
+
+// Session groups the backups taken during one review session. Its
+// directory is created on the first Save.
+type Session struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Files   []Entry   `json:"files"`
+
+	mu  sync.Mutex
+	dir string
+}
+
+// NewSession starts a session named after the current time.
+func NewSession() *Session {
+	now := time.Now()
+	return &Session{ID: now.Format("20060102-150405"), Created: now}
+}
+
+// createDir creates the session's directory under root. Sessions started
+// in the same second share a time-based ID, so when one already took the
+// directory the ID gets a numeric suffix until Mkdir claims a free one.
+func (s *Session) createDir(root string) error {
+	base := s.ID
+	for n := 2; ; n++ {
+		dir := filepath.Join(root, s.ID)
+		err := os.Mkdir(dir, 0700)
+		if err == nil {
+			s.dir = dir
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+		s.ID = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// Save stores content as the backup of filePath and returns where it was
+// written. Each file is saved once per session, keeping its state from
+// before the session's first fix.
+func (s *Session) Save(filePath string, content []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	original, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+	if s.dir == "" {
+		root, err := config.GetBackupsDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate backups directory: %w", err)
+		}
+		if err := os.MkdirAll(root, 0700); err != nil {
+			return "", fmt.Errorf("failed to create backups directory: %w", err)
+		}
+		if err := s.createDir(root); err != nil {
+			return "", err
+		}
+	}
+	for _, e := range s.Files {
+		if e.Original == original {
+			return filepath.Join(s.dir, e.Backup), nil
+		}
+	}
+
+	name := fmt.Sprintf("%04d-%s", len(s.Files)+1, filepath.Base(original))
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	s.Files = append(s.Files, Entry{Original: original, Backup: name, Time: time.Now()})
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, manifestName), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return path, nil
+}
+
+// List returns every stored session, newest first.
+func List() ([]*Session, error) {
+	root, err := config.GetBackupsDir()
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups directory: %w", err)
+	}
+
+	var sessions []*Session
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		s, err := load(filepath.Join(root, d.Name()))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Created.After(sessions[j].Created) })
+	return sessions, nil
+}
+
+// Find returns the session with the given ID; "latest" names the newest.
+func Find(id string) (*Session, error) {
+	sessions, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.ID == id || (id == "latest" && s == sessions[0]) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no backup session %q", id)
+}
+
+func load(dir string) (*Session, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	s.dir = dir
+	return &s, nil
+}
+
+// Restore writes the backed-up content of e back to its original path.
+func (s *Session) Restore(e Entry) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, e.Backup))
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(e.Original); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(e.Original, data, mode); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", e.Original, err)
+	}
+	return nil
+}
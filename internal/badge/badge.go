@@ -0,0 +1,95 @@
+package badge
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Badge is a two-part status badge, e.g. "security | 2 high".
+type Badge struct {
+	Label   string
+	Message string
+	Color   string // Hex color of the message part, e.g. "#e05d44"
+}
+
+// Label used for every scan badge.
+const Label = "security"
+
+var severityColors = []struct {
+	severity string
+	color    string
+}{
+	{"CRITICAL", "#e05d44"},
+	{"HIGH", "#fe7d37"},
+	{"MEDIUM", "#dfb317"},
+	{"LOW", "#a4a61d"},
+}
+
+const (
+	passingColor = "#4c1"
+	unknownColor = "#9f9f9f"
+)
+
+// FromCounts builds a badge from finding counts keyed by severity. The
+// message names the most severe level present ("2 high"); a scan without
+// findings is "passing".
+func FromCounts(bySeverity map[string]int) Badge {
+	for _, sc := range severityColors {
+		if n := bySeverity[sc.severity]; n > 0 {
+			return Badge{
+				Label:   Label,
+				Message: fmt.Sprintf("%d %s", n, strings.ToLower(sc.severity)),
+				Color:   sc.color,
+			}
+		}
+	}
+	return Badge{Label: Label, Message: "passing", Color: passingColor}
+}
+
+// Unknown is shown when no scan has been recorded yet.
+func Unknown() Badge {
+	return Badge{Label: Label, Message: "unknown", Color: unknownColor}
+}
+
+// JSON renders the badge in the shields.io endpoint format, so it can be
+// embedded with https://img.shields.io/endpoint?url=...
+func (b Badge) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, b.Label, b.Message, strings.TrimPrefix(b.Color, "#")}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// SVG renders the badge as a flat shields-style image.
+func (b Badge) SVG() []byte {
+	lw, mw := textWidth(b.Label), textWidth(b.Message)
+	w := lw + mw
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, w, label, message)
+	fmt.Fprintf(&sb, `<title>%s: %s</title>`, label, message)
+	sb.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&sb, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, w)
+	fmt.Fprintf(&sb, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		lw, lw, mw, html.EscapeString(b.Color), w)
+	sb.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&sb, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, lw/2, label, lw/2, label)
+	fmt.Fprintf(&sb, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, lw+mw/2, message, lw+mw/2, message)
+	sb.WriteString(`</g></svg>`)
+	return []byte(sb.String())
+}
+
+// textWidth approximates the rendered width of s in 11px Verdana, plus padding.
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)*7 + 10
+}
@@ -1,16 +1,280 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pefman/sidekick/internal/ui"
 )
 
 type Config struct {
-	DefaultModel string `json:"default_model"`
-	OllamaURL    string `json:"ollama_url"`
-	Debug        bool   `json:"debug"`
+	DefaultModel      string                   `json:"default_model"`
+	OllamaURL         string                   `json:"ollama_url"`
+	Debug             bool                     `json:"debug"`
+	SeverityOverrides []SeverityOverride       `json:"severity_overrides,omitempty"`
+	SeverityStyles    map[string]SeverityStyle `json:"severity_styles,omitempty"` // Emoji and color per severity, e.g. {"HIGH": {"color": "#0072b2"}}
+	WebScanPaths      []string                 `json:"web_scan_paths,omitempty"`
+	SMTP              *SMTPConfig              `json:"smtp,omitempty"`
+	Notifications     NotifyConfig             `json:"notifications,omitempty"`
+	Temperature       *float64                 `json:"temperature,omitempty"`       // Security scans default to 0
+	TopP              *float64                 `json:"top_p,omitempty"`             // Nucleus sampling; model default when unset
+	Seed              *int                     `json:"seed,omitempty"`              // Security scans default to DefaultSeed
+	NumCtx            *int                     `json:"num_ctx,omitempty"`           // Context window in tokens; defaults to DefaultNumCtx
+	NumPredict        *int                     `json:"num_predict,omitempty"`       // Maximum tokens per answer; model default when unset
+	MaxInFlight       int                      `json:"max_in_flight,omitempty"`     // Max concurrent generate requests; 0 = no limit
+	Concurrency       int                      `json:"concurrency,omitempty"`       // Files scanned at once per stage; 0 = 3
+	AutoTune          bool                     `json:"autotune,omitempty"`          // Adapt in-flight requests to server latency
+	ChunkSize         int                      `json:"chunk_size,omitempty"`        // Bytes per model request for large files; 0 = 100000
+	ChunkOverlap      int                      `json:"chunk_overlap,omitempty"`     // Lines repeated between chunks; 0 = 20
+	JSONRetries       *int                     `json:"json_retries,omitempty"`      // Re-prompts for responses that aren't valid JSON; defaults to 2
+	StructuredOutput  *bool                    `json:"structured_output,omitempty"` // Constrain security scan answers to a JSON schema; defaults to true
+	Quarantine        *bool                    `json:"quarantine,omitempty"`        // Neutralize text addressed to the model in scanned code; defaults to true
+	CrossFile         *bool                    `json:"cross_file,omitempty"`        // Add cross-file context (calls, imports, signatures) to security scan prompts; defaults to true
+	RecheckDays       *int                     `json:"recheck_days,omitempty"`      // Days a false positive stays suppressed; defaults to 90, 0 = forever
+	MaxFiles          *int                     `json:"max_files,omitempty"`         // Scans of more files ask for confirmation; defaults to 500, 0 = never ask
+
+	DefaultScanType     string `json:"default_scan_type,omitempty"`     // A registered engine such as security, triad, static, secrets or license; defaults to security
+	DefaultOutputFormat string `json:"default_output_format,omitempty"` // text, html, json or sarif; defaults to text
+
+	Keymap        Keymap `json:"keymap,omitempty"`
+	Plain         bool   `json:"plain,omitempty"`          // Screen-reader-friendly output: no spinners, colors, emoji or box drawing
+	StatusRefresh string `json:"status_refresh,omitempty"` // Spinner redraw interval as a Go duration; defaults to 80ms
+	EditorURL     string `json:"editor_url,omitempty"`     // How finding links open files: vscode, cursor, idea, sublime, or a template with {path} and {line}; defaults to file://
+
+	Triad            TriadConfig      `json:"triad,omitempty"`
+	StaticGate       StaticGateConfig `json:"static_gate,omitempty"`
+	IncludeTests     bool             `json:"include_tests,omitempty"`     // Scan test files too (skipped by default)
+	IncludeGenerated bool             `json:"include_generated,omitempty"` // Scan generated code too (skipped by default); findings are tagged generated-code
+	Policies         []Policy         `json:"policies,omitempty"`
+
+	// TrustedWorkspaces are the repository roots (or directories) in which
+	// sidekick may modify files. Elsewhere it runs in safe mode.
+	TrustedWorkspaces []string `json:"trusted_workspaces,omitempty"`
+
+	// Context holds organization context added to every scan prompt, e.g.
+	// {"compliance": "PCI-DSS", "environment": "internal, behind VPN"}.
+	// Custom prompts can also reference a value as {{.Context.compliance}}.
+	Context map[string]string `json:"context,omitempty"`
+
+	// Profiles are named connection and generation settings, e.g. "work" or
+	// "gpu-server"; Profile is the one applied by default (see ActiveProfile).
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	Profile  string             `json:"profile,omitempty"`
+
+	applied string  // Profile applied by Load
+	base    Profile // Top-level settings before it was applied
+}
+
+// StaticGateConfig checks files against static patterns before security
+// scans, so files without suspicious matches are skipped or sent to a
+// cheaper model.
+type StaticGateConfig struct {
+	Mode        string `json:"mode,omitempty"`         // "skip" or "fast"; empty scans every file with the main model
+	Model       string `json:"model,omitempty"`        // Model for files without matches in "fast" mode
+	MinSeverity string `json:"min_severity,omitempty"` // Matches below this severity don't count; defaults to low
+}
+
+// TriadConfig bounds the cost of triad scans. Zero values mean no limit.
+type TriadConfig struct {
+	MaxRounds int    `json:"max_rounds,omitempty"` // Defaults to 3
+	MaxTokens int    `json:"max_tokens,omitempty"` // Total prompt + completion tokens
+	Timeout   string `json:"timeout,omitempty"`    // Go duration, e.g. "10m"
+}
+
+// Keymap binds keys to actions in the interactive menus and review mode, in
+// addition to the built-in arrow, Enter and Esc keys. Each action lists key
+// names: a character ("j"), a two-key sequence ("gg"), or one of "up",
+// "down", "left", "right", "enter", "esc", "home", "end", "tab". Actions
+// left empty use the vim-style defaults from DefaultKeymap.
+type Keymap struct {
+	Up     []string     `json:"up,omitempty"`
+	Down   []string     `json:"down,omitempty"`
+	Top    []string     `json:"top,omitempty"`
+	Bottom []string     `json:"bottom,omitempty"`
+	Select []string     `json:"select,omitempty"`
+	Back   []string     `json:"back,omitempty"`
+	Review ReviewKeymap `json:"review,omitempty"`
+}
+
+// ReviewKeymap binds the commands typed in finding review mode.
+type ReviewKeymap struct {
+	Apply         []string `json:"apply,omitempty"`
+	Diff          []string `json:"diff,omitempty"`
+	Edit          []string `json:"edit,omitempty"`
+	Ignore        []string `json:"ignore,omitempty"`
+	FalsePositive []string `json:"false_positive,omitempty"`
+	Next          []string `json:"next,omitempty"`
+	Previous      []string `json:"previous,omitempty"`
+	Quit          []string `json:"quit,omitempty"`
+}
+
+// DefaultKeymap returns the vim-style default bindings.
+func DefaultKeymap() Keymap {
+	return Keymap{
+		Up:     []string{"k"},
+		Down:   []string{"j"},
+		Top:    []string{"gg"},
+		Bottom: []string{"G"},
+		Select: []string{"l"},
+		Back:   []string{"h", "q"},
+		Review: ReviewKeymap{
+			Apply:         []string{"a"},
+			Diff:          []string{"s"},
+			Edit:          []string{"e"},
+			Ignore:        []string{"i"},
+			FalsePositive: []string{"f"},
+			Next:          []string{"n", "j"},
+			Previous:      []string{"p", "k"},
+			Quit:          []string{"q"},
+		},
+	}
+}
+
+// WithDefaults fills every unset action from DefaultKeymap.
+func (k Keymap) WithDefaults() Keymap {
+	d := DefaultKeymap()
+	or := func(keys, def []string) []string {
+		if len(keys) == 0 {
+			return def
+		}
+		return keys
+	}
+	return Keymap{
+		Up:     or(k.Up, d.Up),
+		Down:   or(k.Down, d.Down),
+		Top:    or(k.Top, d.Top),
+		Bottom: or(k.Bottom, d.Bottom),
+		Select: or(k.Select, d.Select),
+		Back:   or(k.Back, d.Back),
+		Review: ReviewKeymap{
+			Apply:         or(k.Review.Apply, d.Review.Apply),
+			Diff:          or(k.Review.Diff, d.Review.Diff),
+			Edit:          or(k.Review.Edit, d.Review.Edit),
+			Ignore:        or(k.Review.Ignore, d.Review.Ignore),
+			FalsePositive: or(k.Review.FalsePositive, d.Review.FalsePositive),
+			Next:          or(k.Review.Next, d.Review.Next),
+			Previous:      or(k.Review.Previous, d.Review.Previous),
+			Quit:          or(k.Review.Quit, d.Review.Quit),
+		},
+	}
+}
+
+// StructuredOutputs reports whether security scans send Ollama the findings
+// schema, which is the default.
+func (c *Config) StructuredOutputs() bool {
+	return c.StructuredOutput == nil || *c.StructuredOutput
+}
+
+// CrossFileContexts reports whether security scan prompts include the
+// cross-file context of each file, which is the default.
+func (c *Config) CrossFileContexts() bool {
+	return c.CrossFile == nil || *c.CrossFile
+}
+
+// Quarantines reports whether text in scanned code that addresses the
+// model is neutralized before scanning, which is the default.
+func (c *Config) Quarantines() bool {
+	return c.Quarantine == nil || *c.Quarantine
+}
+
+// DefaultRecheckDays is how long findings marked false positive stay
+// suppressed when recheck_days isn't set.
+const DefaultRecheckDays = 90
+
+// Recheck returns the configured number of days a false positive stays
+// suppressed before it resurfaces for re-review; 0 means forever.
+func (c *Config) Recheck() int {
+	if c.RecheckDays == nil || *c.RecheckDays < 0 {
+		return DefaultRecheckDays
+	}
+	return *c.RecheckDays
+}
+
+// DefaultMaxFiles is how many files a scan covers before asking for
+// confirmation when max_files isn't set.
+const DefaultMaxFiles = 500
+
+// FileLimit returns the number of files above which a scan asks for
+// confirmation; 0 means it never asks.
+func (c *Config) FileLimit() int {
+	if c.MaxFiles == nil || *c.MaxFiles < 0 {
+		return DefaultMaxFiles
+	}
+	return *c.MaxFiles
+}
+
+// ScanType returns the configured default scan type, or "security".
+func (c *Config) ScanType() string {
+	if c.DefaultScanType == "" {
+		return "security"
+	}
+	return c.DefaultScanType
+}
+
+// OutputFormat returns the configured default output format, or "text".
+func (c *Config) OutputFormat() string {
+	if c.DefaultOutputFormat == "" {
+		return "text"
+	}
+	return c.DefaultOutputFormat
+}
+
+// MaxConcurrency is the largest accepted concurrency setting.
+const MaxConcurrency = 16
+
+// MinChunkSize is the smallest accepted chunk_size, in bytes.
+const MinChunkSize = 1000
+
+// DefaultSeed is the fixed seed used for reproducible security scans.
+const DefaultSeed = 42
+
+// DefaultNumCtx is the context window requested for scans, large enough for
+// a default-sized chunk and its prompt. Ollama's own default of a few
+// thousand tokens silently truncates larger files.
+const DefaultNumCtx = 32768
+
+// MinNumCtx is the smallest accepted num_ctx; scan prompts alone need about
+// this much.
+const MinNumCtx = 2048
+
+// NotifyConfig lists incoming webhook URLs that receive a scan summary.
+type NotifyConfig struct {
+	SlackWebhook   string `json:"slack_webhook,omitempty"`
+	TeamsWebhook   string `json:"teams_webhook,omitempty"`
+	DiscordWebhook string `json:"discord_webhook,omitempty"`
+}
+
+// SMTPConfig holds the mail server used to deliver scan reports by email.
+// The password may be supplied via SIDEKICK_SMTP_PASSWORD instead of the file.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+}
+
+// SeverityStyle changes how a severity is shown in the terminal, review
+// mode and HTML reports. Empty fields keep the default.
+type SeverityStyle struct {
+	Emoji string `json:"emoji,omitempty"`
+	Color string `json:"color,omitempty"` // Hex, e.g. "#d55e00"
+}
+
+// SeverityOverride re-maps the severity of findings that match every
+// non-empty criterion. Rules are evaluated in order; the first match wins.
+type SeverityOverride struct {
+	IssueID  string `json:"issue_id,omitempty"` // e.g. "CWE-89"
+	Title    string `json:"title,omitempty"`    // Case-insensitive substring of the finding title
+	Path     string `json:"path,omitempty"`     // Glob matched against the file name or full path
+	Severity string `json:"severity"`           // CRITICAL, HIGH, MEDIUM, LOW
 }
 
 func GetConfigPath() (string, error) {
@@ -21,6 +285,82 @@ func GetConfigPath() (string, error) {
 	return filepath.Join(homeDir, ".sidekick", "config.json"), nil
 }
 
+// GetReportsDir returns the directory where generated reports are kept.
+func GetReportsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "reports"), nil
+}
+
+// GetBackupsDir returns the directory holding per-session backups of files
+// modified by fixes.
+func GetBackupsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "backups"), nil
+}
+
+// GetCacheDir returns the directory holding cached model results.
+func GetCacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "cache"), nil
+}
+
+// GetAuditDir returns the directory holding the review decision logs.
+func GetAuditDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "audit"), nil
+}
+
+// GetDebugDir returns the directory holding structured debug logs of the
+// model calls made by --debug scans.
+func GetDebugDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "debug"), nil
+}
+
+// GetHistoryPath returns the file where per-scan history is appended.
+func GetHistoryPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "history.jsonl"), nil
+}
+
+// GetSuppressionsPath returns the file listing the findings suppressed as
+// false positives.
+func GetSuppressionsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "suppressions.json"), nil
+}
+
+// GetSessionPath returns the file describing the last scan, used to scan
+// its failed files again.
+func GetSessionPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sidekick", "last-scan.json"), nil
+}
+
 func Load() (*Config, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -30,7 +370,11 @@ func Load() (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return GetDefault(), nil
+			def := GetDefault()
+			if err := def.applyProfile(def.ActiveProfile()); err != nil {
+				return nil, err
+			}
+			return def, nil
 		}
 		return nil, err
 	}
@@ -39,10 +383,192 @@ func Load() (*Config, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
+	if err := config.applyProfile(config.ActiveProfile()); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
 
+// LoadStrict reads the config file rejecting unknown fields, for validation.
+// A missing file is not an error.
+func LoadStrict() (*Config, string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return GetDefault(), configPath, nil
+		}
+		return nil, configPath, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var config Config
+	if err := dec.Decode(&config); err != nil {
+		return nil, configPath, err
+	}
+
+	return &config, configPath, nil
+}
+
+// Validate reports problems with config values that would make a scan fail
+// or behave unexpectedly.
+func (c *Config) Validate() []string {
+	var problems []string
+
+	if strings.TrimSpace(c.DefaultModel) == "" {
+		problems = append(problems, "default_model is empty")
+	}
+	if u, err := url.Parse(c.OllamaURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("ollama_url %q must be an http:// or https:// URL", c.OllamaURL))
+	}
+
+	for i, rule := range c.SeverityOverrides {
+		if !isSeverity(rule.Severity) {
+			problems = append(problems, fmt.Sprintf("severity_overrides[%d].severity %q must be CRITICAL, HIGH, MEDIUM or LOW", i, rule.Severity))
+		}
+		if rule.IssueID == "" && rule.Title == "" && rule.Path == "" {
+			problems = append(problems, fmt.Sprintf("severity_overrides[%d] has no issue_id, title or path and never matches", i))
+		}
+		if rule.Path != "" {
+			if _, err := filepath.Match(rule.Path, ""); err != nil {
+				problems = append(problems, fmt.Sprintf("severity_overrides[%d].path %q is not a valid glob", i, rule.Path))
+			}
+		}
+	}
+
+	for sev, style := range c.SeverityStyles {
+		if !isSeverity(sev) {
+			problems = append(problems, fmt.Sprintf("severity_styles key %q must be CRITICAL, HIGH, MEDIUM or LOW", sev))
+		}
+		if style.Color != "" && !isHexColor(style.Color) {
+			problems = append(problems, fmt.Sprintf("severity_styles.%s.color %q must be a hex color like #d55e00", sev, style.Color))
+		}
+	}
+
+	if c.Triad.MaxRounds < 0 || c.Triad.MaxTokens < 0 {
+		problems = append(problems, "triad.max_rounds and triad.max_tokens must not be negative")
+	}
+	if c.Triad.Timeout != "" {
+		if _, err := time.ParseDuration(c.Triad.Timeout); err != nil {
+			problems = append(problems, fmt.Sprintf("triad.timeout %q is not a duration (e.g. \"10m\")", c.Triad.Timeout))
+		}
+	}
+
+	if err := ui.CheckEditorURL(c.EditorURL); err != nil {
+		problems = append(problems, fmt.Sprintf("editor_url %q %v", c.EditorURL, err))
+	}
+	if c.StatusRefresh != "" {
+		if d, err := time.ParseDuration(c.StatusRefresh); err != nil || d < 10*time.Millisecond {
+			problems = append(problems, fmt.Sprintf("status_refresh %q must be a duration of at least 10ms (e.g. \"250ms\")", c.StatusRefresh))
+		}
+	}
+
+	problems = append(problems, validatePolicies(c.Policies)...)
+	problems = append(problems, validateProfiles(c)...)
+
+	if c.Concurrency < 0 || c.Concurrency > MaxConcurrency {
+		problems = append(problems, fmt.Sprintf("concurrency %d must be between 1 and %d (0 = default)", c.Concurrency, MaxConcurrency))
+	}
+	if c.ChunkSize != 0 && c.ChunkSize < MinChunkSize {
+		problems = append(problems, fmt.Sprintf("chunk_size %d must be at least %d bytes (0 = default)", c.ChunkSize, MinChunkSize))
+	}
+	if c.ChunkOverlap < 0 {
+		problems = append(problems, fmt.Sprintf("chunk_overlap %d must not be negative", c.ChunkOverlap))
+	}
+	if c.MaxInFlight < 0 {
+		problems = append(problems, fmt.Sprintf("max_in_flight %d must not be negative", c.MaxInFlight))
+	}
+	if c.MaxFiles != nil && *c.MaxFiles < 0 {
+		problems = append(problems, fmt.Sprintf("max_files %d must not be negative (0 = never ask)", *c.MaxFiles))
+	}
+	if c.JSONRetries != nil && *c.JSONRetries < 0 {
+		problems = append(problems, fmt.Sprintf("json_retries %d must not be negative", *c.JSONRetries))
+	}
+	switch c.StaticGate.Mode {
+	case "", "skip":
+	case "fast":
+		if c.StaticGate.Model == "" {
+			problems = append(problems, `static_gate.model is required with mode "fast"`)
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("static_gate.mode %q must be skip or fast", c.StaticGate.Mode))
+	}
+	if c.StaticGate.MinSeverity != "" && !isSeverity(c.StaticGate.MinSeverity) {
+		problems = append(problems, fmt.Sprintf("static_gate.min_severity %q must be CRITICAL, HIGH, MEDIUM or LOW", c.StaticGate.MinSeverity))
+	}
+	if c.Temperature != nil && *c.Temperature < 0 {
+		problems = append(problems, fmt.Sprintf("temperature %g must not be negative", *c.Temperature))
+	}
+	if c.TopP != nil && (*c.TopP <= 0 || *c.TopP > 1) {
+		problems = append(problems, fmt.Sprintf("top_p %g must be greater than 0 and at most 1", *c.TopP))
+	}
+	if c.NumCtx != nil && *c.NumCtx < MinNumCtx {
+		problems = append(problems, fmt.Sprintf("num_ctx %d must be at least %d tokens", *c.NumCtx, MinNumCtx))
+	}
+	if c.NumPredict != nil && *c.NumPredict < -1 {
+		problems = append(problems, fmt.Sprintf("num_predict %d must be -1 (no limit) or more", *c.NumPredict))
+	}
+
+	for name, value := range c.Context {
+		if !isIdentifier(name) {
+			problems = append(problems, fmt.Sprintf("context key %q must be letters, digits and underscores (it is used as {{.Context.%s}})", name, name))
+		}
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, fmt.Sprintf("context.%s is empty", name))
+		}
+	}
+
+	if c.SMTP != nil {
+		if c.SMTP.Host == "" {
+			problems = append(problems, "smtp.host is empty")
+		}
+		if c.SMTP.From == "" {
+			problems = append(problems, "smtp.from is empty")
+		}
+	}
+
+	for name, hook := range map[string]string{
+		"slack_webhook":   c.Notifications.SlackWebhook,
+		"teams_webhook":   c.Notifications.TeamsWebhook,
+		"discord_webhook": c.Notifications.DiscordWebhook,
+	} {
+		if hook == "" {
+			continue
+		}
+		if u, err := url.Parse(hook); err != nil || u.Scheme != "https" {
+			problems = append(problems, fmt.Sprintf("notifications.%s must be an https:// URL", name))
+		}
+	}
+
+	return problems
+}
+
+func isSeverity(s string) bool {
+	switch strings.ToUpper(s) {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW":
+		return true
+	}
+	return false
+}
+
+func isHexColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if !unicode.Is(unicode.ASCII_Hex_Digit, r) {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Config) Save() error {
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -55,7 +581,7 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	data, err := json.MarshalIndent(c.unapplied(), "", "  ")
 	if err != nil {
 		return err
 	}
@@ -75,3 +601,18 @@ func (c *Config) Display() {
 	fmt.Printf("  Model: %s | URL: %s | Debug: %v\n",
 		c.DefaultModel, c.OllamaURL, c.Debug)
 }
+
+// isIdentifier reports whether name can be used in a template field
+// reference such as {{.Context.name}}.
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
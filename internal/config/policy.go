@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Policy applies scan settings to one subtree of the scanned project. When
+// several policies match a file, the one with the longest path wins.
+type Policy struct {
+	Path        string `json:"path"`                   // Directory relative to the scan root, e.g. "internal/auth"
+	ScanType    string `json:"scan_type,omitempty"`    // Scan type for files in this subtree
+	MinSeverity string `json:"min_severity,omitempty"` // Drop findings below this severity
+	Exclude     bool   `json:"exclude,omitempty"`      // Skip this subtree entirely
+}
+
+// PolicyFor returns the most specific policy covering path, or nil.
+func PolicyFor(policies []Policy, root, path string) *Policy {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	var best *Policy
+	bestLen := -1
+	for i := range policies {
+		prefix := strings.Trim(filepath.ToSlash(policies[i].Path), "/")
+		if prefix == "" || prefix == "." {
+			prefix = ""
+		} else if rel != prefix && !strings.HasPrefix(rel, prefix+"/") {
+			continue
+		}
+		if len(prefix) > bestLen {
+			best = &policies[i]
+			bestLen = len(prefix)
+		}
+	}
+	return best
+}
+
+// SeverityRank orders severities from LOW (1) to CRITICAL (4); anything
+// else ranks 0.
+func SeverityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return 4
+	case "HIGH":
+		return 3
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
+
+func validatePolicies(policies []Policy) []string {
+	var problems []string
+	for i, p := range policies {
+		if strings.TrimSpace(p.Path) == "" {
+			problems = append(problems, fmt.Sprintf("policies[%d].path is empty (use \".\" for the whole project)", i))
+		}
+		switch p.ScanType {
+		case "", "security", "triad", "static", "secrets", "license":
+		default:
+			problems = append(problems, fmt.Sprintf("policies[%d].scan_type %q must be security, triad, static, secrets or license", i, p.ScanType))
+		}
+		if p.MinSeverity != "" && !isSeverity(p.MinSeverity) {
+			problems = append(problems, fmt.Sprintf("policies[%d].min_severity %q must be CRITICAL, HIGH, MEDIUM or LOW", i, p.MinSeverity))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProfileEnv names the environment variable selecting a profile; it takes
+// precedence over the profile saved in the config file.
+const ProfileEnv = "SIDEKICK_PROFILE"
+
+// Profile is a named set of connection and generation settings, e.g. one
+// per Ollama server. Fields left empty keep the top-level setting.
+type Profile struct {
+	OllamaURL   string   `json:"ollama_url,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	NumCtx      *int     `json:"num_ctx,omitempty"`
+	NumPredict  *int     `json:"num_predict,omitempty"`
+	MaxInFlight int      `json:"max_in_flight,omitempty"`
+}
+
+// ErrUnknownProfile is returned by Load when the selected profile isn't
+// configured.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// selectedProfile is the profile chosen with --profile, if any.
+var selectedProfile string
+
+// SelectProfile makes Load apply the named profile instead of the one from
+// SIDEKICK_PROFILE or the config file; "" restores that default.
+func SelectProfile(name string) {
+	selectedProfile = name
+}
+
+// ActiveProfile returns the name of the profile Load applies: the one given
+// to SelectProfile, else SIDEKICK_PROFILE, else the saved profile. "" means
+// the top-level settings are used as they are.
+func (c *Config) ActiveProfile() string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	if name := os.Getenv(ProfileEnv); name != "" {
+		return name
+	}
+	return c.Profile
+}
+
+// ProfileNames returns the names of the configured profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile overlays the named profile on the top-level settings,
+// remembering them so Save can write later changes to the profile instead.
+func (c *Config) applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("%w %q (no profiles are configured)", ErrUnknownProfile, name)
+		}
+		return fmt.Errorf("%w %q (expected %s)", ErrUnknownProfile, name, strings.Join(c.ProfileNames(), ", "))
+	}
+	c.applied = name
+	c.base = c.profileSettings()
+	c.setProfileSettings(overlay(c.base, p))
+	return nil
+}
+
+// profileSettings returns the top-level values of the settings a profile
+// can change.
+func (c *Config) profileSettings() Profile {
+	return Profile{
+		OllamaURL:   c.OllamaURL,
+		Model:       c.DefaultModel,
+		Temperature: c.Temperature,
+		TopP:        c.TopP,
+		Seed:        c.Seed,
+		NumCtx:      c.NumCtx,
+		NumPredict:  c.NumPredict,
+		MaxInFlight: c.MaxInFlight,
+	}
+}
+
+func (c *Config) setProfileSettings(p Profile) {
+	c.OllamaURL = p.OllamaURL
+	c.DefaultModel = p.Model
+	c.Temperature = p.Temperature
+	c.TopP = p.TopP
+	c.Seed = p.Seed
+	c.NumCtx = p.NumCtx
+	c.NumPredict = p.NumPredict
+	c.MaxInFlight = p.MaxInFlight
+}
+
+// overlay returns base with the fields set in p replacing its own.
+func overlay(base, p Profile) Profile {
+	if p.OllamaURL != "" {
+		base.OllamaURL = p.OllamaURL
+	}
+	if p.Model != "" {
+		base.Model = p.Model
+	}
+	if p.Temperature != nil {
+		base.Temperature = p.Temperature
+	}
+	if p.TopP != nil {
+		base.TopP = p.TopP
+	}
+	if p.Seed != nil {
+		base.Seed = p.Seed
+	}
+	if p.NumCtx != nil {
+		base.NumCtx = p.NumCtx
+	}
+	if p.NumPredict != nil {
+		base.NumPredict = p.NumPredict
+	}
+	if p.MaxInFlight != 0 {
+		base.MaxInFlight = p.MaxInFlight
+	}
+	return base
+}
+
+// unapplied returns a copy of c for saving: settings changed while a
+// profile is applied are stored in that profile, and the top-level
+// settings keep the values they had before it was applied.
+func (c *Config) unapplied() *Config {
+	if c.applied == "" {
+		return c
+	}
+	saved := *c
+	p, ok := c.Profiles[c.applied]
+	if !ok {
+		// The profile was deleted, and the changes made under it with it
+		saved.setProfileSettings(c.base)
+		return &saved
+	}
+	cur := c.profileSettings()
+	if p.OllamaURL != "" || cur.OllamaURL != c.base.OllamaURL {
+		p.OllamaURL = cur.OllamaURL
+	}
+	if p.Model != "" || cur.Model != c.base.Model {
+		p.Model = cur.Model
+	}
+	if p.Temperature != nil || !sameFloat(cur.Temperature, c.base.Temperature) {
+		p.Temperature = cur.Temperature
+	}
+	if p.TopP != nil || !sameFloat(cur.TopP, c.base.TopP) {
+		p.TopP = cur.TopP
+	}
+	if p.Seed != nil || !sameInt(cur.Seed, c.base.Seed) {
+		p.Seed = cur.Seed
+	}
+	if p.NumCtx != nil || !sameInt(cur.NumCtx, c.base.NumCtx) {
+		p.NumCtx = cur.NumCtx
+	}
+	if p.NumPredict != nil || !sameInt(cur.NumPredict, c.base.NumPredict) {
+		p.NumPredict = cur.NumPredict
+	}
+	if p.MaxInFlight != 0 || cur.MaxInFlight != c.base.MaxInFlight {
+		p.MaxInFlight = cur.MaxInFlight
+	}
+	saved.Profiles = make(map[string]Profile, len(c.Profiles))
+	for name, profile := range c.Profiles {
+		saved.Profiles[name] = profile
+	}
+	saved.Profiles[c.applied] = p
+	saved.setProfileSettings(c.base)
+	return &saved
+}
+
+func sameFloat(a, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameInt(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// validateProfiles reports problems with the configured profiles.
+func validateProfiles(c *Config) []string {
+	var problems []string
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			problems = append(problems, fmt.Sprintf("profile %q is not one of the configured profiles", c.Profile))
+		}
+	}
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		if strings.TrimSpace(name) == "" {
+			problems = append(problems, "profiles has an entry with an empty name")
+		}
+		if p.OllamaURL != "" {
+			if u, err := url.Parse(p.OllamaURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				problems = append(problems, fmt.Sprintf("profiles.%s.ollama_url %q must be an http:// or https:// URL", name, p.OllamaURL))
+			}
+		}
+		if p.Temperature != nil && *p.Temperature < 0 {
+			problems = append(problems, fmt.Sprintf("profiles.%s.temperature %g must not be negative", name, *p.Temperature))
+		}
+		if p.TopP != nil && (*p.TopP <= 0 || *p.TopP > 1) {
+			problems = append(problems, fmt.Sprintf("profiles.%s.top_p %g must be greater than 0 and at most 1", name, *p.TopP))
+		}
+		if p.NumCtx != nil && *p.NumCtx < MinNumCtx {
+			problems = append(problems, fmt.Sprintf("profiles.%s.num_ctx %d must be at least %d tokens", name, *p.NumCtx, MinNumCtx))
+		}
+		if p.NumPredict != nil && *p.NumPredict < -1 {
+			problems = append(problems, fmt.Sprintf("profiles.%s.num_predict %d must be -1 (no limit) or more", name, *p.NumPredict))
+		}
+		if p.MaxInFlight < 0 {
+			problems = append(problems, fmt.Sprintf("profiles.%s.max_in_flight %d must not be negative", name, p.MaxInFlight))
+		}
+	}
+	return problems
+}
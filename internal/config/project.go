@@ -0,0 +1,112 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the name of the per-project config file, looked up from
+// the scan path towards the filesystem root.
+const ProjectFile = ".sidekick.yaml"
+
+// Project is the scan policy a team commits to its repository. Its settings
+// override the user's config file; command-line flags override both.
+type Project struct {
+	Model       string   `yaml:"model,omitempty"`
+	ScanType    string   `yaml:"scan_type,omitempty"`    // A registered engine such as security, triad, static, secrets or license
+	Ignore      []string `yaml:"ignore,omitempty"`       // Gitignore-style patterns relative to the project directory
+	MinSeverity string   `yaml:"min_severity,omitempty"` // Only show and report findings at or above this severity
+	FailOn      string   `yaml:"fail_on,omitempty"`      // Exit non-zero on findings at or above this severity
+
+	// PromptTemplates replace the built-in custom prompt templates, keyed by
+	// mode (ask, edit or plan). Each must reference {{.UserPrompt}},
+	// {{.FilePath}} and {{.Code}}.
+	PromptTemplates map[string]string `yaml:"prompt_templates,omitempty"`
+
+	Path string `yaml:"-"` // File the project was read from
+}
+
+// Dir returns the directory holding the project file; Ignore patterns are
+// relative to it.
+func (p *Project) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// FindProject reads the nearest .sidekick.yaml in start or one of its
+// parents. It returns nil when there is none. Unknown keys are errors, so a
+// typo in a shared policy doesn't silently go unapplied.
+func FindProject(start string) (*Project, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return parseProject(path, data)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func parseProject(path string, data []byte) (*Project, error) {
+	p := &Project{Path: path}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Apply overlays the project's model and scan type on c.
+func (p *Project) Apply(c *Config) {
+	if p.Model != "" {
+		c.DefaultModel = p.Model
+	}
+	if p.ScanType != "" {
+		c.DefaultScanType = p.ScanType
+	}
+}
+
+// Validate reports problems with the project settings.
+func (p *Project) Validate() []string {
+	var problems []string
+	if p.MinSeverity != "" && !isSeverity(p.MinSeverity) {
+		problems = append(problems, fmt.Sprintf("min_severity %q must be CRITICAL, HIGH, MEDIUM or LOW", p.MinSeverity))
+	}
+	if p.FailOn != "" && !isSeverity(p.FailOn) {
+		problems = append(problems, fmt.Sprintf("fail_on %q must be CRITICAL, HIGH, MEDIUM or LOW", p.FailOn))
+	}
+	modes := make([]string, 0, len(p.PromptTemplates))
+	for mode := range p.PromptTemplates {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		switch mode {
+		case "ask", "edit", "plan":
+		default:
+			problems = append(problems, fmt.Sprintf("prompt_templates key %q must be ask, edit or plan", mode))
+		}
+	}
+	return problems
+}
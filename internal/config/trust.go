@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Workspace returns the workspace a path belongs to: the root of its git
+// repository, or its own directory outside one.
+func Workspace(path string) string {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		dir = filepath.Clean(path)
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// Trusted reports whether path lies in a trusted workspace. Sidekick only
+// modifies files, e.g. to apply fixes, in trusted workspaces.
+func (c *Config) Trusted(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, root := range c.TrustedWorkspaces {
+		if Within(root, abs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Trust adds the workspace of path to the trusted workspaces and returns
+// it. The caller saves the config.
+func (c *Config) Trust(path string) string {
+	root := Workspace(path)
+	for _, trusted := range c.TrustedWorkspaces {
+		if trusted == root {
+			return root
+		}
+	}
+	c.TrustedWorkspaces = append(c.TrustedWorkspaces, root)
+	return root
+}
+
+// Untrust removes the workspace of path, or path itself when trusted as
+// given, from the trusted workspaces. It reports whether one was removed.
+func (c *Config) Untrust(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	root := Workspace(path)
+	kept := c.TrustedWorkspaces[:0]
+	for _, trusted := range c.TrustedWorkspaces {
+		if trusted != abs && trusted != root {
+			kept = append(kept, trusted)
+		}
+	}
+	removed := len(kept) < len(c.TrustedWorkspaces)
+	c.TrustedWorkspaces = kept
+	return removed
+}
+
+// ArtifactPath returns where to write a file named name, such as a report,
+// for a scan of scanPath when no path was given: the working directory,
+// unless that lies in the scan's workspace and it isn't trusted, then the
+// reports directory.
+func (c *Config) ArtifactPath(name, scanPath string) string {
+	if c.Trusted(scanPath) {
+		return name
+	}
+	wd, err := os.Getwd()
+	if err != nil || !Within(Workspace(scanPath), wd) {
+		return name
+	}
+	dir, err := GetReportsDir()
+	if err != nil || os.MkdirAll(dir, 0755) != nil {
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+// Within reports whether path is dir or inside it.
+func Within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
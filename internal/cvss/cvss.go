@@ -0,0 +1,153 @@
+// Package cvss parses CVSS v3.0 and v3.1 vectors and computes their base
+// scores, as specified by FIRST (https://www.first.org/cvss/v3.1/specification-document).
+package cvss
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// baseMetrics lists the base metrics in the order of a canonical vector,
+// with the values each takes.
+var baseMetrics = []struct {
+	name   string
+	values string
+}{
+	{"AV", "NALP"},
+	{"AC", "LH"},
+	{"PR", "NLH"},
+	{"UI", "NR"},
+	{"S", "UC"},
+	{"C", "HLN"},
+	{"I", "HLN"},
+	{"A", "HLN"},
+}
+
+// otherMetrics are the temporal and environmental metrics. They are
+// accepted in vectors but do not change the base score.
+var otherMetrics = map[string]bool{
+	"E": true, "RL": true, "RC": true,
+	"CR": true, "IR": true, "AR": true,
+	"MAV": true, "MAC": true, "MPR": true, "MUI": true, "MS": true, "MC": true, "MI": true, "MA": true,
+}
+
+// Vector is a parsed CVSS v3 vector.
+type Vector struct {
+	Version string            // "3.0" or "3.1"
+	Metrics map[string]string // Base metric -> value, e.g. "AV" -> "N"
+}
+
+// Parse validates a CVSS v3 vector such as
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H". Every base metric must
+// appear exactly once with a valid value.
+func Parse(s string) (*Vector, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	version, ok := strings.CutPrefix(parts[0], "CVSS:")
+	if !ok || (version != "3.0" && version != "3.1") {
+		return nil, fmt.Errorf("not a CVSS v3 vector: %q", s)
+	}
+
+	v := &Vector{Version: version, Metrics: make(map[string]string)}
+	seen := make(map[string]bool)
+	for _, part := range parts[1:] {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed metric %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("metric %s given twice", name)
+		}
+		seen[name] = true
+		if otherMetrics[name] {
+			continue
+		}
+		valid := ""
+		for _, m := range baseMetrics {
+			if m.name == name {
+				valid = m.values
+			}
+		}
+		if valid == "" {
+			return nil, fmt.Errorf("unknown metric %s", name)
+		}
+		if len(value) != 1 || !strings.Contains(valid, value) {
+			return nil, fmt.Errorf("invalid value %s for metric %s", value, name)
+		}
+		v.Metrics[name] = value
+	}
+	for _, m := range baseMetrics {
+		if _, ok := v.Metrics[m.name]; !ok {
+			return nil, fmt.Errorf("missing base metric %s", m.name)
+		}
+	}
+	return v, nil
+}
+
+// String returns the base vector in canonical metric order, dropping any
+// temporal and environmental metrics.
+func (v *Vector) String() string {
+	var b strings.Builder
+	b.WriteString("CVSS:" + v.Version)
+	for _, m := range baseMetrics {
+		b.WriteString("/" + m.name + ":" + v.Metrics[m.name])
+	}
+	return b.String()
+}
+
+// BaseScore computes the vector's base score, from 0.0 to 10.0.
+func (v *Vector) BaseScore() float64 {
+	changed := v.Metrics["S"] == "C"
+
+	av := map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}[v.Metrics["AV"]]
+	ac := map[string]float64{"L": 0.77, "H": 0.44}[v.Metrics["AC"]]
+	ui := map[string]float64{"N": 0.85, "R": 0.62}[v.Metrics["UI"]]
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}[v.Metrics["PR"]]
+	if changed {
+		pr = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}[v.Metrics["PR"]]
+	}
+	cia := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+
+	iss := 1 - (1-cia[v.Metrics["C"]])*(1-cia[v.Metrics["I"]])*(1-cia[v.Metrics["A"]])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0
+	}
+	exploitability := 8.22 * av * ac * pr * ui
+	if changed {
+		return v.roundUp(math.Min(1.08*(impact+exploitability), 10))
+	}
+	return v.roundUp(math.Min(impact+exploitability, 10))
+}
+
+// roundUp rounds up to one decimal. v3.1 defines it to avoid floating
+// point errors, such as 4.000001 rounding up to 4.1.
+func (v *Vector) roundUp(x float64) float64 {
+	if v.Version == "3.0" {
+		return math.Ceil(x*10) / 10
+	}
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+// Rating returns the qualitative severity of a score: NONE, LOW, MEDIUM,
+// HIGH or CRITICAL.
+func Rating(score float64) string {
+	switch {
+	case score >= 9.0:
+		return "CRITICAL"
+	case score >= 7.0:
+		return "HIGH"
+	case score >= 4.0:
+		return "MEDIUM"
+	case score > 0:
+		return "LOW"
+	}
+	return "NONE"
+}
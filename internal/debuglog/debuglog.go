@@ -0,0 +1,140 @@
+package debuglog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// keepLogs is how many debug logs are kept; older ones are removed when a
+// new one is created.
+const keepLogs = 10
+
+// Entry is one model call, stored as a line of JSON in a debug log.
+type Entry struct {
+	Time             time.Time `json:"time"`
+	File             string    `json:"file"`
+	Stage            string    `json:"stage"` // e.g. "context", "scan", "json-repair", "triad-auditor"
+	Model            string    `json:"model"`
+	Prompt           string    `json:"prompt"`
+	Response         string    `json:"response"`
+	Error            string    `json:"error,omitempty"`
+	DurationMS       int64     `json:"duration_ms"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+}
+
+// Log is the structured debug log of one scan.
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// Create starts a new debug log in the debug directory, removing the
+// oldest logs beyond the last few.
+func Create() (*Log, error) {
+	dir, err := config.GetDebugDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create debug directory: %w", err)
+	}
+	prune(dir)
+
+	path := filepath.Join(dir, time.Now().Format("20060102-150405.000")+".jsonl")
+	// Prompts hold source code, so the log is private
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create debug log: %w", err)
+	}
+	return &Log{f: f, path: path}, nil
+}
+
+// Path returns where the log is written.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes an entry. Write failures are ignored: the debug log must
+// never fail a scan.
+func (l *Log) Append(e Entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.f.Write(append(data, '\n'))
+}
+
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Latest returns the path of the most recent debug log.
+func Latest() (string, error) {
+	dir, err := config.GetDebugDir()
+	if err != nil {
+		return "", err
+	}
+	logs := list(dir)
+	if len(logs) == 0 {
+		return "", fmt.Errorf("no debug logs in %s; run a scan with --debug first", dir)
+	}
+	return logs[len(logs)-1], nil
+}
+
+// Read returns the entries of a debug log. Lines that fail to parse are
+// skipped.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read debug log: %w", err)
+	}
+	return entries, nil
+}
+
+// list returns the debug logs in dir, oldest first. Their names are
+// timestamps, so name order is time order.
+func list(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	sort.Strings(matches)
+	return matches
+}
+
+// prune removes all but the newest keepLogs-1 logs, making room for one
+// more.
+func prune(dir string) {
+	logs := list(dir)
+	for len(logs) >= keepLogs {
+		if !strings.HasSuffix(logs[0], ".jsonl") {
+			break
+		}
+		os.Remove(logs[0])
+		logs = logs[1:]
+	}
+}
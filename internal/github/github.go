@@ -0,0 +1,221 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the GitHub REST API used unless GITHUB_API_URL says
+// otherwise (GitHub Enterprise Server, GitHub Actions).
+const DefaultAPIURL = "https://api.github.com"
+
+// PullRequest identifies a pull request.
+type PullRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (pr PullRequest) String() string {
+	return fmt.Sprintf("%s/%s#%d", pr.Owner, pr.Repo, pr.Number)
+}
+
+var (
+	shortRef = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+	urlRef   = regexp.MustCompile(`^https?://[^/]+/([\w.-]+)/([\w.-]+)/pull/(\d+)/?$`)
+)
+
+// ParsePullRequest reads "owner/repo#123" or a pull request URL.
+func ParsePullRequest(ref string) (PullRequest, error) {
+	m := shortRef.FindStringSubmatch(ref)
+	if m == nil {
+		m = urlRef.FindStringSubmatch(ref)
+	}
+	if m == nil {
+		return PullRequest{}, fmt.Errorf("invalid pull request %q (expected owner/repo#123 or a pull request URL)", ref)
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil || n <= 0 {
+		return PullRequest{}, fmt.Errorf("invalid pull request number in %q", ref)
+	}
+	return PullRequest{Owner: m[1], Repo: m[2], Number: n}, nil
+}
+
+// Client calls the GitHub REST API with a token.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// HeadCommit returns the SHA of the latest commit of pr.
+func (c *Client) HeadCommit(pr PullRequest) (string, error) {
+	var resp struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.do("GET", c.pullPath(pr), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Head.SHA, nil
+}
+
+// LineRange is an inclusive range of line numbers.
+type LineRange struct {
+	Start, End int
+}
+
+// CommentableLines returns, per file path in pr, the line ranges of the new
+// version that appear in the diff and so can carry review comments.
+func (c *Client) CommentableLines(pr PullRequest) (map[string][]LineRange, error) {
+	lines := make(map[string][]LineRange)
+	for page := 1; ; page++ {
+		var files []struct {
+			Filename string `json:"filename"`
+			Status   string `json:"status"`
+			Patch    string `json:"patch"`
+		}
+		path := fmt.Sprintf("%s/files?per_page=100&page=%d", c.pullPath(pr), page)
+		if err := c.do("GET", path, nil, &files); err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.Status != "removed" {
+				lines[f.Filename] = hunkRanges(f.Patch)
+			}
+		}
+		if len(files) < 100 {
+			return lines, nil
+		}
+	}
+}
+
+// hunkRanges returns the new-file range of every hunk in patch, including
+// its context lines.
+func hunkRanges(patch string) []LineRange {
+	var ranges []LineRange
+	for _, line := range strings.Split(patch, "\n") {
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+			continue
+		}
+		startStr, countStr, hasCount := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+		start, err := strconv.Atoi(startStr)
+		if err != nil {
+			continue
+		}
+		count := 1
+		if hasCount {
+			if count, err = strconv.Atoi(countStr); err != nil {
+				continue
+			}
+		}
+		if count > 0 {
+			ranges = append(ranges, LineRange{Start: start, End: start + count - 1})
+		}
+	}
+	return ranges
+}
+
+// ReviewComment is a comment on lines StartLine..Line of the new version of
+// Path. StartLine is 0 for a single-line comment.
+type ReviewComment struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	Line      int    `json:"line"`
+	Side      string `json:"side"`
+	Body      string `json:"body"`
+}
+
+// CreateReview posts a review with body and comments on commit of pr, and
+// returns its URL. The review comments without approving or requesting
+// changes.
+func (c *Client) CreateReview(pr PullRequest, commit, body string, comments []ReviewComment) (string, error) {
+	for i := range comments {
+		comments[i].Side = "RIGHT"
+	}
+	req := struct {
+		CommitID string          `json:"commit_id"`
+		Body     string          `json:"body"`
+		Event    string          `json:"event"`
+		Comments []ReviewComment `json:"comments"`
+	}{commit, body, "COMMENT", comments}
+
+	var resp struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.do("POST", c.pullPath(pr)+"/reviews", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.HTMLURL, nil
+}
+
+func (c *Client) pullPath(pr PullRequest) string {
+	return fmt.Sprintf("/repos/%s/%s/pulls/%d", pr.Owner, pr.Repo, pr.Number)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out.
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GitHub returned status %d for %s %s: %s", resp.StatusCode, method, path, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return nil
+}
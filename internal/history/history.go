@@ -0,0 +1,104 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// Entry is one completed scan, stored as a line of JSON in the history file.
+type Entry struct {
+	Time             time.Time      `json:"time"`
+	Target           string         `json:"target"`
+	Model            string         `json:"model"`
+	ScanType         string         `json:"scan_type"`
+	FilesScanned     int            `json:"files_scanned"`
+	FilesWithIssues  int            `json:"files_with_issues"`
+	PromptTokens     int            `json:"prompt_tokens"`
+	CompletionTokens int            `json:"completion_tokens"`
+	DurationMS       int64          `json:"duration_ms,omitempty"`
+	BySeverity       map[string]int `json:"by_severity,omitempty"` // Finding counts keyed by upper-case severity
+	ByCWE            map[string]int `json:"by_cwe,omitempty"`      // Finding counts keyed by issue ID, e.g. "CWE-89"
+}
+
+// Duration returns how long the scan took, or 0 if it wasn't recorded.
+func (e Entry) Duration() time.Duration {
+	return time.Duration(e.DurationMS) * time.Millisecond
+}
+
+// Append adds an entry to the history file, creating it if needed.
+func Append(e Entry) error {
+	path, err := config.GetHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Load reads every entry from the history file. A missing file yields no entries.
+// Lines that fail to parse are skipped.
+func Load() ([]Entry, error) {
+	path, err := config.GetHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Latest returns the most recent entry, restricted to target when it is
+// non-empty. It returns nil when there is no matching entry.
+func Latest(target string) (*Entry, error) {
+	entries, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if target == "" || entries[i].Target == target {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,63 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// Session is the last scan, kept so that the files it failed to scan can
+// be scanned again (scan --retry-failed) and merged into its report.
+type Session struct {
+	Time     time.Time         `json:"time"`
+	Target   string            `json:"target"`
+	Model    string            `json:"model"`
+	ScanType string            `json:"scan_type"`
+	Format   string            `json:"format"`
+	Output   string            `json:"output,omitempty"` // Report file written by the scan; "" for stdout or none
+	Failed   map[string]string `json:"failed,omitempty"` // Files that failed to scan -> why
+	Report   json.RawMessage   `json:"report"`           // JSON report of every file scanned, before --min-severity
+}
+
+// SaveSession replaces the saved session with s.
+func SaveSession(s Session) error {
+	path, err := config.GetSessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession returns the saved session, or nil when no scan has saved one.
+func LoadSession() (*Session, error) {
+	path, err := config.GetSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,141 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats summarizes a set of history entries.
+type Stats struct {
+	Scans        int
+	FilesScanned int
+	AvgDuration  time.Duration // Over scans that recorded a duration
+	BySeverity   map[string]int
+	Periods      []PeriodStats // Oldest first
+	TopCWEs      []Count       // Most frequent first
+	Models       []ModelStats  // Most used first
+}
+
+// PeriodStats counts scans and findings in one ISO week.
+type PeriodStats struct {
+	Period     string // e.g. "2026-W42"
+	Scans      int
+	BySeverity map[string]int
+}
+
+// Count is a key with the number of times it occurred.
+type Count struct {
+	Key   string
+	Count int
+}
+
+// ModelStats describes how a model performed across its scans.
+type ModelStats struct {
+	Model           string
+	Scans           int
+	AvgDuration     time.Duration
+	FilesPerMinute  float64 // 0 when no durations were recorded
+	TokensPerScan   int
+	FindingsPerScan float64
+}
+
+// Summarize computes Stats over entries. Entries recorded before durations,
+// severities or CWEs were kept simply contribute nothing to those figures.
+func Summarize(entries []Entry) Stats {
+	st := Stats{BySeverity: make(map[string]int)}
+
+	type modelAcc struct {
+		scans, timed, timedFiles, tokens, findings int
+		duration                                   time.Duration
+	}
+	models := make(map[string]*modelAcc)
+	periods := make(map[string]*PeriodStats)
+	cwes := make(map[string]int)
+
+	var totalDuration time.Duration
+	timed := 0
+	for _, e := range entries {
+		st.Scans++
+		st.FilesScanned += e.FilesScanned
+		if e.DurationMS > 0 {
+			totalDuration += e.Duration()
+			timed++
+		}
+
+		year, week := e.Time.ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		p, ok := periods[key]
+		if !ok {
+			p = &PeriodStats{Period: key, BySeverity: make(map[string]int)}
+			periods[key] = p
+		}
+		p.Scans++
+
+		m, ok := models[e.Model]
+		if !ok {
+			m = &modelAcc{}
+			models[e.Model] = m
+		}
+		m.scans++
+		m.tokens += e.PromptTokens + e.CompletionTokens
+		if e.DurationMS > 0 {
+			m.timed++
+			m.timedFiles += e.FilesScanned
+			m.duration += e.Duration()
+		}
+
+		for sev, n := range e.BySeverity {
+			sev = strings.ToUpper(sev)
+			st.BySeverity[sev] += n
+			p.BySeverity[sev] += n
+			m.findings += n
+		}
+		for id, n := range e.ByCWE {
+			cwes[id] += n
+		}
+	}
+	if timed > 0 {
+		st.AvgDuration = totalDuration / time.Duration(timed)
+	}
+
+	for _, p := range periods {
+		st.Periods = append(st.Periods, *p)
+	}
+	sort.Slice(st.Periods, func(i, j int) bool { return st.Periods[i].Period < st.Periods[j].Period })
+
+	for id, n := range cwes {
+		st.TopCWEs = append(st.TopCWEs, Count{Key: id, Count: n})
+	}
+	sort.Slice(st.TopCWEs, func(i, j int) bool {
+		if st.TopCWEs[i].Count != st.TopCWEs[j].Count {
+			return st.TopCWEs[i].Count > st.TopCWEs[j].Count
+		}
+		return st.TopCWEs[i].Key < st.TopCWEs[j].Key
+	})
+
+	for name, m := range models {
+		ms := ModelStats{
+			Model:           name,
+			Scans:           m.scans,
+			TokensPerScan:   m.tokens / m.scans,
+			FindingsPerScan: float64(m.findings) / float64(m.scans),
+		}
+		if m.timed > 0 {
+			ms.AvgDuration = m.duration / time.Duration(m.timed)
+			if minutes := m.duration.Minutes(); minutes > 0 {
+				ms.FilesPerMinute = float64(m.timedFiles) / minutes
+			}
+		}
+		st.Models = append(st.Models, ms)
+	}
+	sort.Slice(st.Models, func(i, j int) bool {
+		if st.Models[i].Scans != st.Models[j].Scans {
+			return st.Models[i].Scans > st.Models[j].Scans
+		}
+		return st.Models[i].Model < st.Models[j].Model
+	})
+
+	return st
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// Suppression hides a finding, identified by its fingerprint, from later
+// scans. Once it expires the finding resurfaces for re-review.
+type Suppression struct {
+	Fingerprint string     `json:"fingerprint"`
+	File        string     `json:"file"`
+	Title       string     `json:"title"`
+	Severity    string     `json:"severity"`
+	IssueID     string     `json:"issue_id,omitempty"`
+	Reason      string     `json:"reason"` // The review decision, e.g. "false_positive"
+	Created     time.Time  `json:"created"`
+	Expires     *time.Time `json:"expires,omitempty"` // Never when unset
+}
+
+// Expired reports whether the suppression no longer applies at now.
+func (s Suppression) Expired(now time.Time) bool {
+	return s.Expires != nil && !now.Before(*s.Expires)
+}
+
+// LoadSuppressions reads every suppression, oldest first. A missing file
+// yields none.
+func LoadSuppressions() ([]Suppression, error) {
+	path, err := config.GetSuppressionsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppressions: %w", err)
+	}
+	var list []Suppression
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Suppress saves s, replacing any earlier suppression of the same finding.
+func Suppress(s Suppression) error {
+	list, err := LoadSuppressions()
+	if err != nil {
+		return err
+	}
+	kept := list[:0]
+	for _, existing := range list {
+		if existing.Fingerprint != s.Fingerprint {
+			kept = append(kept, existing)
+		}
+	}
+	return saveSuppressions(append(kept, s))
+}
+
+// RemoveSuppressions deletes the suppressions with the given fingerprints,
+// returning how many were removed.
+func RemoveSuppressions(fingerprints []string) (int, error) {
+	list, err := LoadSuppressions()
+	if err != nil {
+		return 0, err
+	}
+	remove := make(map[string]bool)
+	for _, fp := range fingerprints {
+		remove[fp] = true
+	}
+	kept := list[:0]
+	for _, s := range list {
+		if !remove[s.Fingerprint] {
+			kept = append(kept, s)
+		}
+	}
+	removed := len(list) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, saveSuppressions(kept)
+}
+
+func saveSuppressions(list []Suppression) error {
+	path, err := config.GetSuppressionsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal suppressions: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write suppressions: %w", err)
+	}
+	return nil
+}
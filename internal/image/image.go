@@ -0,0 +1,362 @@
+// Package image extracts the application files of a container image so
+// they can be scanned like a source tree.
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/ui"
+)
+
+// maxFileSize is the largest file extracted; the scanner skips bigger ones
+// anyway.
+const maxFileSize = 2 << 20
+
+// systemDirs hold the operating system and packages of the base image
+// rather than application source or config, and aren't extracted.
+var systemDirs = []string{
+	"bin", "boot", "dev", "etc", "lib", "lib32", "lib64", "libx32", "proc", "run",
+	"sbin", "sys", "tmp", "usr", "var/cache", "var/lib", "var/log",
+}
+
+// appDirs are kept even though they are inside a system directory.
+var appDirs = []string{"usr/src", "usr/local/src", "usr/share/nginx/html", "var/www"}
+
+// Layer is a filesystem layer of an image.
+type Layer struct {
+	Digest    string // e.g. "sha256:3f4b..."
+	CreatedBy string // The Dockerfile instruction that created it, if known
+}
+
+// Image is a container image extracted for scanning.
+type Image struct {
+	Ref    string
+	Layers []Layer
+	files  map[string]int // Path inside the image -> index of the layer it last came from
+}
+
+// Files returns how many files were extracted.
+func (img *Image) Files() int {
+	return len(img.files)
+}
+
+// Provenance describes the layer the file at rel (relative to the
+// extraction directory) came from, e.g. "layer 4/6 sha256:3f4b5c6d7e8f
+// (COPY . /app)", or "" if it isn't from the image.
+func (img *Image) Provenance(rel string) string {
+	i, ok := img.files[path.Clean(filepath.ToSlash(rel))]
+	if !ok {
+		return ""
+	}
+	l := img.Layers[i]
+	digest := l.Digest
+	if len(digest) > len("sha256:")+12 {
+		digest = digest[:len("sha256:")+12]
+	}
+	s := fmt.Sprintf("layer %d/%d %s", i+1, len(img.Layers), digest)
+	if l.CreatedBy != "" {
+		s += " (" + l.CreatedBy + ")"
+	}
+	return s
+}
+
+// Extract extracts the application files of the image ref into dir, which
+// should be empty. ref is either a `docker save` archive or an image
+// reference, which is pulled with docker (or podman) unless it is present.
+// Layers are applied in order, honouring whiteouts, so dir holds the files
+// as a container would see them; system directories, links and files over
+// 2 MB are left out.
+func Extract(ref, dir string) (*Image, error) {
+	archive := ref
+	if info, err := os.Stat(ref); err != nil || info.IsDir() {
+		tmp, err := os.CreateTemp("", "sidekick-image-*.tar")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create image archive: %w", err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := save(ref, tmp.Name()); err != nil {
+			return nil, err
+		}
+		archive = tmp.Name()
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image archive: %w", err)
+	}
+	defer f.Close()
+
+	// The manifest names the layers, which may come before or after it in
+	// the archive, so index the entries first
+	entries, err := indexArchive(f)
+	if err != nil {
+		return nil, err
+	}
+	var manifest []struct {
+		Config string
+		Layers []string
+	}
+	data, err := readEntry(f, entries, "manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a docker save archive: %w", ref, err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest) == 0 {
+		return nil, fmt.Errorf("%s has an invalid manifest.json", ref)
+	}
+	if len(manifest) > 1 {
+		return nil, fmt.Errorf("%s holds %d images; save only the one to scan", ref, len(manifest))
+	}
+
+	img := &Image{Ref: ref, files: make(map[string]int)}
+	createdBy := layerHistory(f, entries, manifest[0].Config)
+	for i, name := range manifest[0].Layers {
+		layer := Layer{Digest: layerDigest(name)}
+		if i < len(createdBy) {
+			layer.CreatedBy = createdBy[i]
+		}
+		img.Layers = append(img.Layers, layer)
+
+		e, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: layer %s is missing from the archive", ref, name)
+		}
+		if err := img.applyLayer(io.NewSectionReader(f, e.offset, e.size), dir, i); err != nil {
+			return nil, fmt.Errorf("%s: layer %d: %w", ref, i+1, err)
+		}
+	}
+	return img, nil
+}
+
+// save writes the image ref to archive with docker, or podman when docker
+// isn't installed, pulling it first if needed.
+func save(ref, archive string) error {
+	cli := "docker"
+	if _, err := exec.LookPath(cli); err != nil {
+		if _, err := exec.LookPath("podman"); err != nil {
+			return fmt.Errorf("scanning images needs docker or podman (or a `docker save` archive)")
+		}
+		cli = "podman"
+	}
+	if err := run(cli, "image", "inspect", ref); err != nil {
+		fmt.Printf("📥 Pulling %s\n", ref)
+		if err := run(cli, "pull", ref); err != nil {
+			return fmt.Errorf("failed to pull %s: %w", ref, err)
+		}
+	}
+	if err := run(cli, "save", "-o", archive, ref); err != nil {
+		return fmt.Errorf("failed to save %s: %w", ref, err)
+	}
+	return nil
+}
+
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
+
+type entry struct {
+	offset, size int64
+}
+
+// indexArchive records where each regular file of the tar archive f is.
+func indexArchive(f *os.File) (map[string]entry, error) {
+	entries := make(map[string]entry)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// The reader is positioned at the start of the entry's data
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		entries[path.Clean(hdr.Name)] = entry{offset: offset, size: hdr.Size}
+	}
+}
+
+func readEntry(f *os.File, entries map[string]entry, name string) ([]byte, error) {
+	e, ok := entries[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	return io.ReadAll(io.NewSectionReader(f, e.offset, e.size))
+}
+
+// layerHistory returns the instruction that created each layer, from the
+// image config, or nil if it isn't available.
+func layerHistory(f *os.File, entries map[string]entry, config string) []string {
+	data, err := readEntry(f, entries, config)
+	if err != nil {
+		return nil
+	}
+	var cfg struct {
+		History []struct {
+			CreatedBy  string `json:"created_by"`
+			EmptyLayer bool   `json:"empty_layer"`
+		}
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return nil
+	}
+	var created []string
+	for _, h := range cfg.History {
+		if h.EmptyLayer {
+			continue
+		}
+		// Shell-form instructions are recorded as "/bin/sh -c #(nop) COPY ..."
+		// or "/bin/sh -c apt-get ..."
+		s := strings.TrimSuffix(strings.TrimSpace(h.CreatedBy), " # buildkit")
+		s = strings.TrimPrefix(s, "/bin/sh -c ")
+		if rest, ok := strings.CutPrefix(s, "#(nop) "); ok {
+			s = strings.TrimSpace(rest)
+		} else if s != "" && !strings.HasPrefix(s, "RUN ") && !strings.HasPrefix(s, "COPY ") && !strings.HasPrefix(s, "ADD ") {
+			s = "RUN " + s
+		}
+		s = ui.Truncate(s, 60)
+		created = append(created, s)
+	}
+	return created
+}
+
+// layerDigest derives the digest of a layer from its archive path:
+// "blobs/sha256/<hex>" for OCI layouts, "<hex>/layer.tar" for older saves.
+func layerDigest(name string) string {
+	if hex, ok := strings.CutPrefix(name, "blobs/sha256/"); ok {
+		return "sha256:" + hex
+	}
+	if hex, ok := strings.CutSuffix(name, "/layer.tar"); ok {
+		return "sha256:" + hex
+	}
+	return name
+}
+
+// applyLayer extracts the application files of layer index i into dir,
+// applying its whiteouts to the files of earlier layers.
+func (img *Image) applyLayer(r io.Reader, dir string, i int) error {
+	br := bufio.NewReader(r)
+	// Layers may be gzip-compressed in OCI layouts
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name, ok := cleanName(hdr.Name)
+		if !ok {
+			continue
+		}
+
+		base := path.Base(name)
+		parent := path.Dir(name)
+		if base == ".wh..wh..opq" {
+			// Opaque directory: hide everything earlier layers put there
+			img.remove(dir, parent, true)
+			continue
+		}
+		if hidden, ok := strings.CutPrefix(base, ".wh."); ok {
+			img.remove(dir, path.Join(parent, hidden), false)
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxFileSize || !application(name) {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return err
+		}
+		img.files[name] = i
+	}
+}
+
+// remove deletes name, a file or directory, from the extracted files; with
+// contentsOnly the directory itself is kept.
+func (img *Image) remove(dir, name string, contentsOnly bool) {
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	for f := range img.files {
+		if (!contentsOnly && f == name) || strings.HasPrefix(f, prefix) {
+			delete(img.files, f)
+			os.Remove(filepath.Join(dir, filepath.FromSlash(f)))
+		}
+	}
+}
+
+// cleanName turns a tar entry name into a path relative to the image root,
+// rejecting ones that would escape it.
+func cleanName(name string) (string, bool) {
+	name = path.Clean("/" + strings.TrimPrefix(name, "./"))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || name == "." {
+		return "", false
+	}
+	return name, true
+}
+
+// application reports whether name is outside the system directories.
+func application(name string) bool {
+	for _, d := range appDirs {
+		if name == d || strings.HasPrefix(name, d+"/") {
+			return true
+		}
+	}
+	for _, d := range systemDirs {
+		if name == d || strings.HasPrefix(name, d+"/") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package interactive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
+)
+
+// compareColumn is the width of each side of the comparison table.
+const compareColumn = 44
+
+// modelRun is one model's security scan of the compared file.
+type modelRun struct {
+	model    string
+	issues   []scanner.SecurityIssue
+	duration time.Duration
+	usage    ollama.TokenUsage
+}
+
+// compareModels scans one file with two models and shows the findings on
+// which they differ side by side, so the user can pick the default model
+// to trust. It returns a status message for the Models menu.
+func (im *InteractiveMode) compareModels(client *ollama.Client, models []ollama.Model) string {
+	if len(models) < 2 {
+		fmt.Printf("\n❌ Comparing needs at least two installed models\n")
+		im.pressEnterToContinue()
+		return ""
+	}
+
+	modelA := im.selectModel("First model to compare", models, "")
+	if modelA == "" {
+		return ""
+	}
+	modelB := im.selectModel("Second model to compare", models, modelA)
+	if modelB == "" {
+		return ""
+	}
+
+	im.clearScreen()
+	im.showWelcome()
+	fmt.Printf("%s▸ COMPARE MODELS%s\n\n", orange, reset)
+	fmt.Printf("%s▸%s File path: ", orange, reset)
+	path := im.readInput()
+	if path == "" {
+		return ""
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		fmt.Printf("\n❌ %s is not a file\n", path)
+		im.pressEnterToContinue()
+		return ""
+	}
+
+	var runs [2]modelRun
+	for i, model := range []string{modelA, modelB} {
+		fmt.Printf("\n%s▸%s Scanning with %s...\n", orange, reset, model)
+		run, err := im.scanWithModel(client, model, path)
+		if err != nil {
+			fmt.Printf("\n❌ %s: %v\n", model, err)
+			im.pressEnterToContinue()
+			return ""
+		}
+		runs[i] = run
+	}
+
+	showComparison(runs, scanner.CompareFindings(runs[0].issues, runs[1].issues))
+
+	items := []MenuItem{
+		{Label: "← Back", Value: "back"},
+		{Label: fmt.Sprintf("Set %s as default", modelA), Value: modelA},
+		{Label: fmt.Sprintf("Set %s as default", modelB), Value: modelB},
+	}
+	fmt.Print("\nPress Enter to choose a default model...")
+	im.readInput()
+	selected, err := SelectMenu("Keep a model as default?", items, 0)
+	if err != nil || selected <= 0 {
+		return ""
+	}
+	im.config.DefaultModel = items[selected].Value
+	if err := im.config.Save(); err != nil {
+		fmt.Printf("\n❌ Failed to save: %v\n", err)
+		im.pressEnterToContinue()
+		return ""
+	}
+	return fmt.Sprintf("✓ Default model set to: %s", im.config.DefaultModel)
+}
+
+// selectModel lets the user pick one of models, leaving out exclude. It
+// returns "" if the user backed out.
+func (im *InteractiveMode) selectModel(title string, models []ollama.Model, exclude string) string {
+	var items []MenuItem
+	for _, m := range models {
+		if m.Name == exclude {
+			continue
+		}
+		prefix := "  "
+		if m.Name == im.config.DefaultModel {
+			prefix = "✓ "
+		}
+		items = append(items, MenuItem{Label: prefix + m.Name, Value: m.Name})
+	}
+	selected, err := SelectMenu(title, items, 0)
+	if err != nil || selected == -1 {
+		return ""
+	}
+	return items[selected].Value
+}
+
+// scanWithModel runs a security scan of one file with model, with the
+// configured scan settings but without the result cache, so each model
+// really looks at the file.
+func (im *InteractiveMode) scanWithModel(client *ollama.Client, model, path string) (modelRun, error) {
+	if err := client.CheckModel(model); err != nil {
+		return modelRun{}, err
+	}
+	s := scanner.NewScanner(client, model, im.config.Debug, "security", "")
+	defer s.Close()
+	s.SetOrgContext(im.config.Context)
+	s.SetChunking(im.config.ChunkSize, im.config.ChunkOverlap)
+	s.SetQuarantine(im.config.Quarantines())
+	s.SetIncludeGenerated(true)
+
+	start := time.Now()
+	results, err := s.ScanFiles(context.Background(), []string{path})
+	if err != nil {
+		return modelRun{}, err
+	}
+	run := modelRun{model: model, duration: time.Since(start)}
+	for _, r := range results {
+		if r.Skipped != "" {
+			return modelRun{}, fmt.Errorf("file skipped: %s", r.Skipped)
+		}
+		run.issues = append(run.issues, r.Issues...)
+		run.usage.Add(r.Usage)
+	}
+	return run, nil
+}
+
+// showComparison prints both models' totals, the findings only one of them
+// reported side by side, and the findings they agree on.
+func showComparison(runs [2]modelRun, cmp scanner.ModelComparison) {
+	fmt.Printf("\n%s━━━ Model comparison ━━━%s\n\n", orange, reset)
+	fmt.Printf("  %s %s\n", ui.PadRight(bold+ui.Truncate(runs[0].model, compareColumn)+reset, compareColumn), bold+ui.Truncate(runs[1].model, compareColumn)+reset)
+	for _, line := range [][2]string{
+		{fmt.Sprintf("%d finding(s)", len(runs[0].issues)), fmt.Sprintf("%d finding(s)", len(runs[1].issues))},
+		{fmt.Sprintf("%s, %d tokens", runs[0].duration.Round(100*time.Millisecond), runs[0].usage.Total()),
+			fmt.Sprintf("%s, %d tokens", runs[1].duration.Round(100*time.Millisecond), runs[1].usage.Total())},
+	} {
+		fmt.Printf("  %s%s %s%s\n", gray, ui.PadRight(line[0], compareColumn), line[1], reset)
+	}
+
+	if len(cmp.OnlyA)+len(cmp.OnlyB) == 0 {
+		fmt.Printf("\n%s✓%s Both models reported the same findings\n", orange, reset)
+	} else {
+		fmt.Printf("\n%sDiffering findings%s\n", orange, reset)
+		rows := max(len(cmp.OnlyA), len(cmp.OnlyB))
+		for i := 0; i < rows; i++ {
+			left, right := "", ""
+			if i < len(cmp.OnlyA) {
+				left = compareCell(cmp.OnlyA[i])
+			}
+			if i < len(cmp.OnlyB) {
+				right = compareCell(cmp.OnlyB[i])
+			}
+			fmt.Printf("  %s %s\n", ui.PadRight(left, compareColumn), right)
+		}
+	}
+
+	if len(cmp.Both) > 0 {
+		fmt.Printf("\n%sReported by both (%d)%s\n", gray, len(cmp.Both), reset)
+		for _, pair := range cmp.Both {
+			line := compareCell(pair[0])
+			if a, b := strings.ToUpper(pair[0].Severity), strings.ToUpper(pair[1].Severity); a != b {
+				line += fmt.Sprintf(" (%s vs %s)", a, b)
+			}
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// compareCell renders a finding in one column: severity, line and title.
+func compareCell(issue scanner.SecurityIssue) string {
+	sev := strings.ToUpper(issue.Severity)
+	return ui.Truncate(fmt.Sprintf("%s L%d %s", ui.SeverityEmoji(sev), issue.LineStart, issue.Title), compareColumn)
+}
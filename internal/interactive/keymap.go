@@ -0,0 +1,107 @@
+package interactive
+
+import (
+	"github.com/eiannone/keyboard"
+	"github.com/pefman/sidekick/internal/config"
+)
+
+type menuAction int
+
+const (
+	actionNone menuAction = iota
+	actionUp
+	actionDown
+	actionTop
+	actionBottom
+	actionSelect
+	actionBack
+)
+
+// keymap resolves key presses (including two-key sequences such as "gg")
+// to menu actions.
+type keymap struct {
+	bindings map[string]menuAction
+	prefixes map[string]bool
+	pending  string
+}
+
+// activeKeymap is used by every menu; New replaces it with the configured one.
+var activeKeymap = newKeymap(config.Keymap{})
+
+func newKeymap(k config.Keymap) *keymap {
+	k = k.WithDefaults()
+	m := &keymap{
+		// Built-in keys always work, whatever the config says
+		bindings: map[string]menuAction{
+			"up": actionUp, "down": actionDown, "home": actionTop, "end": actionBottom,
+			"enter": actionSelect, "right": actionSelect, "esc": actionBack, "left": actionBack,
+		},
+		prefixes: make(map[string]bool),
+	}
+	for action, keys := range map[menuAction][]string{
+		actionUp: k.Up, actionDown: k.Down, actionTop: k.Top, actionBottom: k.Bottom,
+		actionSelect: k.Select, actionBack: k.Back,
+	} {
+		for _, key := range keys {
+			m.bindings[key] = action
+			if r := []rune(key); len(r) == 2 && !namedKeys[key] {
+				m.prefixes[string(r[0])] = true
+			}
+		}
+	}
+	return m
+}
+
+// namedKeys are the key names that aren't character sequences.
+var namedKeys = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true,
+	"enter": true, "esc": true, "home": true, "end": true, "tab": true,
+}
+
+// keyName names a key press the way Keymap entries do.
+func keyName(char rune, key keyboard.Key) string {
+	switch key {
+	case keyboard.KeyArrowUp:
+		return "up"
+	case keyboard.KeyArrowDown:
+		return "down"
+	case keyboard.KeyArrowLeft:
+		return "left"
+	case keyboard.KeyArrowRight:
+		return "right"
+	case keyboard.KeyEnter:
+		return "enter"
+	case keyboard.KeyEsc:
+		return "esc"
+	case keyboard.KeyHome:
+		return "home"
+	case keyboard.KeyEnd:
+		return "end"
+	case keyboard.KeyTab:
+		return "tab"
+	case keyboard.KeySpace:
+		return " "
+	}
+	if char != 0 {
+		return string(char)
+	}
+	return ""
+}
+
+// resolve returns the action for a key press. The first key of a sequence
+// returns actionNone and is remembered until the next press.
+func (m *keymap) resolve(char rune, key keyboard.Key) menuAction {
+	name := keyName(char, key)
+	if m.pending != "" {
+		seq := m.pending + name
+		m.pending = ""
+		if action, ok := m.bindings[seq]; ok {
+			return action
+		}
+	}
+	if m.prefixes[name] {
+		m.pending = name
+		return actionNone
+	}
+	return m.bindings[name]
+}
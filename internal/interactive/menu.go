@@ -12,6 +12,8 @@ import (
 	"github.com/eiannone/keyboard"
 	"github.com/pefman/sidekick/internal/config"
 	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
 	"github.com/pefman/sidekick/internal/updater"
 )
 
@@ -45,6 +47,8 @@ func New() *InteractiveMode {
 		cfg = config.GetDefault()
 	}
 
+	activeKeymap = newKeymap(cfg.Keymap)
+
 	return &InteractiveMode{
 		config: cfg,
 		reader: bufio.NewReader(os.Stdin),
@@ -66,6 +70,7 @@ func (im *InteractiveMode) Run() error {
 
 	for {
 		items := []MenuItem{
+			{Label: fmt.Sprintf("Scan (%s)", im.config.ScanType()), Value: "scan"},
 			{Label: "Settings", Value: "settings"},
 			{Label: "Models", Value: "models"},
 			{Label: "Help", Value: "help"},
@@ -77,7 +82,7 @@ func (im *InteractiveMode) Run() error {
 			if version := im.getUpdateVersion(); version != "" {
 				label = fmt.Sprintf("Update Available (%s)", version)
 			}
-			items = append(items[:2], append([]MenuItem{{Label: label, Value: "update"}}, items[2:]...)...)
+			items = append(items[:3], append([]MenuItem{{Label: label, Value: "update"}}, items[3:]...)...)
 		}
 
 		if selectedIdx >= len(items) {
@@ -101,7 +106,7 @@ func (im *InteractiveMode) Run() error {
 
 		fmt.Println()
 		fmt.Printf("  Mode: %s%s%s  (Tab to change, Enter to submit, Esc to quit)\n", orange, strings.ToUpper(modes[modeIdx]), reset)
-		fmt.Println("  Menu: Use ↑↓ to select, Enter to open/execute")
+		fmt.Println("  Menu: Use ↑↓ (j/k once in the menu) to select, Enter to open/execute")
 		fmt.Println()
 
 		char, key, err := keyboard.GetKey()
@@ -109,6 +114,29 @@ func (im *InteractiveMode) Run() error {
 			return err
 		}
 
+		if selectedIdx >= 0 && char != 0 {
+			// With the menu focused, character keys navigate instead of typing
+			switch activeKeymap.resolve(char, key) {
+			case actionUp:
+				selectedIdx--
+			case actionDown:
+				if selectedIdx < len(items)-1 {
+					selectedIdx++
+				}
+			case actionTop:
+				selectedIdx = 0
+			case actionBottom:
+				selectedIdx = len(items) - 1
+			case actionBack:
+				selectedIdx = -1
+			case actionSelect:
+				key = keyboard.KeyEnter
+			}
+			if key != keyboard.KeyEnter {
+				continue
+			}
+		}
+
 		switch key {
 		case keyboard.KeyEsc, keyboard.KeyArrowLeft:
 			im.clearScreen()
@@ -137,8 +165,7 @@ func (im *InteractiveMode) Run() error {
 					break
 				}
 				keyboard.Close()
-				customPrompt := fmt.Sprintf("MODE: %s\n%s", strings.ToUpper(modes[modeIdx]), prompt)
-				if err := im.runPrompt(customPrompt); err != nil {
+				if err := im.runPrompt(modes[modeIdx], prompt); err != nil {
 					fmt.Printf("\n%s✗%s Error: %v\n", orange, reset, err)
 					im.pressEnterToContinue()
 				}
@@ -150,6 +177,15 @@ func (im *InteractiveMode) Run() error {
 			}
 
 			switch items[selectedIdx].Value {
+			case "scan":
+				keyboard.Close()
+				if err := im.runDefaultScan(); err != nil {
+					fmt.Printf("\n%s✗%s Error: %v\n", orange, reset, err)
+					im.pressEnterToContinue()
+				}
+				if err := keyboard.Open(); err != nil {
+					return err
+				}
 			case "settings":
 				keyboard.Close()
 				im.settingsMenu()
@@ -181,6 +217,15 @@ func (im *InteractiveMode) Run() error {
 			}
 		case keyboard.KeyArrowRight:
 			switch items[selectedIdx].Value {
+			case "scan":
+				keyboard.Close()
+				if err := im.runDefaultScan(); err != nil {
+					fmt.Printf("\n%s✗%s Error: %v\n", orange, reset, err)
+					im.pressEnterToContinue()
+				}
+				if err := keyboard.Open(); err != nil {
+					return err
+				}
 			case "settings":
 				keyboard.Close()
 				im.settingsMenu()
@@ -218,11 +263,11 @@ func (im *InteractiveMode) Run() error {
 	}
 }
 
-func (im *InteractiveMode) runPrompt(customPrompt string) error {
+// runDefaultScan asks for a path and runs the configured default scan type.
+func (im *InteractiveMode) runDefaultScan() error {
 	im.clearScreen()
 	im.showWelcome()
 
-	// Get scan path
 	fmt.Printf("\n%s▸%s Path (press Enter for current directory): ", orange, reset)
 	path := im.readInput()
 	if path == "" {
@@ -233,20 +278,59 @@ func (im *InteractiveMode) runPrompt(customPrompt string) error {
 		}
 	}
 
+	fmt.Println()
+	results, err := performScan(path, im.config, im.config.ScanType(), "", im.confirmLargeScan)
+	if err != nil {
+		return err
+	}
+	im.offerReview(results)
+
+	im.pressEnterToContinue()
+	return nil
+}
+
+func (im *InteractiveMode) runPrompt(mode, prompt string) error {
+	// Choose what the prompt runs against
+	path, cleanup, err := im.readTarget()
+	defer cleanup()
+	if err != nil || path == "" {
+		return err
+	}
+
+	customPrompt := scanner.CustomPrompt(mode, im.readFields(), prompt)
+
 	// Use config settings
-	model := im.config.DefaultModel
 	scanType := "custom"
 
 	// Start scan immediately
 	fmt.Println()
-	if err := performScan(path, model, im.config.Debug, scanType, customPrompt); err != nil {
+	results, err := performScan(path, im.config, scanType, customPrompt, im.confirmLargeScan)
+	if err != nil {
 		return err
 	}
+	if mode == "edit" {
+		im.offerEditReview(results)
+	} else {
+		im.offerReview(results)
+	}
 
 	im.pressEnterToContinue()
 	return nil
 }
 
+// readFields asks for optional answer fields; with fields, the answer is
+// requested as structured rows and shown as a table.
+func (im *InteractiveMode) readFields() []string {
+	fmt.Printf("%s▸%s Table fields, comma-separated (press Enter for a free-text answer): ", orange, reset)
+	var fields []string
+	for _, f := range strings.Split(im.readInput(), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
 func (im *InteractiveMode) clearScreen() {
 	fmt.Print("\033[H\033[2J")
 }
@@ -359,6 +443,77 @@ func (im *InteractiveMode) readPromptWithMode() (string, string, bool) {
 	}
 }
 
+// confirmLargeScan asks whether to go ahead with a scan of more files than
+// max_files.
+func (im *InteractiveMode) confirmLargeScan(files, limit int) bool {
+	fmt.Printf("%s▸%s This scan covers %d files (more than %d) and may take hours. Continue? (y/N): ", orange, reset, files, limit)
+	answer := im.readInput()
+	return answer == "y" || answer == "Y"
+}
+
+// offerReview asks whether to go through the findings of a scan in review
+// mode, where fixes can be applied and findings marked false positives.
+func (im *InteractiveMode) offerReview(results []scanner.ScanResult) {
+	count := 0
+	for _, result := range results {
+		count += len(result.Issues)
+	}
+	if count == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s▸%s Review %d finding(s) now? (y/N): ", orange, reset, count)
+	if answer := im.readInput(); answer != "y" && answer != "Y" {
+		return
+	}
+	im.offerTrust(results)
+	if err := scanner.ReviewSession(results); err != nil {
+		fmt.Printf("\n%s✗%s Review failed: %v\n", orange, reset, err)
+	}
+}
+
+// offerEditReview asks whether to go through the changes an edit-mode
+// prompt proposed, applying them file by file as in review mode.
+func (im *InteractiveMode) offerEditReview(results []scanner.ScanResult) {
+	count := 0
+	for _, result := range results {
+		if len(scanner.ParsePatches(result.RawFindings)) > 0 {
+			count++
+		}
+	}
+	if count == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s▸%s Review the changes to %d file(s) now? (y/N): ", orange, reset, count)
+	if answer := im.readInput(); answer != "y" && answer != "Y" {
+		return
+	}
+	im.offerTrust(results)
+	if err := scanner.ReviewEdits(results); err != nil {
+		fmt.Printf("\n%s✗%s Review failed: %v\n", orange, reset, err)
+	}
+}
+
+// offerTrust asks, in safe mode, whether to trust the scanned workspace so
+// fixes can be applied during review.
+func (im *InteractiveMode) offerTrust(results []scanner.ScanResult) {
+	if !scanner.SafeMode() || len(results) == 0 {
+		return
+	}
+	workspace := config.Workspace(results[0].FilePath)
+	fmt.Printf("%s▸%s 🔒 %s is not a trusted workspace, so fixes can't be applied. Trust it? (y/N): ", orange, reset, workspace)
+	if answer := im.readInput(); answer != "y" && answer != "Y" {
+		return
+	}
+	im.config.Trust(workspace)
+	if err := im.config.Save(); err != nil {
+		fmt.Printf("\n%s✗%s Failed to save: %v\n", orange, reset, err)
+		return
+	}
+	scanner.SetSafeMode(false)
+}
+
 func (im *InteractiveMode) pressEnterToContinue() {
 	fmt.Print("\nPress Enter to continue...")
 	im.reader.ReadString('\n')
@@ -374,12 +529,12 @@ func (im *InteractiveMode) waitForBack() {
 	defer keyboard.Close()
 
 	for {
-		_, key, err := keyboard.GetKey()
+		char, key, err := keyboard.GetKey()
 		if err != nil {
 			break
 		}
-		switch key {
-		case keyboard.KeyEnter, keyboard.KeyEsc, keyboard.KeyArrowLeft:
+		switch activeKeymap.resolve(char, key) {
+		case actionSelect, actionBack:
 			return
 		}
 	}
@@ -391,42 +546,17 @@ func (im *InteractiveMode) scanMenu() error {
 	if !ok {
 		return nil
 	}
-	customPrompt := fmt.Sprintf("MODE: %s\n%s", strings.ToUpper(mode), promptText)
-
-	im.clearScreen()
-	im.showWelcome()
-	fmt.Printf("%s▸ PROMPT%s\n", orange, reset)
-
-	// Get scan path
-	fmt.Printf("\n%s▸%s Path (press Enter for current directory): ", orange, reset)
-	path := im.readInput()
-	if path == "" {
-		var err error
-		path, err = os.Getwd()
-		if err != nil {
-			return err
-		}
-	}
-
-	// Use config settings
-	model := im.config.DefaultModel
-	scanType := "custom"
-
-	// Start scan immediately
-	fmt.Println()
-	if err := performScan(path, model, im.config.Debug, scanType, customPrompt); err != nil {
-		return err
-	}
-
-	im.pressEnterToContinue()
-	return nil
+	return im.runPrompt(mode, promptText)
 }
 
 func (im *InteractiveMode) settingsMenu() {
 	for {
 		items := []MenuItem{
+			{Label: fmt.Sprintf("Profile: %s", profileLabel(im.config.ActiveProfile())), Value: "profile"},
 			{Label: fmt.Sprintf("URL: %s", im.config.OllamaURL), Value: "url"},
 			{Label: fmt.Sprintf("Debug: %v", im.config.Debug), Value: "debug"},
+			{Label: fmt.Sprintf("Default scan type: %s", im.config.ScanType()), Value: "scantype"},
+			{Label: fmt.Sprintf("Default output format: %s", im.config.OutputFormat()), Value: "format"},
 		}
 
 		selected, err := SelectMenu("SETTINGS", items, 0)
@@ -435,9 +565,25 @@ func (im *InteractiveMode) settingsMenu() {
 		}
 
 		switch items[selected].Value {
+		case "profile":
+			im.profilesMenu()
 		case "debug":
 			im.config.Debug = !im.config.Debug
 			im.config.Save()
+		case "scantype":
+			if im.config.ScanType() == "security" {
+				im.config.DefaultScanType = "triad"
+			} else {
+				im.config.DefaultScanType = "security"
+			}
+			im.config.Save()
+		case "format":
+			if im.config.OutputFormat() == "text" {
+				im.config.DefaultOutputFormat = "html"
+			} else {
+				im.config.DefaultOutputFormat = "text"
+			}
+			im.config.Save()
 		case "reset":
 			im.config = config.GetDefault()
 			im.config.Save()
@@ -494,14 +640,8 @@ func (im *InteractiveMode) modelsMenu() {
 			return
 		}
 
-		if len(models) == 0 {
-			im.clearScreen()
-			im.showWelcome()
-			fmt.Println("\n⚠️  No models found. Install a model first:")
-			fmt.Println("   ollama pull qwen2.5-coder:14b")
-			fmt.Println("   ollama pull deepseek-r1:14b")
-			im.pressEnterToContinue()
-			return
+		if len(models) == 0 && statusMessage == "" {
+			statusMessage = "⚠️  No models found. Use \"Pull new model…\" below, e.g. qwen2.5-coder:14b"
 		}
 
 		// Sort models alphabetically (case-insensitive)
@@ -510,7 +650,7 @@ func (im *InteractiveMode) modelsMenu() {
 		})
 
 		// Build menu items
-		items := make([]MenuItem, len(models)+2)
+		items := make([]MenuItem, len(models)+4)
 		currentIdx := 0
 		for i, model := range models {
 			sizeStr := formatSize(model.Size)
@@ -520,16 +660,24 @@ func (im *InteractiveMode) modelsMenu() {
 				currentIdx = i
 			}
 			items[i] = MenuItem{
-				Label: fmt.Sprintf("%s%-40s     %s%s%s", prefix, model.Name, gray, sizeStr, reset),
+				Label: fmt.Sprintf("%s%s     %s%s%s", prefix, ui.PadRight(model.Name, 40), gray, sizeStr, reset),
 				Value: model.Name,
 			}
 		}
-		// Add URL change and back options
+		// Add pull, compare, URL change and back options
 		items[len(models)] = MenuItem{
-			Label: fmt.Sprintf("\n🔗 Change Ollama URL (Current: %s)", im.config.OllamaURL),
-			Value: "__change_url__",
+			Label: "\n⬇ Pull new model…",
+			Value: "__pull__",
 		}
 		items[len(models)+1] = MenuItem{
+			Label: "⚖ Compare two models on a file…",
+			Value: "__compare__",
+		}
+		items[len(models)+2] = MenuItem{
+			Label: fmt.Sprintf("🔗 Change Ollama URL (Current: %s)", im.config.OllamaURL),
+			Value: "__change_url__",
+		}
+		items[len(models)+3] = MenuItem{
 			Label: "← Back",
 			Value: "__back__",
 		}
@@ -557,6 +705,14 @@ func (im *InteractiveMode) modelsMenu() {
 		if items[selected].Value == "__back__" {
 			return
 		}
+		if items[selected].Value == "__pull__" {
+			statusMessage = im.pullModel(client)
+			continue
+		}
+		if items[selected].Value == "__compare__" {
+			statusMessage = im.compareModels(client, models)
+			continue
+		}
 		if items[selected].Value == "__change_url__" {
 			if im.changeOllamaURL() {
 				// URL changed successfully, refresh models
@@ -566,8 +722,17 @@ func (im *InteractiveMode) modelsMenu() {
 			continue
 		}
 
-		// Set as default
 		modelName := items[selected].Value
+		action := im.modelActions(client, modelName)
+		if action == "delete" {
+			statusMessage = im.deleteModel(client, modelName)
+			continue
+		}
+		if action != "default" {
+			continue
+		}
+
+		// Set as default
 		im.config.DefaultModel = modelName
 		if err := im.config.Save(); err != nil {
 			fmt.Printf("\n❌ Failed to save: %v\n", err)
@@ -579,6 +744,114 @@ func (im *InteractiveMode) modelsMenu() {
 	}
 }
 
+// modelActions offers to show a model's details before making it the
+// default. It returns "default", "delete", or "" if the user backed out.
+func (im *InteractiveMode) modelActions(client *ollama.Client, modelName string) string {
+	for {
+		items := []MenuItem{
+			{Label: "Set as default", Value: "default"},
+			{Label: "Show details", Value: "details"},
+			{Label: "Delete model", Value: "delete"},
+			{Label: "← Back", Value: "back"},
+		}
+
+		selected, err := SelectMenu(modelName, items, 0)
+		if err != nil || selected == -1 {
+			return ""
+		}
+
+		switch items[selected].Value {
+		case "default", "delete":
+			return items[selected].Value
+		case "details":
+			im.showModelDetails(client, modelName)
+		case "back":
+			return ""
+		}
+	}
+}
+
+// pullModel asks for a model name and downloads it, showing progress.
+// It returns a status message for the Models menu.
+func (im *InteractiveMode) pullModel(client *ollama.Client) string {
+	im.clearScreen()
+	im.showWelcome()
+	fmt.Printf("%s▸ PULL MODEL%s\n\n", orange, reset)
+	fmt.Printf("%s▸%s Model name (e.g. qwen2.5-coder:14b, empty to cancel): ", orange, reset)
+	name := im.readInput()
+	if name == "" {
+		return ""
+	}
+
+	fmt.Println()
+	err := client.PullModel(name, func(p ollama.PullProgress) {
+		if p.Total > 0 {
+			fmt.Printf("\r\033[K  %s %s / %s (%d%%)", p.Status, formatSize(p.Completed), formatSize(p.Total), p.Completed*100/p.Total)
+		} else {
+			fmt.Printf("\r\033[K  %s", p.Status)
+		}
+	})
+	fmt.Println()
+	if err != nil {
+		fmt.Printf("\n❌ Error: %v\n", err)
+		im.pressEnterToContinue()
+		return ""
+	}
+	return fmt.Sprintf("✓ Pulled model: %s", name)
+}
+
+// deleteModel removes a model after confirmation. It returns a status
+// message for the Models menu.
+func (im *InteractiveMode) deleteModel(client *ollama.Client, modelName string) string {
+	items := []MenuItem{
+		{Label: "No, keep it", Value: "no"},
+		{Label: fmt.Sprintf("Yes, delete %s", modelName), Value: "yes"},
+	}
+	selected, err := SelectMenu(fmt.Sprintf("Delete %s?", modelName), items, 0)
+	if err != nil || selected == -1 || items[selected].Value != "yes" {
+		return ""
+	}
+
+	if err := client.DeleteModel(modelName); err != nil {
+		fmt.Printf("\n❌ Error: %v\n", err)
+		im.pressEnterToContinue()
+		return ""
+	}
+	return fmt.Sprintf("✓ Deleted model: %s", modelName)
+}
+
+func (im *InteractiveMode) showModelDetails(client *ollama.Client, modelName string) {
+	im.clearScreen()
+	im.showWelcome()
+	fmt.Printf("%s▸ %s%s\n\n", orange, modelName, reset)
+
+	details, err := client.ShowModel(modelName)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+	} else {
+		orUnknown := func(s string) string {
+			if s == "" {
+				return "unknown"
+			}
+			return s
+		}
+		fmt.Printf("  Family:         %s\n", orUnknown(details.Family))
+		fmt.Printf("  Parameters:     %s\n", orUnknown(details.ParameterSize))
+		fmt.Printf("  Quantization:   %s\n", orUnknown(details.QuantizationLevel))
+		if details.ContextLength > 0 {
+			fmt.Printf("  Context length: %d tokens\n", details.ContextLength)
+		} else {
+			fmt.Println("  Context length: unknown")
+		}
+		if !details.ModifiedAt.IsZero() {
+			fmt.Printf("  Modified:       %s\n", details.ModifiedAt.Format("2006-01-02 15:04"))
+		}
+	}
+
+	fmt.Print("\nPress Enter or ←/Esc to go back...")
+	im.waitForBack()
+}
+
 func (im *InteractiveMode) updateMenu() {
 	im.clearScreen()
 	im.showWelcome()
@@ -619,8 +892,8 @@ func (im *InteractiveMode) updateMenu() {
 			if response == "y" || response == "Y" {
 				cmd := exec.Command("sudo", "sidekick", "update")
 				cmd.Stdin = os.Stdin
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
+				cmd.Stdout = ui.Stdout()
+				cmd.Stderr = ui.Stderr()
 				fmt.Println()
 				if runErr := cmd.Run(); runErr != nil {
 					fmt.Printf("\n%s✗%s Sudo update failed: %v\n", orange, reset, runErr)
@@ -657,7 +930,7 @@ func (im *InteractiveMode) showHelp() {
 	fmt.Println("  sidekick                       # Launch interactive UI")
 	fmt.Println()
 	fmt.Println("KEYBOARD SHORTCUTS:")
-	fmt.Println("  Use ↑↓ arrows to navigate")
+	fmt.Println("  Use ↑↓ arrows or j/k to navigate, gg/G for first/last")
 	fmt.Println("  Enter/→ to select")
 	fmt.Println("  ←/Esc to go back")
 	fmt.Println()
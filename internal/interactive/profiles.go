@@ -0,0 +1,192 @@
+package interactive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/ui"
+)
+
+// profilesMenu lists the config profiles and creates, switches to and
+// deletes them.
+func (im *InteractiveMode) profilesMenu() {
+	statusMessage := ""
+	for {
+		active := im.config.ActiveProfile()
+		var items []MenuItem
+		currentIdx := 0
+		for _, name := range im.config.ProfileNames() {
+			p := im.config.Profiles[name]
+			prefix := "  "
+			if name == active {
+				prefix = "✓ "
+				currentIdx = len(items)
+			}
+			items = append(items, MenuItem{
+				Label: fmt.Sprintf("%s%s %s%s%s", prefix, ui.PadRight(name, 20), gray, profileSummary(p), reset),
+				Value: name,
+			})
+		}
+		none := "  "
+		if active == "" {
+			none = "✓ "
+		}
+		items = append(items,
+			MenuItem{Label: none + "No profile (top-level settings)", Value: "__none__"},
+			MenuItem{Label: "\n+ New profile…", Value: "__new__"},
+			MenuItem{Label: "← Back", Value: "__back__"},
+		)
+
+		title := "PROFILES"
+		if statusMessage != "" {
+			title += "  " + statusMessage
+			statusMessage = ""
+		}
+		selected, err := SelectMenu(title, items, currentIdx)
+		if err != nil || selected == -1 {
+			return
+		}
+
+		switch value := items[selected].Value; value {
+		case "__back__":
+			return
+		case "__new__":
+			statusMessage = im.newProfile()
+		case "__none__":
+			statusMessage = im.switchProfile("")
+		default:
+			statusMessage = im.profileActions(value)
+		}
+	}
+}
+
+// profileActions offers to switch to or delete a profile. It returns a
+// status message for the Profiles menu.
+func (im *InteractiveMode) profileActions(name string) string {
+	items := []MenuItem{
+		{Label: "Switch to this profile", Value: "switch"},
+		{Label: "Delete profile", Value: "delete"},
+		{Label: "← Back", Value: "back"},
+	}
+	selected, err := SelectMenu(name, items, 0)
+	if err != nil || selected == -1 {
+		return ""
+	}
+	switch items[selected].Value {
+	case "switch":
+		return im.switchProfile(name)
+	case "delete":
+		return im.deleteProfile(name)
+	}
+	return ""
+}
+
+// newProfile asks for a profile's name, Ollama URL and model, starting from
+// the current settings, and saves it.
+func (im *InteractiveMode) newProfile() string {
+	im.clearScreen()
+	im.showWelcome()
+	fmt.Printf("%s▸ NEW PROFILE%s\n\n", orange, reset)
+	fmt.Printf("%s▸%s Name (e.g. work, gpu-server; empty to cancel): ", orange, reset)
+	name := im.readInput()
+	if name == "" {
+		return ""
+	}
+	if _, exists := im.config.Profiles[name]; exists {
+		return fmt.Sprintf("⚠️  Profile %s already exists", name)
+	}
+	fmt.Printf("%s▸%s Ollama URL [%s]: ", orange, reset, im.config.OllamaURL)
+	url := im.readInput()
+	if url == "" {
+		url = im.config.OllamaURL
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "❌ Invalid URL: must start with http:// or https://"
+	}
+	fmt.Printf("%s▸%s Model [%s]: ", orange, reset, im.config.DefaultModel)
+	model := im.readInput()
+	if model == "" {
+		model = im.config.DefaultModel
+	}
+
+	if im.config.Profiles == nil {
+		im.config.Profiles = make(map[string]config.Profile)
+	}
+	im.config.Profiles[name] = config.Profile{OllamaURL: url, Model: model}
+	if err := im.config.Save(); err != nil {
+		return fmt.Sprintf("❌ Failed to save: %v", err)
+	}
+	return fmt.Sprintf("✓ Created profile: %s", name)
+}
+
+// switchProfile makes name the saved profile and applies it to this
+// session; "" goes back to the top-level settings.
+func (im *InteractiveMode) switchProfile(name string) string {
+	im.config.Profile = name
+	if err := im.config.Save(); err != nil {
+		return fmt.Sprintf("❌ Failed to save: %v", err)
+	}
+	config.SelectProfile(name)
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	im.config = cfg
+	if name == "" {
+		if env := cfg.ActiveProfile(); env != "" {
+			return fmt.Sprintf("⚠️  %s=%s still selects a profile", config.ProfileEnv, env)
+		}
+		return "✓ Using the top-level settings"
+	}
+	return fmt.Sprintf("✓ Switched to profile: %s", name)
+}
+
+// deleteProfile removes a profile after confirmation. Deleting the profile
+// in use goes back to the top-level settings.
+func (im *InteractiveMode) deleteProfile(name string) string {
+	items := []MenuItem{
+		{Label: "No, keep it", Value: "no"},
+		{Label: fmt.Sprintf("Yes, delete %s", name), Value: "yes"},
+	}
+	selected, err := SelectMenu(fmt.Sprintf("Delete profile %s?", name), items, 0)
+	if err != nil || selected == -1 || items[selected].Value != "yes" {
+		return ""
+	}
+
+	active := im.config.ActiveProfile() == name
+	delete(im.config.Profiles, name)
+	if im.config.Profile == name {
+		im.config.Profile = ""
+	}
+	if err := im.config.Save(); err != nil {
+		return fmt.Sprintf("❌ Failed to save: %v", err)
+	}
+	if active {
+		if status := im.switchProfile(""); strings.HasPrefix(status, "❌") || strings.HasPrefix(status, "⚠️") {
+			return status
+		}
+	}
+	return fmt.Sprintf("✓ Deleted profile: %s", name)
+}
+
+// profileLabel names the active profile for the Settings menu.
+func profileLabel(name string) string {
+	if name == "" {
+		return "none"
+	}
+	return name
+}
+
+// profileSummary describes what a profile sets, e.g.
+// "http://gpu:11434 · qwen2.5-coder:32b".
+func profileSummary(p config.Profile) string {
+	var parts []string
+	if p.OllamaURL != "" {
+		parts = append(parts, p.OllamaURL)
+	}
+	if p.Model != "" {
+		parts = append(parts, p.Model)
+	}
+	return strings.Join(parts, " · ")
+}
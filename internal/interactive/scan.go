@@ -1,109 +1,132 @@
 package interactive
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/history"
 	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/report"
 	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/pefman/sidekick/internal/walker"
 )
 
-func performScan(targetPath, modelName string, debug bool, scanType, customPrompt string) error {
+// performScan scans targetPath, shows the results and writes the configured
+// report. It returns the results for review. Scans of more files than
+// max_files only go ahead if confirm agrees.
+func performScan(targetPath string, cfg *config.Config, scanType, customPrompt string, confirm func(files, limit int) bool) ([]scanner.ScanResult, error) {
+	modelName := cfg.DefaultModel
+
 	// Validate path
-	info, err := os.Stat(targetPath)
-	if err != nil {
-		return fmt.Errorf("path does not exist: %w", err)
+	if _, err := os.Stat(targetPath); err != nil {
+		return nil, fmt.Errorf("path does not exist: %w", err)
 	}
 
 	fmt.Printf("\n%s▸%s Scanning: %s\n", orange, reset, targetPath)
 	fmt.Printf("%s▸%s Model: %s\n\n", orange, reset, modelName)
 
+	// Files are only modified in trusted workspaces
+	scanner.SetSafeMode(!cfg.Trusted(targetPath))
+
 	// Initialize Ollama client
-	client := ollama.NewClient("http://localhost:11434")
+	client := ollama.NewClient(cfg.OllamaURL)
 
 	// Check if model is available
 	if err := client.CheckModel(modelName); err != nil {
-		return fmt.Errorf("model check failed: %w\nMake sure Ollama is running and the model is installed", err)
+		return nil, fmt.Errorf("model check failed: %w\nMake sure Ollama is running and the model is installed", err)
 	}
 
 	// Initialize scanner
-	s := scanner.NewScanner(client, modelName, debug, scanType, customPrompt)
+	s := scanner.NewScanner(client, modelName, cfg.Debug, scanType, customPrompt)
 	defer s.Close()
+	s.SetConcurrency(cfg.Concurrency)
+	s.SetOrgContext(cfg.Context)
+	s.SetChunking(cfg.ChunkSize, cfg.ChunkOverlap)
+	s.SetContextCache(true)
+	s.SetResultCache(true)
+	s.SetQuarantine(cfg.Quarantines())
+	s.SetIncludeGenerated(cfg.IncludeGenerated)
+
+	// Print prompt answers as they are generated, under each file's header
+	if scanType == "custom" {
+		current := ""
+		s.SetStream(func(filePath, token string) {
+			if filePath != current {
+				current = filePath
+				fmt.Printf("\n%s━━━ %s ━━━%s\n", orange, filepath.Base(filePath), reset)
+			}
+			fmt.Print(token)
+		})
+	}
 
 	// Collect files
-	var files []string
-	if info.IsDir() {
-		files, err = collectFiles(targetPath)
-		if err != nil {
-			return fmt.Errorf("failed to collect files: %w", err)
-		}
-	} else {
-		files = []string{targetPath}
+	files, err := walker.Collect(targetPath, walker.Options{IncludeTests: cfg.IncludeTests})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect files: %w", err)
 	}
 
 	if len(files) == 0 {
 		fmt.Println("No files to scan")
-		return nil
+		return nil, nil
+	}
+
+	if limit := cfg.FileLimit(); limit > 0 && len(files) > limit && !confirm(len(files), limit) {
+		fmt.Println("Scan cancelled")
+		return nil, nil
 	}
 
 	fmt.Printf("%s▸%s Found %d files to analyze\n\n", orange, reset, len(files))
+	if scanType == "security" && cfg.CrossFileContexts() {
+		s.SetProjectContext(scanner.BuildProjectContext(files))
+	}
 
 	// Scan files
-	results, err := s.ScanFiles(files)
+	results, err := s.ScanFiles(context.Background(), files)
 	if err != nil {
-		return fmt.Errorf("scan failed: %w", err)
+		return nil, fmt.Errorf("scan failed: %w", err)
 	}
 
-	// Display results with review mode option
-	displayResults(results, client, modelName)
-
-	return nil
-}
-
-func collectFiles(root string) ([]string, error) {
-	var files []string
-
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			name := info.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" ||
-				name == "vendor" || name == "dist" || name == "build" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	// Findings marked false positive stay hidden until their re-check date
+	suppressed := 0
+	if list, err := history.LoadSuppressions(); err == nil {
+		results, suppressed = scanner.ApplySuppressions(results, list, time.Now())
+	}
 
-		// Skip sensitive files
-		name := info.Name()
-		sensitiveFiles := []string{".env", ".env.local", ".env.production", "id_rsa", "id_ed25519", ".pem", ".key", ".pfx", ".p12"}
-		for _, sensitive := range sensitiveFiles {
-			if name == sensitive || strings.HasSuffix(name, sensitive) {
-				return nil // Skip this file
-			}
+	displayResults(results, suppressed, client, modelName)
+
+	if cfg.OutputFormat() == "html" {
+		reportPath := cfg.ArtifactPath(report.GetDefaultReportPath(targetPath), targetPath)
+		if err := report.GenerateHTML(results, report.Metadata{
+			ScanPath:   targetPath,
+			Model:      modelName,
+			TotalFiles: len(files),
+			Suppressed: suppressed,
+			Languages:  walker.Languages(files),
+		}, reportPath); err != nil {
+			return nil, fmt.Errorf("failed to generate report: %w", err)
 		}
+		fmt.Printf("%s▸%s Report saved: %s\n", orange, reset, reportPath)
+	}
 
-		files = append(files, path)
-
-		return nil
-	})
-
-	return files, err
+	return results, nil
 }
 
-func displayResults(results []scanner.ScanResult, client *ollama.Client, modelName string) {
+func displayResults(results []scanner.ScanResult, suppressed int, client *ollama.Client, modelName string) {
 	filesWithIssues := 0
 
 	for _, result := range results {
 		if result.HasIssues {
 			filesWithIssues++
-			fmt.Printf("\n%s━━━ %s ━━━%s\n", orange, filepath.Base(result.FilePath), reset)
-			fmt.Println(result.RawFindings)
+			if result.Streamed {
+				continue
+			}
+			fmt.Printf("\n%s━━━ %s ━━━%s\n", orange, ui.Hyperlink(ui.EditorURL(result.FilePath, 0), filepath.Base(result.FilePath)), reset)
+			fmt.Println(result.Findings())
 			fmt.Println()
 
 		}
@@ -114,10 +137,26 @@ func displayResults(results []scanner.ScanResult, client *ollama.Client, modelNa
 	fmt.Printf("%s📊 Scan Summary%s\n", orange, reset)
 	fmt.Printf("   Files scanned: %d\n", len(results))
 	fmt.Printf("   Files with findings: %d\n", filesWithIssues)
+	if summary := scanner.SummarizeFindings(results); summary.Findings > 0 {
+		fmt.Printf("   Risk score: %d/100 (%s)\n", summary.RiskScore, summary.RiskLevel)
+	}
+	if suppressed > 0 {
+		fmt.Printf("   Suppressed as false positives: %d\n", suppressed)
+	}
+	if n := scanner.Resurfaced(results); n > 0 {
+		fmt.Printf("   ⏰ Resurfaced for re-review: %d\n", n)
+	}
+	if skipped := scanner.SkipSummary(results); skipped != "" {
+		fmt.Printf("   Skipped: %s\n", skipped)
+	}
+	if warnings := scanner.InjectionWarnings(results); len(warnings) > 0 {
+		fmt.Printf("   🛡️  Possible prompt injection (review by hand):\n")
+		for _, w := range warnings {
+			fmt.Printf("      %s\n", w)
+		}
+	}
 	if filesWithIssues == 0 {
 		fmt.Printf("   %s✓%s No issues detected!\n", cyan, reset)
 	}
 	fmt.Printf("%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", orange, reset)
-
-	// Review mode is no longer offered from scan output.
 }
@@ -36,26 +36,30 @@ func SelectMenu(title string, items []MenuItem, currentIndex int) (int, error) {
 			}
 		}
 
-		fmt.Printf("\n%sUse ↑↓ arrows, Enter/→ to select, ←/Esc to go back%s\n", orange, reset)
+		fmt.Printf("\n%sUse ↑↓ or j/k, Enter/→ to select, ←/Esc to go back%s\n", orange, reset)
 
 		// Read key
-		_, key, err := keyboard.GetKey()
+		char, key, err := keyboard.GetKey()
 		if err != nil {
 			return -1, err
 		}
 
-		switch key {
-		case keyboard.KeyArrowUp:
+		switch activeKeymap.resolve(char, key) {
+		case actionUp:
 			if selected > 0 {
 				selected--
 			}
-		case keyboard.KeyArrowDown:
+		case actionDown:
 			if selected < len(items)-1 {
 				selected++
 			}
-		case keyboard.KeyEnter, keyboard.KeyArrowRight:
+		case actionTop:
+			selected = 0
+		case actionBottom:
+			selected = len(items) - 1
+		case actionSelect:
 			return selected, nil
-		case keyboard.KeyEsc, keyboard.KeyArrowLeft:
+		case actionBack:
 			return -1, nil
 		}
 	}
@@ -0,0 +1,122 @@
+package interactive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readTarget asks what a prompt should run against: the whole repository,
+// a directory, a single file or a pasted snippet. It returns the path to
+// scan and a cleanup function for any temporary files; an empty path means
+// the user backed out.
+func (im *InteractiveMode) readTarget() (string, func(), error) {
+	noop := func() {}
+	items := []MenuItem{
+		{Label: "Whole repository", Value: "repo"},
+		{Label: "A directory", Value: "dir"},
+		{Label: "A single file", Value: "file"},
+		{Label: "Pasted snippet", Value: "snippet"},
+		{Label: "← Back", Value: "back"},
+	}
+
+	selected, err := SelectMenu("Run prompt against", items, 0)
+	if err != nil || selected == -1 {
+		return "", noop, err
+	}
+
+	im.clearScreen()
+	im.showWelcome()
+
+	switch items[selected].Value {
+	case "repo":
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", noop, err
+		}
+		return repoRoot(wd), noop, nil
+
+	case "dir", "file":
+		wantDir := items[selected].Value == "dir"
+		label := "File"
+		if wantDir {
+			label = "Directory"
+		}
+		fmt.Printf("\n%s▸%s %s path: ", orange, reset, label)
+		path := im.readInput()
+		if path == "" {
+			return "", noop, nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", noop, fmt.Errorf("path does not exist: %w", err)
+		}
+		if info.IsDir() != wantDir {
+			return "", noop, fmt.Errorf("%s is not a %s", path, strings.ToLower(label))
+		}
+		return path, noop, nil
+
+	case "snippet":
+		return im.readSnippet()
+	}
+
+	return "", noop, nil
+}
+
+// readSnippet reads pasted code up to a line containing only "." and
+// writes it to a temporary file, named so the model can tell its language.
+func (im *InteractiveMode) readSnippet() (string, func(), error) {
+	noop := func() {}
+
+	fmt.Printf("\n%s▸%s File name for the snippet, e.g. handler.go (press Enter for snippet.txt): ", orange, reset)
+	name := filepath.Base(im.readInput())
+	if name == "." || name == "/" {
+		name = "snippet.txt"
+	}
+
+	fmt.Printf("%s▸%s Paste the code, then a line with a single '.' to finish:\n", orange, reset)
+	var code strings.Builder
+	for {
+		line, err := im.reader.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") == "." {
+			break
+		}
+		code.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", noop, err
+		}
+	}
+	if strings.TrimSpace(code.String()) == "" {
+		return "", noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "sidekick-snippet-")
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create snippet directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(code.String()), 0600); err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("failed to write snippet: %w", err)
+	}
+	return path, cleanup, nil
+}
+
+// repoRoot returns the nearest enclosing directory containing .git, or dir
+// itself when it is not inside a repository.
+func repoRoot(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
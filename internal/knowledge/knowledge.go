@@ -0,0 +1,90 @@
+// Package knowledge maps the CWE and OWASP Top 10 identifiers models put
+// on findings to their canonical names, descriptions and references.
+package knowledge
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Entry describes one weakness or risk category.
+type Entry struct {
+	ID          string `json:"id"` // "CWE-89" or "OWASP-A03"
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+}
+
+//go:embed knowledge.json
+var data []byte
+
+// entries holds the knowledge base by ID, loaded on first use.
+var entries = func() map[string]Entry {
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		panic(fmt.Sprintf("knowledge: invalid embedded knowledge base: %v", err))
+	}
+	m := make(map[string]Entry, len(list))
+	for _, e := range list {
+		if e.URL == "" {
+			e.URL = cweURL(e.ID)
+		}
+		m[e.ID] = e
+	}
+	return m
+}()
+
+// cweIDPattern matches the ways models write CWE IDs: "CWE-89", "CWE89",
+// "cwe_89", "CWE: 089", "CWE-89: SQL Injection".
+var cweIDPattern = regexp.MustCompile(`(?i)^cwe[\s_:#-]*0*([1-9][0-9]{0,4})\b`)
+
+// owaspIDPattern matches OWASP Top 10 IDs: "OWASP-A03", "OWASP A3",
+// "A03:2021", "A03:2021-Injection". A 2017 suffix is kept, since that
+// list is numbered differently.
+var owaspIDPattern = regexp.MustCompile(`(?i)^(?:owasp[\s_:-]*(?:top[\s_-]*10[\s_:-]*)?)?a0?([1-9]|10)\b(?:\s*[:_-]\s*(20(?:17|21)))?`)
+
+// Normalize rewrites a CWE or OWASP ID to its canonical form, "CWE-89" or
+// "OWASP-A03" ("OWASP-A03:2017" for the 2017 list). Anything else is
+// returned trimmed but otherwise unchanged.
+func Normalize(id string) string {
+	id = strings.TrimSpace(id)
+	if m := cweIDPattern.FindStringSubmatch(id); m != nil {
+		return "CWE-" + m[1]
+	}
+	if m := owaspIDPattern.FindStringSubmatch(id); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		canonical := fmt.Sprintf("OWASP-A%02d", n)
+		if m[2] == "2017" {
+			canonical += ":2017"
+		}
+		return canonical
+	}
+	return id
+}
+
+// Lookup returns the entry for an ID, in any form Normalize accepts. CWEs
+// not in the knowledge base still get their MITRE reference URL, with an
+// empty name.
+func Lookup(id string) (Entry, bool) {
+	id = Normalize(id)
+	if e, ok := entries[id]; ok {
+		return e, true
+	}
+	if url := cweURL(id); url != "" {
+		return Entry{ID: id, URL: url}, true
+	}
+	return Entry{}, false
+}
+
+// cweURL returns the MITRE definition page of a canonical CWE ID, or "".
+func cweURL(id string) string {
+	n, ok := strings.CutPrefix(id, "CWE-")
+	if !ok {
+		return ""
+	}
+	return "https://cwe.mitre.org/data/definitions/" + n + ".html"
+}
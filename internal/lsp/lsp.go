@@ -0,0 +1,375 @@
+// Package lsp implements the small part of the Language Server Protocol
+// that editors need to show scan findings inline: documents are scanned
+// when saved, findings are published as diagnostics, and suggested fixes
+// are offered as quick fixes.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Position is a zero-based line and character offset in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the span from Start up to, not including, End.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic severities.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// Diagnostic is a finding shown on a range of a document.
+type Diagnostic struct {
+	Range    Range     `json:"range"`
+	Severity int       `json:"severity"`
+	Code     string    `json:"code,omitempty"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+	Data     *QuickFix `json:"data,omitempty"`
+}
+
+// QuickFix is the edit that fixes a diagnostic. It travels with the
+// diagnostic, so code action requests need no server-side state.
+type QuickFix struct {
+	Title   string `json:"title"`
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// ScanFunc scans the file at path and returns its diagnostics.
+type ScanFunc func(ctx context.Context, path string) ([]Diagnostic, error)
+
+// Server answers one editor over a pair of streams.
+type Server struct {
+	scan ScanFunc
+	name string
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // Scans in progress by document URI
+	wg      sync.WaitGroup
+}
+
+// NewServer returns a server that scans documents with scan. name
+// identifies it to the editor.
+func NewServer(scan ScanFunc, name string) *Server {
+	return &Server{
+		scan:    scan,
+		name:    name,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// message is a JSON-RPC request, notification or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type textDocument struct {
+	URI string `json:"uri"`
+}
+
+// Serve reads requests from in and writes responses and notifications to
+// out until the editor sends "exit" or in is closed. Scans still running
+// then are cancelled.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	defer s.stopScans()
+
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg == nil {
+			s.reply(nil, nil, &responseError{codeParseError, "invalid JSON"})
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		s.handle(msg)
+	}
+}
+
+func (s *Server) handle(msg *message) {
+	switch msg.Method {
+	case "initialize":
+		s.reply(msg.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    0, // Saved files are scanned from disk
+					"save":      map[string]bool{"includeText": false},
+				},
+				"codeActionProvider": map[string]interface{}{
+					"codeActionKinds": []string{"quickfix"},
+				},
+			},
+			"serverInfo": map[string]string{"name": s.name},
+		}, nil)
+	case "shutdown":
+		s.stopScans()
+		s.reply(msg.ID, nil, nil)
+	case "textDocument/didSave":
+		var p struct {
+			TextDocument textDocument `json:"textDocument"`
+		}
+		if json.Unmarshal(msg.Params, &p) == nil {
+			s.startScan(p.TextDocument.URI)
+		}
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument textDocument `json:"textDocument"`
+		}
+		if json.Unmarshal(msg.Params, &p) == nil {
+			s.cancelScan(p.TextDocument.URI)
+			s.publish(p.TextDocument.URI, []Diagnostic{})
+		}
+	case "textDocument/codeAction":
+		var p struct {
+			TextDocument textDocument `json:"textDocument"`
+			Context      struct {
+				Diagnostics []Diagnostic `json:"diagnostics"`
+			} `json:"context"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			s.reply(msg.ID, nil, &responseError{codeInvalidParams, err.Error()})
+			return
+		}
+		s.reply(msg.ID, codeActions(p.TextDocument.URI, p.Context.Diagnostics), nil)
+	default:
+		// Requests need an answer; other notifications are ignored
+		if msg.ID != nil {
+			s.reply(msg.ID, nil, &responseError{codeMethodNotFound, "method not supported: " + msg.Method})
+		}
+	}
+}
+
+// codeActions offers the quick fixes of the diagnostics an editor asks
+// about.
+func codeActions(uri string, diagnostics []Diagnostic) []interface{} {
+	actions := []interface{}{}
+	for _, d := range diagnostics {
+		if d.Source != "sidekick" || d.Data == nil {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"title":       d.Data.Title,
+			"kind":        "quickfix",
+			"diagnostics": []Diagnostic{d},
+			"edit": map[string]interface{}{
+				"changes": map[string][]interface{}{
+					uri: {map[string]interface{}{"range": d.Data.Range, "newText": d.Data.NewText}},
+				},
+			},
+		})
+	}
+	return actions
+}
+
+// startScan scans the document at uri in the background and publishes its
+// diagnostics. A scan of the same document still running is cancelled.
+func (s *Server) startScan(uri string) {
+	path, err := PathFromURI(uri)
+	if err != nil {
+		s.logMessage(fmt.Sprintf("cannot scan %s: %v", uri, err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	if prev, ok := s.cancels[uri]; ok {
+		prev()
+	}
+	s.cancels[uri] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		diagnostics, err := s.scan(ctx, path)
+
+		s.mu.Lock()
+		current := ctx.Err() == nil
+		if current {
+			delete(s.cancels, uri)
+		}
+		s.mu.Unlock()
+		cancel()
+		if !current {
+			return // Superseded by a newer save, or closed
+		}
+		if err != nil {
+			s.logMessage(fmt.Sprintf("scan of %s failed: %v", path, err))
+			return
+		}
+		if diagnostics == nil {
+			diagnostics = []Diagnostic{}
+		}
+		s.publish(uri, diagnostics)
+	}()
+}
+
+func (s *Server) cancelScan(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.cancels[uri]; ok {
+		cancel()
+		delete(s.cancels, uri)
+	}
+}
+
+// stopScans cancels every scan in progress and waits for them to return.
+func (s *Server) stopScans() {
+	s.mu.Lock()
+	for uri, cancel := range s.cancels {
+		cancel()
+		delete(s.cancels, uri)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) publish(uri string, diagnostics []Diagnostic) {
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// logMessage shows msg in the editor's log for the server.
+func (s *Server) logMessage(msg string) {
+	s.notify("window/logMessage", map[string]interface{}{"type": 1, "message": msg})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	s.write(&message{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}, rerr *responseError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	if result == nil && rerr == nil {
+		// A successful response must carry a result, even if null
+		s.writeRaw(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":null}`, id))
+		return
+	}
+	s.write(&message{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+}
+
+func (s *Server) write(msg *message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.writeRaw(string(data))
+}
+
+func (s *Server) writeRaw(body string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// readMessage reads one message framed by a Content-Length header. It
+// returns a nil message when the body isn't valid JSON.
+func readMessage(r *bufio.Reader) (*message, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read message header: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, nil
+	}
+	return &msg, nil
+}
+
+// PathFromURI returns the file path of a file:// URI.
+func PathFromURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", u.Scheme)
+	}
+	path := u.Path
+	// file:///C:/dir on Windows
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
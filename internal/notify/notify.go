@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// Summary is the scan outcome sent to notification channels.
+type Summary struct {
+	Target          string
+	Model           string
+	FilesScanned    int
+	FilesWithIssues int
+	BySeverity      map[string]int
+}
+
+// Notifier delivers a scan summary to a single channel.
+type Notifier interface {
+	Name() string
+	Notify(summary Summary) error
+}
+
+// FromConfig returns a notifier for every webhook configured.
+func FromConfig(cfg config.NotifyConfig) []Notifier {
+	var notifiers []Notifier
+	if cfg.SlackWebhook != "" {
+		notifiers = append(notifiers, &SlackNotifier{WebhookURL: cfg.SlackWebhook})
+	}
+	if cfg.TeamsWebhook != "" {
+		notifiers = append(notifiers, &TeamsNotifier{WebhookURL: cfg.TeamsWebhook})
+	}
+	if cfg.DiscordWebhook != "" {
+		notifiers = append(notifiers, &DiscordNotifier{WebhookURL: cfg.DiscordWebhook})
+	}
+	return notifiers
+}
+
+// Text renders the summary as a short plain-text message.
+func (s Summary) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sidekick scan of %s (%s)\n", s.Target, s.Model)
+	fmt.Fprintf(&b, "Files scanned: %d | Files with findings: %d", s.FilesScanned, s.FilesWithIssues)
+	for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
+		if n := s.BySeverity[sev]; n > 0 {
+			fmt.Fprintf(&b, "\n%s: %d", sev, n)
+		}
+	}
+	return b.String()
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+func postJSON(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
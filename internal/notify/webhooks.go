@@ -0,0 +1,44 @@
+package notify
+
+// SlackNotifier posts to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (n *SlackNotifier) Name() string { return "Slack" }
+
+func (n *SlackNotifier) Notify(summary Summary) error {
+	return postJSON(n.WebhookURL, map[string]string{"text": summary.Text()})
+}
+
+// TeamsNotifier posts a MessageCard to a Microsoft Teams incoming webhook.
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+func (n *TeamsNotifier) Name() string { return "Teams" }
+
+func (n *TeamsNotifier) Notify(summary Summary) error {
+	color := "2EB886"
+	if summary.BySeverity["CRITICAL"] > 0 || summary.BySeverity["HIGH"] > 0 {
+		color = "FF7E00"
+	}
+	return postJSON(n.WebhookURL, map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    "Sidekick scan finished",
+		"themeColor": color,
+		"text":       summary.Text(),
+	})
+}
+
+// DiscordNotifier posts to a Discord channel webhook.
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (n *DiscordNotifier) Name() string { return "Discord" }
+
+func (n *DiscordNotifier) Notify(summary Summary) error {
+	return postJSON(n.WebhookURL, map[string]string{"content": summary.Text()})
+}
@@ -0,0 +1,111 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// autoTuner limits concurrent generate requests to a limit that follows the
+// server. Requests are timed in windows; the limit grows by one while the
+// average latency stays close to the best seen, shrinks by one when it has
+// doubled (the server is queueing rather than running requests in
+// parallel), and halves after a failed request.
+type autoTuner struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	max    int
+	active int
+
+	// Current window
+	count  int
+	total  time.Duration
+	failed bool
+
+	best time.Duration // Lowest window average so far
+}
+
+func newAutoTuner(max int) *autoTuner {
+	t := &autoTuner{limit: 1, max: max}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// acquire waits for a free slot, or until ctx is done, and returns when the
+// request started.
+func (t *autoTuner) acquire(ctx context.Context) (time.Time, error) {
+	// Wake the waiters below when ctx is done, so they can give up
+	stop := context.AfterFunc(ctx, func() {
+		t.mu.Lock()
+		t.cond.Broadcast()
+		t.mu.Unlock()
+	})
+	defer stop()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.active >= t.limit {
+		if err := ctx.Err(); err != nil {
+			return time.Time{}, err
+		}
+		t.cond.Wait()
+	}
+	t.active++
+	return time.Now(), nil
+}
+
+// release frees the slot of a request that started at started and records
+// its outcome.
+func (t *autoTuner) release(started time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if errors.Is(err, context.Canceled) {
+		// Cancelled by the user; says nothing about the server
+	} else if err != nil {
+		t.failed = true
+	} else {
+		t.count++
+		t.total += time.Since(started)
+	}
+	// A window covers two rounds of requests at the current limit
+	if t.failed || t.count >= 2*t.limit {
+		t.adjust()
+	}
+	t.cond.Broadcast()
+}
+
+// adjust sets the limit from the window just finished and starts a new one.
+func (t *autoTuner) adjust() {
+	if t.failed {
+		t.limit = maxInt(1, t.limit/2)
+	} else {
+		avg := t.total / time.Duration(t.count)
+		if t.best == 0 || avg < t.best {
+			t.best = avg
+		}
+		switch {
+		case avg > 2*t.best && t.limit > 1:
+			t.limit--
+		case avg <= t.best*5/4 && t.limit < t.max:
+			t.limit++
+		}
+	}
+	t.count, t.total, t.failed = 0, 0, false
+}
+
+// current returns the limit in effect.
+func (t *autoTuner) current() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limit
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
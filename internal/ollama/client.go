@@ -1,23 +1,93 @@
 package ollama
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	session    sessionState
+	mock       bool
+	options    *Options
+	inFlight   chan struct{} // Limits concurrent generate requests when non-nil
+	tuner      *autoTuner    // Adapts the limit to the server when non-nil
+
+	usageMu sync.Mutex
+	usage   TokenUsage
+}
+
+// TokenUsage counts prompt and completion tokens reported by Ollama.
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Add accumulates other into u.
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+}
+
+// Total returns prompt plus completion tokens.
+func (u TokenUsage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
 }
 
 type GenerateRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model   string   `json:"model"`
+	Prompt  string   `json:"prompt"`
+	Stream  bool     `json:"stream"`
+	Options *Options `json:"options,omitempty"`
+	// Format constrains the response: "json", or a JSON schema it must
+	// match (structured outputs, Ollama 0.5+).
+	Format interface{} `json:"format,omitempty"`
+}
+
+// Options are Ollama model parameters sent with a generate request. Fields
+// are pointers so an explicit zero (e.g. temperature 0) is still sent.
+type Options struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	NumCtx      *int     `json:"num_ctx,omitempty"`     // Context window in tokens
+	NumPredict  *int     `json:"num_predict,omitempty"` // Maximum tokens to generate; -1 = no limit
+}
+
+// String renders the options set, e.g. "temperature=0 seed=42 num_ctx=32768".
+func (o *Options) String() string {
+	if o == nil {
+		return "model defaults"
+	}
+	var parts []string
+	if o.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature=%g", *o.Temperature))
+	}
+	if o.TopP != nil {
+		parts = append(parts, fmt.Sprintf("top_p=%g", *o.TopP))
+	}
+	if o.Seed != nil {
+		parts = append(parts, fmt.Sprintf("seed=%d", *o.Seed))
+	}
+	if o.NumCtx != nil {
+		parts = append(parts, fmt.Sprintf("num_ctx=%d", *o.NumCtx))
+	}
+	if o.NumPredict != nil {
+		parts = append(parts, fmt.Sprintf("num_predict=%d", *o.NumPredict))
+	}
+	if len(parts) == 0 {
+		return "model defaults"
+	}
+	return strings.Join(parts, " ")
 }
 
 type GenerateResponse struct {
@@ -25,6 +95,9 @@ type GenerateResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 	Response  string    `json:"response"`
 	Done      bool      `json:"done"`
+
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
 }
 
 type TagsResponse struct {
@@ -37,52 +110,356 @@ type Model struct {
 	Size       int64     `json:"size"`
 }
 
+// ModelDetails describes an installed model, as reported by /api/show.
+type ModelDetails struct {
+	Name              string
+	Family            string
+	Format            string
+	ParameterSize     string
+	QuantizationLevel string
+	ContextLength     int
+	ModifiedAt        time.Time
+}
+
+type showResponse struct {
+	Details struct {
+		Format            string `json:"format"`
+		Family            string `json:"family"`
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+	ModelInfo  map[string]interface{} `json:"model_info"`
+	ModifiedAt time.Time              `json:"modified_at"`
+}
+
+// metadataTimeout bounds requests other than generations, such as listing
+// models. Generations run until their context is done.
+const metadataTimeout = 30 * time.Second
+
 func NewClient(baseURL string) *Client {
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute,
-		},
+		baseURL:    baseURL,
+		httpClient: &http.Client{},
+	}
+}
+
+// SetOptions sets the default generation options used by Generate.
+func (c *Client) SetOptions(opts *Options) {
+	c.options = opts
+}
+
+// Options returns the default generation options, or nil for model defaults.
+func (c *Client) Options() *Options {
+	return c.options
+}
+
+// SetMaxInFlight limits how many generate requests may run against the
+// server at once, independent of how many goroutines call Generate.
+// Zero or a negative value removes the limit.
+func (c *Client) SetMaxInFlight(n int) {
+	if n <= 0 {
+		c.inFlight = nil
+		return
+	}
+	c.inFlight = make(chan struct{}, n)
+}
+
+// SetAutoTune replaces the fixed in-flight limit with one that starts at a
+// single request and adapts, up to max, to the latency and errors of the
+// server. Zero or a negative max turns auto-tuning off.
+func (c *Client) SetAutoTune(max int) {
+	if max <= 0 {
+		c.tuner = nil
+		return
 	}
+	c.tuner = newAutoTuner(max)
+}
+
+// AutoTuneLimit returns the current auto-tuned in-flight limit, or 0 when
+// auto-tuning is off.
+func (c *Client) AutoTuneLimit() int {
+	if c.tuner == nil {
+		return 0
+	}
+	return c.tuner.current()
+}
+
+// Generate generates a response with the default options. Cancelling ctx
+// aborts the request, including one still waiting for an in-flight slot.
+func (c *Client) Generate(ctx context.Context, model, prompt string) (string, error) {
+	return c.GenerateWithOptions(ctx, model, prompt, c.options)
+}
+
+// GenerateWithOptions is Generate with per-request generation options.
+func (c *Client) GenerateWithOptions(ctx context.Context, model, prompt string, opts *Options) (string, error) {
+	response, _, err := c.GenerateDetailed(ctx, model, prompt, opts)
+	return response, err
+}
+
+// TotalUsage returns the tokens used by every generate call made so far.
+func (c *Client) TotalUsage() TokenUsage {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	return c.usage
+}
+
+// GenerateDetailed is GenerateWithOptions that also reports token usage.
+func (c *Client) GenerateDetailed(ctx context.Context, model, prompt string, opts *Options) (string, TokenUsage, error) {
+	return c.generate(ctx, model, prompt, opts, nil)
+}
+
+// GenerateWithSchema generates a response constrained to schema, a JSON
+// schema given as any value that marshals to one, with the default options.
+func (c *Client) GenerateWithSchema(ctx context.Context, model, prompt string, schema any) (string, error) {
+	response, _, err := c.GenerateWithSchemaDetailed(ctx, model, prompt, schema, c.options)
+	return response, err
+}
+
+// GenerateWithSchemaDetailed is GenerateWithSchema with explicit options,
+// also reporting token usage. Mock and replayed responses ignore the
+// schema.
+func (c *Client) GenerateWithSchemaDetailed(ctx context.Context, model, prompt string, schema any, opts *Options) (string, TokenUsage, error) {
+	return c.generate(ctx, model, prompt, opts, schema)
 }
 
-func (c *Client) Generate(model, prompt string) (string, error) {
+func (c *Client) generate(ctx context.Context, model, prompt string, opts *Options, format interface{}) (string, TokenUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return "", TokenUsage{}, err
+	}
+	if c.replaying() {
+		response, err := c.replayResponse(model, prompt)
+		return response, TokenUsage{}, err
+	}
+	if c.mock {
+		response := mockResponse(prompt)
+		c.record(model, prompt, response)
+		return response, TokenUsage{}, nil
+	}
+
 	reqBody := GenerateRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false,
+		Model:   model,
+		Prompt:  prompt,
+		Stream:  false,
+		Options: opts,
+		Format:  format,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return "", TokenUsage{}, err
+	}
+	result, err := c.postGenerate(ctx, jsonData)
+	release(err)
+	if err != nil {
+		return "", TokenUsage{}, err
+	}
+
+	c.record(model, prompt, result.Response)
+
+	usage := TokenUsage{PromptTokens: result.PromptEvalCount, CompletionTokens: result.EvalCount}
+	c.usageMu.Lock()
+	c.usage.Add(usage)
+	c.usageMu.Unlock()
+
+	return result.Response, usage, nil
+}
+
+// GenerateStream generates a response with the default options, calling
+// onToken with each piece of text as Ollama streams it.
+func (c *Client) GenerateStream(ctx context.Context, model, prompt string, onToken func(string)) error {
+	_, _, err := c.GenerateStreamDetailed(ctx, model, prompt, c.options, onToken)
+	return err
+}
+
+// GenerateStreamDetailed is GenerateStream with explicit options. It also
+// returns the complete response and the token usage reported at the end of
+// the stream. Mock and replayed responses arrive as a single token.
+func (c *Client) GenerateStreamDetailed(ctx context.Context, model, prompt string, opts *Options, onToken func(string)) (string, TokenUsage, error) {
+	if c.replaying() || c.mock {
+		response, usage, err := c.GenerateDetailed(ctx, model, prompt, opts)
+		if err == nil && onToken != nil {
+			onToken(response)
+		}
+		return response, usage, err
+	}
+
+	jsonData, err := json.Marshal(GenerateRequest{
+		Model:   model,
+		Prompt:  prompt,
+		Stream:  true,
+		Options: opts,
+	})
+	if err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := c.httpClient.Post(
-		c.baseURL+"/api/generate",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return "", TokenUsage{}, err
+	}
+	response, usage, err := c.postStream(ctx, jsonData, onToken)
+	release(err)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return response, usage, err
+	}
+
+	c.record(model, prompt, response)
+
+	c.usageMu.Lock()
+	c.usage.Add(usage)
+	c.usageMu.Unlock()
+
+	return response, usage, nil
+}
+
+// acquire waits until a generate request may be sent, or ctx is done, and
+// returns the function to call with its outcome once it has finished.
+func (c *Client) acquire(ctx context.Context) (func(err error), error) {
+	if c.tuner != nil {
+		started, err := c.tuner.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func(err error) { c.tuner.release(started, err) }, nil
+	}
+	if c.inFlight != nil {
+		select {
+		case c.inFlight <- struct{}{}:
+			return func(error) { <-c.inFlight }, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func(error) {}, nil
+}
+
+// postGenerate sends a non-streaming generate request.
+func (c *Client) postGenerate(ctx context.Context, jsonData []byte) (GenerateResponse, error) {
+	var result GenerateResponse
+	resp, err := c.postJSON(ctx, "/api/generate", jsonData)
+	if err != nil {
+		return result, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return result, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var result GenerateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return result, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result, nil
+}
+
+// postStream sends a streaming generate request, calling onToken with each
+// piece of text, and returns the complete response and token usage.
+func (c *Client) postStream(ctx context.Context, jsonData []byte, onToken func(string)) (string, TokenUsage, error) {
+	resp, err := c.postJSON(ctx, "/api/generate", jsonData)
+	if err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", TokenUsage{}, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Ollama streams one JSON object per line; the last has done=true and the token counts
+	var response strings.Builder
+	var usage TokenUsage
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var chunk struct {
+			GenerateResponse
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &chunk); err != nil {
+			return response.String(), usage, fmt.Errorf("failed to decode stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return response.String(), usage, fmt.Errorf("generation failed: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			response.WriteString(chunk.Response)
+			if onToken != nil {
+				onToken(chunk.Response)
+			}
+		}
+		if chunk.Done {
+			usage = TokenUsage{PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount}
+			break
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return response.String(), usage, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return response.String(), usage, nil
+}
+
+// postJSON posts a JSON body to path, bounded by ctx.
+func (c *Client) postJSON(ctx context.Context, path string, jsonData []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.httpClient.Do(req)
+}
+
+// metadataRequest sends a request other than a generation, with an
+// optional JSON body, bounded by metadataTimeout. Closing the response body
+// releases the deadline.
+func (c *Client) metadataRequest(method, path string, jsonData []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	var body io.Reader
+	if jsonData != nil {
+		body = bytes.NewReader(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelOnClose cancels a request's context when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
 
-	return result.Response, nil
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func (c *Client) CheckModel(modelName string) error {
-	resp, err := c.httpClient.Get(c.baseURL + "/api/tags")
+	// Replayed sessions don't need a running Ollama server
+	if c.replaying() || c.mock {
+		return nil
+	}
+
+	resp, err := c.metadataRequest(http.MethodGet, "/api/tags", nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
@@ -116,7 +493,10 @@ func getModelNames(models []Model) []string {
 }
 
 func (c *Client) ListModels() ([]string, error) {
-	resp, err := c.httpClient.Get(c.baseURL + "/api/tags")
+	if c.mock {
+		return []string{MockModel}, nil
+	}
+	resp, err := c.metadataRequest(http.MethodGet, "/api/tags", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
@@ -135,7 +515,10 @@ func (c *Client) ListModels() ([]string, error) {
 }
 
 func (c *Client) ListModelsWithDetails() ([]Model, error) {
-	resp, err := c.httpClient.Get(c.baseURL + "/api/tags")
+	if c.mock {
+		return []Model{{Name: MockModel}}, nil
+	}
+	resp, err := c.metadataRequest(http.MethodGet, "/api/tags", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
@@ -152,3 +535,123 @@ func (c *Client) ListModelsWithDetails() ([]Model, error) {
 
 	return tags.Models, nil
 }
+
+// ShowModel fetches details for an installed model from /api/show.
+func (c *Client) ShowModel(modelName string) (*ModelDetails, error) {
+	if c.mock {
+		return &ModelDetails{Name: MockModel, Family: "mock", Format: "none", ParameterSize: "0B", QuantizationLevel: "none"}, nil
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"model": modelName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.metadataRequest(http.MethodPost, "/api/show", jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var show showResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	details := &ModelDetails{
+		Name:              modelName,
+		Family:            show.Details.Family,
+		Format:            show.Details.Format,
+		ParameterSize:     show.Details.ParameterSize,
+		QuantizationLevel: show.Details.QuantizationLevel,
+		ModifiedAt:        show.ModifiedAt,
+	}
+	// Context length is keyed by architecture, e.g. "qwen2.context_length"
+	if arch, ok := show.ModelInfo["general.architecture"].(string); ok {
+		if n, ok := show.ModelInfo[arch+".context_length"].(float64); ok {
+			details.ContextLength = int(n)
+		}
+	}
+
+	return details, nil
+}
+
+// PullProgress is one progress update streamed by /api/pull.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PullModel downloads a model, calling progress for each streamed update.
+func (c *Client) PullModel(modelName string, progress func(PullProgress)) error {
+	if c.mock {
+		return fmt.Errorf("cannot pull models with the mock backend")
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"model": modelName, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Downloads can take far longer than a generate request
+	resp, err := (&http.Client{}).Post(c.baseURL+"/api/pull", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		var p PullProgress
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			continue
+		}
+		if p.Error != "" {
+			return fmt.Errorf("pull failed: %s", p.Error)
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("failed to read pull progress: %w", err)
+	}
+	return nil
+}
+
+// DeleteModel removes an installed model.
+func (c *Client) DeleteModel(modelName string) error {
+	if c.mock {
+		return fmt.Errorf("cannot delete models with the mock backend")
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"model": modelName})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.metadataRequest(http.MethodDelete, "/api/delete", jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MockModel is the only model reported by the mock backend.
+const MockModel = "mock"
+
+// NewMockClient returns a client that never contacts Ollama. It answers
+// scan prompts with canned findings derived from simple pattern matches, so
+// the full scan and report pipeline can be exercised without a model.
+func NewMockClient() *Client {
+	return &Client{baseURL: "mock://", mock: true}
+}
+
+var numberedLine = regexp.MustCompile(`^\s*(\d+) \| (.*)$`)
+
+type mockLine struct {
+	file string
+	num  int
+	text string
+}
+
+type mockFinding struct {
+	Severity       string `json:"severity"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	LineStart      int    `json:"line_start"`
+	LineEnd        int    `json:"line_end"`
+	Evidence       string `json:"evidence"`
+	Recommendation string `json:"recommendation"`
+	Confidence     string `json:"confidence"`
+	IssueID        string `json:"issue_id"`
+	FixAvailable   bool   `json:"fix_available"`
+	CVSSVector     string `json:"cvss_vector,omitempty"`
+	file           string
+}
+
+var mockRules = []struct {
+	match func(string) bool
+	build func(line mockLine) mockFinding
+}{
+	{
+		match: func(s string) bool {
+			lower := strings.ToLower(s)
+			return (strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.Contains(lower, "api_key")) &&
+				strings.Contains(s, "= \"")
+		},
+		build: func(l mockLine) mockFinding {
+			return mockFinding{Severity: "HIGH", Title: "Hardcoded Credentials", IssueID: "CWE-798",
+				CVSSVector:     "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+				Description:    "A credential is embedded directly in source code.",
+				Recommendation: "Load secrets from the environment or a secret manager."}
+		},
+	},
+	{
+		match: func(s string) bool {
+			upper := strings.ToUpper(s)
+			return strings.Contains(s, "fmt.Sprintf") && (strings.Contains(upper, "SELECT ") || strings.Contains(upper, "INSERT ") ||
+				strings.Contains(upper, "UPDATE ") || strings.Contains(upper, "DELETE "))
+		},
+		build: func(l mockLine) mockFinding {
+			return mockFinding{Severity: "CRITICAL", Title: "SQL Injection", IssueID: "CWE-89",
+				CVSSVector:     "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+				Description:    "A SQL query is built with string formatting from a caller-supplied value.",
+				Recommendation: "Use parameterized queries with placeholders."}
+		},
+	},
+	{
+		match: func(s string) bool { return strings.Contains(s, "exec.Command(") },
+		build: func(l mockLine) mockFinding {
+			return mockFinding{Severity: "CRITICAL", Title: "Command Injection", IssueID: "CWE-78",
+				CVSSVector:     "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:H",
+				Description:    "A process is started with arguments that may come from user input.",
+				Recommendation: "Avoid shell invocation and validate arguments against an allow-list."}
+		},
+	},
+	{
+		match: func(s string) bool { return strings.Contains(s, "os.ReadFile(") || strings.Contains(s, "os.Open(") },
+		build: func(l mockLine) mockFinding {
+			return mockFinding{Severity: "MEDIUM", Title: "Path Traversal", IssueID: "CWE-22",
+				Description:    "A file path is opened without validating it stays within an allowed directory.",
+				Recommendation: "Clean the path and verify it is inside a permitted base directory."}
+		},
+	},
+}
+
+// parseNumberedCode extracts line-numbered code (as produced by the scanner)
+// from a prompt, tracking the most recent FILE: header.
+func parseNumberedCode(prompt string) []mockLine {
+	var lines []mockLine
+	file := ""
+	for _, raw := range strings.Split(prompt, "\n") {
+		if strings.HasPrefix(raw, "FILE: ") {
+			file = strings.TrimSpace(strings.TrimPrefix(raw, "FILE: "))
+			continue
+		}
+		m := numberedLine.FindStringSubmatch(raw)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		lines = append(lines, mockLine{file: file, num: n, text: m[2]})
+	}
+	return lines
+}
+
+func mockFindings(prompt string) []mockFinding {
+	findings := make([]mockFinding, 0)
+	seen := make(map[string]bool)
+	for _, line := range parseNumberedCode(prompt) {
+		for _, rule := range mockRules {
+			if !rule.match(line.text) {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", line.file, line.num)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			f := rule.build(line)
+			f.LineStart = line.num
+			f.LineEnd = line.num
+			f.Evidence = strings.TrimSpace(line.text)
+			f.Confidence = "MEDIUM"
+			f.file = line.file
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// mockLanguages maps file extensions to the language the mock reports.
+var mockLanguages = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".ts": "TypeScript",
+	".java": "Java", ".rb": "Ruby", ".php": "PHP", ".rs": "Rust",
+	".c": "C", ".cpp": "C++", ".cs": "C#", ".sql": "SQL", ".md": "Markdown",
+}
+
+// mockLanguage guesses the language of the prompt's FILE from its extension.
+func mockLanguage(prompt string) string {
+	for _, raw := range strings.Split(prompt, "\n") {
+		if !strings.HasPrefix(raw, "FILE: ") {
+			continue
+		}
+		file := strings.TrimSpace(strings.TrimPrefix(raw, "FILE: "))
+		if i := strings.LastIndex(file, "."); i >= 0 {
+			if lang, ok := mockLanguages[strings.ToLower(file[i:])]; ok {
+				return lang
+			}
+		}
+		break
+	}
+	return "unknown"
+}
+
+func mockResponse(prompt string) string {
+	switch {
+	case strings.Contains(prompt, "Analyze the context of this code file"):
+		return fmt.Sprintf(`{"language": %q, "version": "unknown", "frameworks": [], "libraries": [], "purpose": "Mock analysis", "data_handling": [], "security_concerns": []}`, mockLanguage(prompt))
+
+	case strings.Contains(prompt, "You are the ATTACKER"):
+		return "- (mock) Static findings are assumed exploitable by an unauthenticated attacker."
+
+	case strings.Contains(prompt, "You are the DEFENDER"):
+		return "- (mock) No mitigating controls were identified."
+
+	case strings.Contains(prompt, "You are the AUDITOR"):
+		type vuln struct {
+			Type           string `json:"type"`
+			File           string `json:"file"`
+			Line           int    `json:"line"`
+			Evidence       string `json:"evidence"`
+			Recommendation string `json:"recommendation"`
+		}
+		vulns := make([]vuln, 0)
+		for _, f := range mockFindings(prompt) {
+			vulns = append(vulns, vuln{Type: f.Title, File: f.file, Line: f.LineStart, Evidence: f.Description, Recommendation: f.Recommendation})
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"final_severity":  "High",
+			"confidence":      "High",
+			"summary":         "Mock auditor report",
+			"vulnerabilities": vulns,
+		})
+		return string(data)
+
+	case strings.Contains(prompt, "You are fixing a confirmed vulnerability"):
+		return `{"fix_available": false}`
+
+	case strings.Contains(prompt, `{"rows": [`):
+		return mockRows(prompt)
+
+	case strings.Contains(prompt, "Classify the license of this file"):
+		return mockLicense(prompt)
+
+	case strings.Contains(prompt, `"findings"`):
+		data, _ := json.Marshal(map[string]interface{}{"findings": mockFindings(prompt)})
+		return string(data)
+	}
+
+	return "Mock backend response: no model was called. Run without --backend mock to use Ollama."
+}
+
+// mockRows answers a structured custom prompt with a single placeholder row
+// holding every requested field.
+func mockRows(prompt string) string {
+	const marker = "Use exactly these fields in every row: "
+	row := make(map[string]string)
+	if i := strings.Index(prompt, marker); i >= 0 {
+		list := prompt[i+len(marker):]
+		if j := strings.Index(list, "\n"); j >= 0 {
+			list = list[:j]
+		}
+		for _, f := range strings.Split(list, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				row[f] = "(mock) " + f
+			}
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"rows": []map[string]string{row}})
+	return string(data)
+}
+
+// mockSPDX matches an SPDX tag in the numbered header of a license prompt.
+var mockSPDX = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.+-]+)`)
+
+// mockGPLNotice matches a detected GPL notice listed in a license prompt.
+var mockGPLNotice = regexp.MustCompile(`(?m)^- line (\d+): (?:embedded )?((?:A|L)?GPL-[0-9.]+) license`)
+
+// mockLicense classifies a file by its SPDX tag: tagged files are ok, code
+// without one is missing its header, and anything else is exempt. GPL
+// notices in projects under another license are incompatible.
+func mockLicense(prompt string) string {
+	if m := mockGPLNotice.FindStringSubmatch(prompt); m != nil && !strings.Contains(prompt, "PROJECT LICENSE: "+m[2]) {
+		line, _ := strconv.Atoi(m[1])
+		data, _ := json.Marshal(map[string]interface{}{"license": m[2], "status": "incompatible", "line": line, "reason": "(mock) GPL code in a project under another license."})
+		return string(data)
+	}
+	answer := map[string]interface{}{"license": "", "status": "exempt", "line": 0, "reason": "(mock) Not a source file."}
+	for _, line := range parseNumberedCode(prompt) {
+		if m := mockSPDX.FindStringSubmatch(line.text); m != nil {
+			answer = map[string]interface{}{"license": m[1], "status": "ok", "line": line.num, "reason": "(mock) The file has an SPDX tag."}
+			break
+		}
+	}
+	if answer["status"] == "exempt" {
+		if lang := mockLanguage(prompt); lang != "unknown" && lang != "Markdown" {
+			answer = map[string]interface{}{"license": "", "status": "missing", "line": 0, "reason": "(mock) The file has no license notice."}
+		}
+	}
+	data, _ := json.Marshal(answer)
+	return string(data)
+}
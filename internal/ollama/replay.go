@@ -0,0 +1,118 @@
+package ollama
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Session is a recorded set of generate requests and their responses.
+type Session struct {
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded generate call.
+type Interaction struct {
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+type sessionState struct {
+	mu        sync.Mutex
+	recording *Session
+	replay    map[string][]string // prompt key -> remaining responses, in recorded order
+}
+
+func interactionKey(model, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// StartRecording captures every subsequent generate request and response.
+func (c *Client) StartRecording() {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	c.session.recording = &Session{RecordedAt: time.Now()}
+}
+
+// SaveRecording writes the captured session to path.
+func (c *Client) SaveRecording(path string) error {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.recording == nil {
+		return fmt.Errorf("recording not started")
+	}
+	data, err := json.MarshalIndent(c.session.recording, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadReplay makes the client answer generate requests from a recorded
+// session instead of contacting Ollama.
+func (c *Client) LoadReplay(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return fmt.Errorf("failed to parse session: %w", err)
+	}
+
+	replay := make(map[string][]string)
+	for _, in := range session.Interactions {
+		key := interactionKey(in.Model, in.Prompt)
+		replay[key] = append(replay[key], in.Response)
+	}
+
+	c.session.mu.Lock()
+	c.session.replay = replay
+	c.session.mu.Unlock()
+	return nil
+}
+
+func (c *Client) replaying() bool {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	return c.session.replay != nil
+}
+
+func (c *Client) replayResponse(model, prompt string) (string, error) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	key := interactionKey(model, prompt)
+	responses := c.session.replay[key]
+	if len(responses) == 0 {
+		return "", fmt.Errorf("no recorded response for this prompt (model %s)", model)
+	}
+	// Keep the last response around so repeated prompts still resolve
+	if len(responses) > 1 {
+		c.session.replay[key] = responses[1:]
+	}
+	return responses[0], nil
+}
+
+func (c *Client) record(model, prompt, response string) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.recording == nil {
+		return
+	}
+	c.session.recording.Interactions = append(c.session.recording.Interactions, Interaction{
+		Model:    model,
+		Prompt:   prompt,
+		Response: response,
+	})
+}
@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	"io/fs"
 	"strings"
 	"text/template"
 )
 
-//go:embed custom/*.txt
+//go:embed custom family context.txt
 var promptFS embed.FS
 
 type CustomPromptData struct {
@@ -16,6 +17,144 @@ type CustomPromptData struct {
 	UserPrompt string
 	FilePath   string
 	Code       string
+	Model      string // Used to pick a model-family template variant, if one exists
+	Context    string // Organization context block from OrgContext, may be empty
+}
+
+// modelFamilies are matched against model names in order; the first prefix wins.
+var modelFamilies = []string{"qwen", "deepseek", "llama", "codellama"}
+
+// ModelFamily returns the prompt family for a model name such as
+// "qwen2.5-coder:14b", or "default" when the family has no variants.
+func ModelFamily(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, family := range modelFamilies {
+		if strings.HasPrefix(name, family) {
+			if family == "codellama" {
+				return "llama"
+			}
+			return family
+		}
+	}
+	return "default"
+}
+
+// JSONInstructions returns the JSON output formatting instructions that work
+// best for the given model's family.
+func JSONInstructions(model string) string {
+	data, err := promptFS.ReadFile(fmt.Sprintf("family/%s.txt", ModelFamily(model)))
+	if err != nil {
+		data, _ = promptFS.ReadFile("family/default.txt")
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// OrgContext renders the organization context variables (compliance
+// requirements, deployment environment, threat model notes, ...) as a prompt
+// block ending in a blank line, or "" when there are none.
+func OrgContext(vars map[string]string) string {
+	if len(vars) == 0 {
+		return ""
+	}
+	data, err := promptFS.ReadFile("context.txt")
+	if err != nil {
+		return ""
+	}
+	tmpl, err := template.New("context.txt").Parse(string(data))
+	if err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return ""
+	}
+	return buf.String() + "\n"
+}
+
+// ExpandVars replaces {{.Context.<name>}} placeholders in text, such as a
+// user's custom prompt, with organization context variables. Text that
+// isn't a valid template is returned unchanged.
+func ExpandVars(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	tmpl, err := template.New("prompt").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return text
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Context map[string]string }{vars}); err != nil {
+		return text
+	}
+	return buf.String()
+}
+
+// requiredPlaceholders must appear in every custom prompt template.
+var requiredPlaceholders = []string{"{{.UserPrompt}}", "{{.FilePath}}", "{{.Code}}"}
+
+// overrides replace the embedded custom prompt templates, keyed by mode.
+var overrides map[string]string
+
+// SetTemplates replaces the custom prompt templates of the given modes (ask,
+// edit or plan), e.g. with a project's own, for every model family. It
+// rejects templates that don't parse or lack a required placeholder.
+func SetTemplates(templates map[string]string) error {
+	for mode, text := range templates {
+		if errs := CheckTemplate(mode, text); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	overrides = templates
+	return nil
+}
+
+// CheckTemplate parses a custom prompt template and checks that it
+// references the required placeholders.
+func CheckTemplate(name, text string) []error {
+	if _, err := template.New(name).Parse(text); err != nil {
+		return []error{fmt.Errorf("%s: %w", name, err)}
+	}
+	var errs []error
+	for _, placeholder := range requiredPlaceholders {
+		if !strings.Contains(text, placeholder) {
+			errs = append(errs, fmt.Errorf("%s: missing placeholder %s", name, placeholder))
+		}
+	}
+	return errs
+}
+
+// ValidateTemplates parses every embedded custom prompt template and checks
+// that it references the required placeholders.
+func ValidateTemplates() []error {
+	var errs []error
+
+	var paths []string
+	err := fs.WalkDir(promptFS, "custom", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".txt") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return []error{fmt.Errorf("read prompt templates: %w", err)}
+	}
+
+	for _, path := range paths {
+		tmplBytes, err := promptFS.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		errs = append(errs, CheckTemplate(path, string(tmplBytes))...)
+	}
+
+	return errs
 }
 
 func RenderCustomPrompt(data CustomPromptData) (string, error) {
@@ -27,10 +166,19 @@ func RenderCustomPrompt(data CustomPromptData) (string, error) {
 		mode = "ask"
 	}
 
-	path := fmt.Sprintf("custom/%s.txt", mode)
+	// Prefer an override, then a model-family variant
+	// (custom/<family>/<mode>.txt) when present
+	path := fmt.Sprintf("custom/%s/%s.txt", ModelFamily(data.Model), mode)
 	tmplBytes, err := promptFS.ReadFile(path)
+	if text, ok := overrides[mode]; ok {
+		path, tmplBytes, err = mode, []byte(text), nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("read prompt template: %w", err)
+		path = fmt.Sprintf("custom/%s.txt", mode)
+		tmplBytes, err = promptFS.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read prompt template: %w", err)
+		}
 	}
 
 	tmpl, err := template.New(path).Parse(string(tmplBytes))
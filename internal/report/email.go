@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// SendEmail delivers a report file as an attachment to the given recipients.
+func SendEmail(cfg *config.SMTPConfig, to []string, subject, body, attachmentPath string) error {
+	if cfg == nil || cfg.Host == "" {
+		return fmt.Errorf("smtp is not configured")
+	}
+	if cfg.From == "" {
+		return fmt.Errorf("smtp.from is not configured")
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+	for _, addr := range append([]string{cfg.From}, to...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("invalid email address: %q", addr)
+		}
+	}
+
+	attachment, err := os.ReadFile(attachmentPath)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+
+	var msg bytes.Buffer
+	mw := multipart.NewWriter(&msg)
+
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	textPart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=utf-8"},
+	})
+	if err != nil {
+		return err
+	}
+	textPart.Write([]byte(body))
+
+	name := filepath.Base(attachmentPath)
+	filePart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", name)},
+	})
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		filePart.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	filePart.Write([]byte(encoded + "\r\n"))
+
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	password := cfg.Password
+	if env := os.Getenv("SIDEKICK_SMTP_PASSWORD"); env != "" {
+		password = env
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, password, cfg.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", cfg.Host, port)
+	if err := smtp.SendMail(addr, auth, cfg.From, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pefman/sidekick/internal/scanner"
+)
+
+// Formatter renders the results of a scan in one report format. meta
+// describes the scan as fully as the JSON report does.
+type Formatter func(results []scanner.ScanResult, meta JSONMetadata) ([]byte, error)
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[string]Formatter)
+)
+
+func init() {
+	RegisterFormatter("json", func(results []scanner.ScanResult, meta JSONMetadata) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, results, meta); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	RegisterFormatter("html", func(results []scanner.ScanResult, meta JSONMetadata) ([]byte, error) {
+		return RenderHTML(results, meta.Metadata)
+	})
+}
+
+// RegisterFormatter makes a report format available by name, e.g. for
+// "sidekick scan --format <name>". Programs embedding sidekick call it from
+// an init function to add their own formats. Registering the same name
+// twice, or the name "text" used by the terminal output, panics.
+func RegisterFormatter(name string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	if name == "text" {
+		panic("report: formatter name \"text\" is reserved for terminal output")
+	}
+	if _, dup := formatters[name]; dup {
+		panic(fmt.Sprintf("report: formatter %q registered twice", name))
+	}
+	formatters[name] = f
+}
+
+// LookupFormatter returns the formatter registered for a format name.
+func LookupFormatter(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	f, ok := formatters[name]
+	return f, ok
+}
+
+// FormatterNames returns the registered report formats, sorted.
+func FormatterNames() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Format renders results with the formatter registered for name.
+func Format(name string, results []scanner.ScanResult, meta JSONMetadata) ([]byte, error) {
+	f, ok := LookupFormatter(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown report format %q (available: %s)", name, strings.Join(FormatterNames(), ", "))
+	}
+	data, err := f(results, meta)
+	if err != nil {
+		return nil, fmt.Errorf("%s report: %w", name, err)
+	}
+	return data, nil
+}
@@ -1,27 +1,95 @@
 package report
 
 import (
+	"bytes"
 	"fmt"
 	"html/template"
+	"maps"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/pefman/sidekick/internal/knowledge"
 	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/ui"
+	"github.com/pefman/sidekick/internal/walker"
 )
 
+// Metadata describes how a scan was run, for inclusion in reports.
+type Metadata struct {
+	ScanPath   string
+	Model      string
+	TotalFiles int
+	Generation string // Generation parameters, e.g. "temperature=0 seed=42"
+	Incomplete string // Why the scan ended early, e.g. "interrupted: 12 of 40 files scanned"
+	Fast       bool   // One lightweight pass per file (--fast)
+	Suppressed int    // Findings left out as false positives
+
+	// MinSeverity, when set, is the lowest severity of the findings in the
+	// results; SeverityTotals then counts every finding, including those
+	// filtered out.
+	MinSeverity    string
+	SeverityTotals map[string]int
+
+	// Summary rolls up every finding of the scan; when nil it is computed
+	// from the results.
+	Summary *scanner.ProjectSummary
+
+	// Languages counts the collected files and their lines per language,
+	// showing reviewers what the scan covered.
+	Languages []walker.LanguageStats
+}
+
+// LinesScanned returns the lines of all collected files.
+func (m Metadata) LinesScanned() int {
+	n := 0
+	for _, l := range m.Languages {
+		n += l.Lines
+	}
+	return n
+}
+
 type HTMLReport struct {
+	Lang            string // Language of the page, from the locale, so browsers pick CJK fonts and line breaking
 	Timestamp       string
 	ScanPath        string
 	Model           string
+	Generation      string
+	Incomplete      string
+	Fast            bool
+	Suppressed      int
+	MinSeverity     string
+	HiddenFindings  int
 	TotalFiles      int
 	FilesWithIssues int
+	TotalFindings   int
+	Severities      []SeverityCount
+	Summary         *scanner.ProjectSummary
+	Languages       []walker.LanguageStats
+	LinesScanned    int
 	Results         []scanner.ScanResult
+	Owners          []OwnerSummary
+	TechStack       *scanner.TechStack
+	Licenses        *scanner.LicenseSummary
 	GenerationTime  string
 }
 
+// SeverityCount is the number of findings at one severity.
+type SeverityCount struct {
+	Severity string
+	Count    int
+}
+
+// OwnerSummary counts findings for one CODEOWNERS owner.
+type OwnerSummary struct {
+	Owner    string
+	Findings []scanner.SecurityIssue
+}
+
 const htmlTemplate = `<!DOCTYPE html>
-<html lang="en">
+<html lang="{{.Lang}}">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
@@ -32,12 +100,30 @@ const htmlTemplate = `<!DOCTYPE html>
         .header { padding: 20px 24px; border-bottom: 1px solid #222; color: #ff7e00; }
         .summary { display: grid; grid-template-columns: repeat(auto-fit, minmax(180px, 1fr)); gap: 12px; padding: 20px 24px; }
         .card { background: #151515; padding: 16px; border: 1px solid #222; }
+        .warning { margin: 20px 24px 0; padding: 12px 16px; border: 1px solid #ff8700; color: #ff8700; }
+        .file .warning { margin: 12px 16px 0; }
         .content { padding: 20px 24px; }
         .file { margin-bottom: 16px; border: 1px solid #222; }
         .file-header { background: #1a1a1a; color: #ff7e00; padding: 10px 12px; }
         .findings { padding: 12px; }
         .footer { padding: 16px; text-align: center; color: #777; border-top: 1px solid #222; }
-        pre { white-space: pre-wrap; }
+        pre { white-space: pre-wrap; overflow-wrap: anywhere; }
+        .findings, td { overflow-wrap: anywhere; line-break: strict; }
+        table { border-collapse: collapse; width: 100%; }
+        th, td { border: 1px solid #222; padding: 6px 8px; text-align: left; vertical-align: top; }
+        th { color: #ff7e00; background: #151515; }
+        .count { font-size: 24px; font-weight: bold; }
+        .issue { border-top: 1px solid #222; padding: 12px 0; }
+        .issue:first-child { border-top: none; padding-top: 0; }
+        .issue-header { display: flex; flex-wrap: wrap; align-items: center; gap: 8px; }
+        .badge { display: inline-block; padding: 2px 8px; border-radius: 3px; font-size: 12px; font-weight: bold; color: #000; background: #9f9f9f; }
+        .tag { color: #999; font-size: 12px; }
+        .meta { color: #999; font-size: 12px; margin: 6px 0; }
+        .meta span + span::before { content: " · "; }
+        a { color: #4fa3ff; }
+        details { margin: 8px 0; }
+        summary { cursor: pointer; color: #ff7e00; }
+        pre.code { background: #0a0a0a; border: 1px solid #222; padding: 8px; overflow-x: auto; font-family: monospace; }
     </style>
 </head>
 <body>
@@ -45,17 +131,135 @@ const htmlTemplate = `<!DOCTYPE html>
     <div class="header">
       <h2>Sidekick Report</h2>
     </div>
+    {{if .Incomplete}}<div class="warning">⚠️ Incomplete scan ({{.Incomplete}}). Files that weren't scanned may have findings too.</div>{{end}}
+    {{if .Fast}}<div class="warning">⚡ Fast scan: one pass per file without context analysis or suggested fixes. Findings are lower fidelity than a full scan, and files without findings may still have issues.</div>{{end}}
+    {{if .Suppressed}}<div class="warning">{{.Suppressed}} finding(s) marked false positive in review mode are left out until their re-check date (sidekick suppressions lists them).</div>{{end}}
+    {{if .HiddenFindings}}<div class="warning">Showing findings at or above {{.MinSeverity}}: {{.HiddenFindings}} lower-severity finding(s) are counted below but not listed.</div>{{end}}
+    <div class="summary">
+      <div class="card"><div class="count">{{.TotalFindings}}</div>Findings</div>
+      {{range .Severities}}<div class="card"><div class="count">{{.Count}}</div><span class="badge" style="{{severityStyle .Severity}}">{{severityEmoji .Severity}} {{.Severity}}</span></div>{{end}}
+    </div>
     <div class="summary">
       <div class="card">Files Scanned: {{.TotalFiles}}</div>
+      {{if .LinesScanned}}<div class="card">Lines Scanned: {{.LinesScanned}}</div>{{end}}
       <div class="card">Files With Findings: {{.FilesWithIssues}}</div>
       <div class="card">Model: {{.Model}}</div>
+      {{if .Generation}}<div class="card">Generation: {{.Generation}}</div>{{end}}
+    </div>
+    {{if .Languages}}
+    <div class="summary">
+      {{range .Languages}}<div class="card">{{.Language}}: {{.Files}} file(s), {{.Lines}} lines</div>{{end}}
     </div>
+    {{end}}
+    {{with .Summary}}
+    <div class="content">
+      <h3>Project Summary</h3>
+      <div class="summary" style="padding: 0 0 12px;">
+        <div class="card"><div class="count">{{.RiskScore}}<span class="tag"> / 100</span></div>Risk score <span class="badge" style="{{severityStyle .RiskLevel}}">{{.RiskLevel}}</span></div>
+      </div>
+      {{if .ByCategory}}
+      <table>
+        <tr><th>Category</th><th>Example</th><th>Findings</th></tr>
+        {{range .ByCategory}}<tr><td>{{with reference .ID}}<a href="{{.URL}}">{{.ID}}</a>{{else}}{{or .ID "Uncategorized"}}{{end}}</td><td>{{.Title}}</td><td>{{.Count}}</td></tr>{{end}}
+      </table>
+      {{end}}
+      {{if .TopFiles}}
+      <h4>Top Files</h4>
+      <table>
+        <tr><th>File</th><th>Findings</th><th>Worst</th></tr>
+        {{range .TopFiles}}<tr><td><a href="{{lineURL .File 0}}">{{.File}}</a></td><td>{{.Findings}}</td><td><span class="badge" style="{{severityStyle .Worst}}">{{severityEmoji .Worst}} {{.Worst}}</span></td></tr>{{end}}
+      </table>
+      {{end}}
+    </div>
+    {{end}}
+    {{with .TechStack}}
+    <div class="content">
+      <h3>Tech Stack</h3>
+      <table>
+        <tr><th>Languages</th><td>{{range $i, $l := .Languages}}{{if $i}}, {{end}}{{$l.Name}} ({{$l.Files}}){{end}}</td></tr>
+        {{if .Frameworks}}<tr><th>Frameworks</th><td>{{range $i, $f := .Frameworks}}{{if $i}}, {{end}}{{$f.Name}} ({{$f.Files}}){{end}}</td></tr>{{end}}
+        {{if .Libraries}}<tr><th>Libraries</th><td>{{range $i, $l := .Libraries}}{{if $i}}, {{end}}{{$l.Name}} ({{$l.Files}}){{end}}</td></tr>{{end}}
+      </table>
+    </div>
+    {{end}}
+    {{with .Licenses}}
+    <div class="content">
+      <h3>License Compliance</h3>
+      <table>
+        {{if .Projects}}<tr><th>Project License</th><td>{{range $i, $p := .Projects}}{{if $i}}, {{end}}{{$p}}{{end}}</td></tr>{{end}}
+        <tr><th>Files</th><td>{{.Files}}: {{.Compliant}} compliant, {{.Incompatible}} incompatible, {{.Unknown}} unidentified, {{.Missing}} missing a header, {{.Exempt}} exempt</td></tr>
+        {{if .Licenses}}<tr><th>Licenses</th><td>{{range $i, $l := .Licenses}}{{if $i}}, {{end}}{{$l.Name}} ({{$l.Files}}){{end}}</td></tr>{{end}}
+      </table>
+    </div>
+    {{end}}
+    {{if .Owners}}
+    <div class="content">
+      <h3>Findings by Owner</h3>
+      {{range .Owners}}
+      <div class="file">
+        <div class="file-header">{{.Owner}} ({{len .Findings}})</div>
+        <div class="findings">
+          {{range .Findings}}<div><span class="badge" style="{{severityStyle .Severity}}">{{severityEmoji .Severity}} {{.Severity}}</span> {{.Title}}{{if .File}} — <a href="{{lineURL .File .LineStart}}">{{.File}}:{{.LineStart}}</a>{{end}}</div>{{end}}
+        </div>
+      </div>
+      {{end}}
+    </div>
+    {{end}}
     <div class="content">
       {{range .Results}}
-      {{if .HasIssues}}
+      {{if or .HasIssues .Quarantined}}
       <div class="file">
-        <div class="file-header">{{.FilePath}}</div>
+        <div class="file-header">{{.FilePath}}{{if .Layer}} <span class="tag">📦 {{.Layer}}</span>{{end}}</div>
+        {{if .Quarantined}}<div class="warning">🛡️ Possible prompt injection on line(s) {{formatLines .Quarantined}}: text addressed to the AI reviewer. Review this file by hand.</div>{{end}}
+        {{if .Table}}
+        <div class="findings">
+          <table>
+            <tr>{{range .Table.Columns}}<th>{{.}}</th>{{end}}</tr>
+            {{range .Table.Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>{{end}}
+          </table>
+        </div>
+        {{else if .Issues}}
+        <div class="findings">
+          {{$file := .FilePath}}
+          {{range .Issues}}
+          {{$path := or .File $file}}
+          <div class="issue">
+            <div class="issue-header">
+              <span class="badge" style="{{severityStyle .Severity}}">{{severityEmoji .Severity}} {{.Severity}}</span>
+              <strong>{{.Title}}</strong>
+              {{if .IssueID}}{{with reference .IssueID}}<a class="tag" href="{{.URL}}" title="{{.Name}}{{if .Description}}: {{.Description}}{{end}}">{{.ID}}{{if .Name}} {{.Name}}{{end}}</a>{{else}}<span class="tag">{{.IssueID}}</span>{{end}}{{end}}
+              {{if .Resurfaced}}<span class="tag">⏰ Re-review: suppression expired</span>{{end}}
+              {{if .Generated}}<span class="tag">generated-code</span>{{end}}
+              {{if .LineStart}}<a href="{{lineURL $path .LineStart}}">{{if .File}}{{.File}}:{{end}}line {{.LineStart}}{{if gt .LineEnd .LineStart}}-{{.LineEnd}}{{end}}</a>{{end}}
+            </div>
+            <div class="meta">
+              {{- if .CVSSVector}}<span title="{{.CVSSVector}}">CVSS {{printf "%.1f" .CVSSScore}}</span>{{end}}
+              {{- if .Confidence}}<span>Confidence: {{.Confidence}}</span>{{end}}
+              {{- if .ChangedLines}}<span>Changed: {{.ChangedLines}}</span>{{end}}
+              {{- if .Owner}}<span>Owner: {{.Owner}}</span>{{end}}
+              {{- if .Author}}<span>{{.Author}}{{if .Commit}} ({{.Commit}}){{end}}</span>{{end}}
+              {{- if .Models}}<span>Models: {{join .Models ", "}}</span>{{end -}}
+            </div>
+            <p>{{.Description}}</p>
+            {{if .CodeSnippet}}<pre class="code">{{.CodeSnippet}}</pre>{{end}}
+            {{if .Recommendation}}
+            <details>
+              <summary>Recommendation</summary>
+              <p>{{.Recommendation}}</p>
+            </details>
+            {{end}}
+            {{if .SuggestedFix}}
+            <details>
+              <summary>Suggested fix</summary>
+              <pre class="code">{{.SuggestedFix}}</pre>
+            </details>
+            {{end}}
+          </div>
+          {{end}}
+        </div>
+        {{else if .RawFindings}}
         <div class="findings"><pre>{{.RawFindings}}</pre></div>
+        {{end}}
       </div>
       {{end}}
       {{end}}
@@ -65,44 +269,173 @@ const htmlTemplate = `<!DOCTYPE html>
 </body>
 </html>`
 
-func GenerateHTML(results []scanner.ScanResult, scanPath, model string, totalFiles int, outputPath string) error {
+// GenerateHTML writes the HTML report for a scan to outputPath.
+func GenerateHTML(results []scanner.ScanResult, meta Metadata, outputPath string) error {
+	data, err := RenderHTML(results, meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	return nil
+}
+
+// RenderHTML renders the HTML report for a scan.
+func RenderHTML(results []scanner.ScanResult, meta Metadata) ([]byte, error) {
 	filesWithIssues := 0
+	totalFindings := 0
 	for _, result := range results {
 		if result.HasIssues {
 			filesWithIssues++
 		}
+		totalFindings += len(result.Issues)
 	}
 
 	report := HTMLReport{
+		Lang:            "en",
 		Timestamp:       time.Now().Format("2006-01-02 15:04:05"),
-		ScanPath:        scanPath,
-		Model:           model,
-		TotalFiles:      totalFiles,
+		ScanPath:        meta.ScanPath,
+		Model:           meta.Model,
+		Generation:      meta.Generation,
+		Incomplete:      meta.Incomplete,
+		Fast:            meta.Fast,
+		Suppressed:      meta.Suppressed,
+		MinSeverity:     meta.MinSeverity,
+		TotalFiles:      meta.TotalFiles,
 		FilesWithIssues: filesWithIssues,
+		TotalFindings:   totalFindings,
+		Severities:      countSeverities(scanner.CountSeverities(results)),
+		Summary:         meta.Summary,
+		Languages:       meta.Languages,
+		LinesScanned:    meta.LinesScanned(),
 		Results:         results,
+		Owners:          groupByOwner(results),
+		TechStack:       scanner.SummarizeTechStack(results),
+		Licenses:        scanner.SummarizeLicenses(results),
 		GenerationTime:  time.Now().Format("2006-01-02 15:04:05"),
 	}
 
+	if lang := ui.Language(); lang != "" {
+		report.Lang = lang
+	}
+	if report.Summary == nil {
+		summary := scanner.SummarizeFindings(results)
+		report.Summary = &summary
+	}
+	if meta.SeverityTotals != nil {
+		report.Severities = countSeverities(meta.SeverityTotals)
+		report.TotalFindings = 0
+		for _, n := range meta.SeverityTotals {
+			report.TotalFindings += n
+		}
+		report.HiddenFindings = report.TotalFindings - totalFindings
+	}
+
 	funcMap := template.FuncMap{
-		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
+		"severityStyle": severityStyle,
+		"severityEmoji": ui.SeverityEmoji,
+		"lineURL":       lineURL,
+		"join":          strings.Join,
+		"formatLines":   scanner.FormatLines,
+		"reference":     reference,
 	}
 
 	tmpl, err := template.New("report").Funcs(funcMap).Parse(htmlTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, report); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// severityOrder lists the severities shown in the summary cards, most
+// severe first.
+var severityOrder = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+// countSeverities lists findings per severity for the summary cards. The
+// standard severities are always listed; any others the model used follow
+// in name order.
+func countSeverities(counts map[string]int) []SeverityCount {
+	counts = maps.Clone(counts)
+	var out []SeverityCount
+	for _, sev := range severityOrder {
+		out = append(out, SeverityCount{Severity: sev, Count: counts[sev]})
+		delete(counts, sev)
 	}
-	defer file.Close()
+	var others []string
+	for sev := range counts {
+		others = append(others, sev)
+	}
+	sort.Strings(others)
+	for _, sev := range others {
+		out = append(out, SeverityCount{Severity: sev, Count: counts[sev]})
+	}
+	return out
+}
+
+// severityStyle returns the badge style of a severity, in the configured
+// severity color.
+func severityStyle(severity string) template.CSS {
+	return template.CSS("background: " + ui.SeverityHex(severity))
+}
 
-	if err := tmpl.Execute(file, report); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+// reference returns the knowledge base entry for a CWE or OWASP ID, or nil
+// so the template can fall back to the raw ID.
+func reference(id string) *knowledge.Entry {
+	if id == "" {
+		return nil
+	}
+	e, ok := knowledge.Lookup(id)
+	if !ok {
+		return nil
 	}
+	return &e
+}
 
-	return nil
+// lineURL links to a line of a scanned file, opening it in the configured
+// editor. html/template rejects file: and editor URLs from plain strings, so
+// the URL is built here with its path escaped.
+func lineURL(path string, line int) template.URL {
+	return template.URL(ui.EditorURL(path, line))
+}
+
+// groupByOwner groups findings by CODEOWNERS owner. It returns nil when no
+// finding has an owner so the section is omitted from the report.
+func groupByOwner(results []scanner.ScanResult) []OwnerSummary {
+	byOwner := make(map[string][]scanner.SecurityIssue)
+	hasOwner := false
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			owner := issue.Owner
+			if owner == "" {
+				owner = "Unowned"
+			} else {
+				hasOwner = true
+			}
+			if issue.File == "" {
+				issue.File = result.FilePath
+			}
+			byOwner[owner] = append(byOwner[owner], issue)
+		}
+	}
+	if !hasOwner {
+		return nil
+	}
+
+	owners := make([]OwnerSummary, 0, len(byOwner))
+	for owner, findings := range byOwner {
+		owners = append(owners, OwnerSummary{Owner: owner, Findings: findings})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].Owner < owners[j].Owner
+	})
+	return owners
 }
 
 func GetDefaultReportPath(scanPath string) string {
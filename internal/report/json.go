@@ -0,0 +1,214 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/scanner"
+	"github.com/pefman/sidekick/internal/walker"
+)
+
+// JSONMetadata describes a scan for the JSON report, in addition to the
+// fields shared with the other report formats.
+type JSONMetadata struct {
+	Metadata
+	ToolVersion string
+	ScanType    string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Temperature *float64
+	TopP        *float64
+	Seed        *int
+	NumCtx      *int
+	NumPredict  *int
+}
+
+// JSONReport is the machine-readable scan report described by Schema.
+type JSONReport struct {
+	SchemaVersion string                  `json:"schema_version"`
+	Tool          jsonTool                `json:"tool"`
+	Scan          jsonScan                `json:"scan"`
+	Results       []jsonResult            `json:"results"`
+	TechStack     *scanner.TechStack      `json:"tech_stack,omitempty"`
+	Licenses      *scanner.LicenseSummary `json:"licenses,omitempty"` // License compliance summary of license scans
+	Summary       *scanner.ProjectSummary `json:"summary,omitempty"`
+}
+
+type jsonTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type jsonScan struct {
+	Target          string                 `json:"target"`
+	Model           string                 `json:"model"`
+	ScanType        string                 `json:"scan_type"`
+	StartedAt       string                 `json:"started_at,omitempty"`
+	FinishedAt      string                 `json:"finished_at,omitempty"`
+	FilesScanned    int                    `json:"files_scanned"`
+	FilesWithIssues int                    `json:"files_with_issues"`
+	Incomplete      string                 `json:"incomplete,omitempty"`   // Why the scan ended early
+	Fast            bool                   `json:"fast,omitempty"`         // One lightweight pass per file, lower fidelity
+	Suppressed      int                    `json:"suppressed,omitempty"`   // Findings left out as false positives
+	MinSeverity     string                 `json:"min_severity,omitempty"` // Findings below it are counted but not listed
+	Severities      map[string]int         `json:"findings_by_severity"`
+	LinesScanned    int                    `json:"lines_scanned,omitempty"`
+	Languages       []walker.LanguageStats `json:"languages,omitempty"` // Files and lines per language, most lines first
+	Temperature     *float64               `json:"temperature,omitempty"`
+	TopP            *float64               `json:"top_p,omitempty"`
+	Seed            *int                   `json:"seed,omitempty"`
+	NumCtx          *int                   `json:"num_ctx,omitempty"`
+	NumPredict      *int                   `json:"num_predict,omitempty"`
+}
+
+type jsonResult struct {
+	File        string                  `json:"file"`
+	DuplicateOf string                  `json:"duplicate_of,omitempty"`      // Identical file whose findings were reused
+	Skipped     string                  `json:"skipped,omitempty"`           // Why the file wasn't scanned
+	Cached      bool                    `json:"cached,omitempty"`            // Reused from an earlier scan
+	Quarantined []int                   `json:"quarantined_lines,omitempty"` // Lines with text addressed to the model
+	Layer       string                  `json:"layer,omitempty"`             // Image layer the file came from
+	Generated   bool                    `json:"generated,omitempty"`         // Generated code (lockfile, protobuf output, "DO NOT EDIT")
+	HasIssues   bool                    `json:"has_issues"`
+	RawFindings string                  `json:"raw_findings,omitempty"` // Custom prompts only
+	Table       *scanner.Table          `json:"table,omitempty"`
+	Context     *scanner.FileContext    `json:"context,omitempty"`
+	License     *scanner.FileLicense    `json:"license,omitempty"`
+	Issues      []scanner.SecurityIssue `json:"issues"`
+}
+
+// BuildJSON assembles the JSON report for a scan.
+func BuildJSON(results []scanner.ScanResult, meta JSONMetadata) JSONReport {
+	rep := JSONReport{
+		SchemaVersion: SchemaVersion,
+		Tool:          jsonTool{Name: "sidekick", Version: meta.ToolVersion},
+		Scan: jsonScan{
+			Target:       meta.ScanPath,
+			Model:        meta.Model,
+			ScanType:     meta.ScanType,
+			FilesScanned: len(results),
+			Incomplete:   meta.Incomplete,
+			Fast:         meta.Fast,
+			Suppressed:   meta.Suppressed,
+			MinSeverity:  meta.MinSeverity,
+			Severities:   meta.SeverityTotals,
+			LinesScanned: meta.LinesScanned(),
+			Languages:    meta.Languages,
+			Temperature:  meta.Temperature,
+			TopP:         meta.TopP,
+			Seed:         meta.Seed,
+			NumCtx:       meta.NumCtx,
+			NumPredict:   meta.NumPredict,
+		},
+		Results:   make([]jsonResult, 0, len(results)),
+		TechStack: scanner.SummarizeTechStack(results),
+		Licenses:  scanner.SummarizeLicenses(results),
+		Summary:   meta.Summary,
+	}
+	if rep.Summary == nil {
+		summary := scanner.SummarizeFindings(results)
+		rep.Summary = &summary
+	}
+	if rep.Scan.Severities == nil {
+		rep.Scan.Severities = scanner.CountSeverities(results)
+	}
+	if !meta.StartedAt.IsZero() {
+		rep.Scan.StartedAt = meta.StartedAt.Format(time.RFC3339)
+	}
+	if !meta.FinishedAt.IsZero() {
+		rep.Scan.FinishedAt = meta.FinishedAt.Format(time.RFC3339)
+	}
+
+	for _, result := range results {
+		if result.HasIssues {
+			rep.Scan.FilesWithIssues++
+		}
+		r := jsonResult{
+			File:        result.FilePath,
+			DuplicateOf: result.DuplicateOf,
+			Skipped:     result.Skipped,
+			Cached:      result.Cached,
+			Quarantined: result.Quarantined,
+			Layer:       result.Layer,
+			Generated:   result.Generated,
+			HasIssues:   result.HasIssues,
+			Table:       result.Table,
+			Context:     result.Context,
+			License:     result.License,
+			Issues:      make([]scanner.SecurityIssue, 0, len(result.Issues)),
+		}
+		// Findings are fully structured; the rendered text only carries
+		// information for free-text custom prompt answers
+		if meta.ScanType == "custom" && result.Table == nil {
+			r.RawFindings = result.RawFindings
+		}
+		for _, issue := range result.Issues {
+			r.Issues = append(r.Issues, normalizeIssue(issue))
+		}
+		rep.Results = append(rep.Results, r)
+	}
+	return rep
+}
+
+// normalizeIssue upper-cases severity and confidence to the schema's enums,
+// dropping a confidence the model made up.
+func normalizeIssue(issue scanner.SecurityIssue) scanner.SecurityIssue {
+	issue.Severity = strings.ToUpper(strings.TrimSpace(issue.Severity))
+	issue.Confidence = strings.ToUpper(strings.TrimSpace(issue.Confidence))
+	switch issue.Confidence {
+	case "", "HIGH", "MEDIUM", "LOW":
+	default:
+		issue.Confidence = ""
+	}
+	return issue
+}
+
+// WriteJSON writes the JSON report for a scan to w.
+func WriteJSON(w io.Writer, results []scanner.ScanResult, meta JSONMetadata) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(BuildJSON(results, meta))
+}
+
+// ReadJSON parses a JSON report, checking it against Schema first.
+func ReadJSON(data []byte) (*JSONReport, error) {
+	problems, err := ValidateJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("report does not match schema v%s: %s", SchemaVersion, problems[0])
+	}
+	var rep JSONReport
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &rep, nil
+}
+
+// ScanResults returns the files of the report as scan results, e.g. to
+// apply the suggested fixes of an earlier scan.
+func (r *JSONReport) ScanResults() []scanner.ScanResult {
+	results := make([]scanner.ScanResult, 0, len(r.Results))
+	for _, res := range r.Results {
+		results = append(results, scanner.ScanResult{
+			FilePath:    res.File,
+			RawFindings: res.RawFindings,
+			HasIssues:   res.HasIssues,
+			Issues:      res.Issues,
+			Table:       res.Table,
+			Context:     res.Context,
+			License:     res.License,
+			DuplicateOf: res.DuplicateOf,
+			Skipped:     res.Skipped,
+			Cached:      res.Cached,
+			Quarantined: res.Quarantined,
+			Layer:       res.Layer,
+			Generated:   res.Generated,
+		})
+	}
+	return results
+}
@@ -0,0 +1,226 @@
+package report
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/knowledge"
+	"github.com/pefman/sidekick/internal/scanner"
+)
+
+func init() {
+	RegisterFormatter("sarif", RenderSARIF)
+}
+
+// sarifSchema is the SARIF 2.1.0 schema code scanning tools validate against.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name,omitempty"`
+	ShortDescription *sarifMessage  `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage  `json:"fullDescription,omitempty"`
+	HelpURI          string         `json:"helpUri,omitempty"`
+	Properties       map[string]any `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifLevels maps finding severities to SARIF result levels.
+var sarifLevels = map[string]string{
+	"CRITICAL": "error",
+	"HIGH":     "error",
+	"MEDIUM":   "warning",
+	"LOW":      "note",
+}
+
+// RenderSARIF renders results as a SARIF 2.1.0 log, for GitHub code
+// scanning and other SARIF viewers. Findings with a CWE or OWASP ID share a
+// rule carrying that ID's canonical name and reference; others get a rule
+// per title.
+func RenderSARIF(results []scanner.ScanResult, meta JSONMetadata) ([]byte, error) {
+	rules := make(map[string]sarifRule)
+	scores := make(map[string]float64)
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "sidekick",
+			Version:        meta.ToolVersion,
+			InformationURI: "https://github.com/pefman/sidekick",
+		}},
+		Results: []sarifResult{},
+	}
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			file := issue.File
+			if file == "" {
+				file = result.FilePath
+			}
+			rule := sarifRuleFor(issue)
+			if _, ok := rules[rule.ID]; !ok {
+				rules[rule.ID] = rule
+			}
+			if score := issue.Score(); score > scores[rule.ID] {
+				scores[rule.ID] = score
+			}
+
+			level := sarifLevels[strings.ToUpper(issue.Severity)]
+			if level == "" {
+				level = "warning"
+			}
+			text := issue.Title
+			if issue.Description != "" {
+				text += ": " + issue.Description
+			}
+			if issue.Recommendation != "" {
+				text += "\n\nRecommendation: " + issue.Recommendation
+			}
+
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: sarifURI(meta.ScanPath, file)},
+			}}
+			if issue.LineStart > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: issue.LineStart}
+				if issue.LineEnd > issue.LineStart {
+					loc.PhysicalLocation.Region.EndLine = issue.LineEnd
+				}
+			}
+
+			r := sarifResult{
+				RuleID:              rule.ID,
+				Level:               level,
+				Message:             sarifMessage{Text: text},
+				Locations:           []sarifLocation{loc},
+				PartialFingerprints: map[string]string{"sidekick/v1": issue.Fingerprint(file)},
+			}
+			if issue.Confidence != "" || issue.Generated || issue.CVSSVector != "" {
+				r.Properties = map[string]any{}
+				if issue.Confidence != "" {
+					r.Properties["confidence"] = issue.Confidence
+				}
+				if issue.Generated {
+					r.Properties["tags"] = []string{"generated-code"}
+				}
+				if issue.CVSSVector != "" {
+					r.Properties["cvss_vector"] = issue.CVSSVector
+					r.Properties["cvss_score"] = issue.CVSSScore
+				}
+			}
+			run.Results = append(run.Results, r)
+		}
+	}
+
+	run.Tool.Driver.Rules = make([]sarifRule, 0, len(rules))
+	for id, rule := range rules {
+		if score := scores[id]; score > 0 {
+			// GitHub code scanning ranks alerts by the highest score of a rule
+			rule.Properties = map[string]any{"security-severity": strconv.FormatFloat(score, 'f', 1, 64)}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	log := sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// sarifRuleFor returns the rule a finding is reported under.
+func sarifRuleFor(issue scanner.SecurityIssue) sarifRule {
+	if issue.IssueID != "" {
+		if ref, ok := knowledge.Lookup(issue.IssueID); ok {
+			rule := sarifRule{ID: ref.ID, HelpURI: ref.URL}
+			name := ref.Name
+			if name == "" {
+				name = issue.Title
+			}
+			rule.Name = name
+			rule.ShortDescription = &sarifMessage{Text: name}
+			if ref.Description != "" {
+				rule.FullDescription = &sarifMessage{Text: ref.Description}
+			}
+			return rule
+		}
+		return sarifRule{ID: issue.IssueID, Name: issue.Title, ShortDescription: &sarifMessage{Text: issue.Title}}
+	}
+	id := "sidekick/" + strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(issue.Title)), "-")
+	return sarifRule{ID: id, Name: issue.Title, ShortDescription: &sarifMessage{Text: issue.Title}}
+}
+
+// sarifURI makes file relative to the scanned directory when it lies
+// inside it, with forward slashes as SARIF requires.
+func sarifURI(scanPath, file string) string {
+	if scanPath != "" && filepath.IsAbs(file) {
+		base := scanPath
+		if abs, err := filepath.Abs(scanPath); err == nil {
+			base = abs
+		}
+		if rel, err := filepath.Rel(base, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON report format described by Schema.
+const SchemaVersion = "1"
+
+// Schema is the JSON schema for sidekick's JSON scan report.
+//
+//go:embed schema/report.schema.json
+var Schema []byte
+
+// ValidateJSON checks a JSON report against Schema and returns every
+// violation found. It supports the subset of JSON schema the report uses:
+// type, required, properties, items, enum and local $ref.
+func ValidateJSON(data []byte) ([]string, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		return nil, fmt.Errorf("invalid embedded schema: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	v := &schemaValidator{root: schema}
+	v.validate("$", schema, doc)
+	return v.errors, nil
+}
+
+type schemaValidator struct {
+	root   map[string]interface{}
+	errors []string
+}
+
+func (v *schemaValidator) addError(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *schemaValidator) resolve(schema map[string]interface{}) map[string]interface{} {
+	ref, ok := schema["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") {
+		return schema
+	}
+	var node interface{} = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return schema
+		}
+		node = m[part]
+	}
+	if resolved, ok := node.(map[string]interface{}); ok {
+		return resolved
+	}
+	return schema
+}
+
+func (v *schemaValidator) validate(path string, schema map[string]interface{}, value interface{}) {
+	schema = v.resolve(schema)
+
+	if typ, ok := schema["type"].(string); ok && !matchesType(typ, value) {
+		v.addError(path, "expected %s, got %s", typ, jsonTypeName(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addError(path, "value %v is not one of %v", value, enum)
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := val[name]; !present {
+					v.addError(path, "missing required property %q", name)
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if propSchema, ok := props[k].(map[string]interface{}); ok {
+				v.validate(path+"."+k, propSchema, val[k])
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				v.validate(fmt.Sprintf("%s[%d]", path, i), items, item)
+			}
+		}
+	}
+}
+
+func matchesType(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
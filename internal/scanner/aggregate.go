@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/knowledge"
+)
+
+// maxTopFiles is how many files ProjectSummary lists by finding count.
+const maxTopFiles = 5
+
+// riskWeights is what each finding adds to the risk score, by severity.
+var riskWeights = map[string]int{"CRITICAL": 50, "HIGH": 20, "MEDIUM": 5, "LOW": 1}
+
+// ProjectSummary rolls up the findings of a scan across all files.
+type ProjectSummary struct {
+	Findings   int             `json:"findings"`
+	BySeverity map[string]int  `json:"by_severity"`
+	ByCategory []CategoryCount `json:"by_category"` // Most frequent first
+	TopFiles   []FileCount     `json:"top_files"`   // Files with the most findings, most severe first on ties
+	RiskScore  int             `json:"risk_score"`  // 0-100, see SummarizeFindings
+	RiskLevel  string          `json:"risk_level"`  // NONE, LOW, MEDIUM, HIGH or CRITICAL
+}
+
+// CategoryCount is the number of findings with one CWE or OWASP ID.
+type CategoryCount struct {
+	ID    string `json:"id"`    // e.g. "CWE-89"; "" for findings without one
+	Title string `json:"title"` // Canonical name of the ID, else the title of the first such finding
+	Count int    `json:"count"`
+}
+
+// FileCount is the number of findings in one file.
+type FileCount struct {
+	File     string `json:"file"`
+	Findings int    `json:"findings"`
+	Worst    string `json:"worst_severity"`
+}
+
+// SummarizeFindings aggregates the findings of results. The risk score
+// adds 50 per critical, 20 per high, 5 per medium and 1 per low finding,
+// capped at 100, so one critical finding rates HIGH (30-59) and two rate
+// CRITICAL (60+); 10-29 is MEDIUM and anything below LOW.
+func SummarizeFindings(results []ScanResult) ProjectSummary {
+	sum := ProjectSummary{
+		BySeverity: make(map[string]int),
+		ByCategory: []CategoryCount{},
+		TopFiles:   []FileCount{},
+	}
+	categories := make(map[string]int) // ID -> index in ByCategory
+	files := make(map[string]int)      // File -> index in TopFiles
+	score := 0
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			severity := strings.ToUpper(issue.Severity)
+			sum.Findings++
+			sum.BySeverity[severity]++
+			score += riskWeights[severity]
+
+			id := strings.ToUpper(knowledge.Normalize(issue.IssueID))
+			i, ok := categories[id]
+			if !ok {
+				i = len(sum.ByCategory)
+				categories[id] = i
+				title := issue.Title
+				if ref, ok := knowledge.Lookup(id); ok && ref.Name != "" {
+					title = ref.Name
+				}
+				sum.ByCategory = append(sum.ByCategory, CategoryCount{ID: id, Title: title})
+			}
+			sum.ByCategory[i].Count++
+
+			file := issue.File
+			if file == "" {
+				file = result.FilePath
+			}
+			j, ok := files[file]
+			if !ok {
+				j = len(sum.TopFiles)
+				files[file] = j
+				sum.TopFiles = append(sum.TopFiles, FileCount{File: file})
+			}
+			sum.TopFiles[j].Findings++
+			if config.SeverityRank(severity) > config.SeverityRank(sum.TopFiles[j].Worst) {
+				sum.TopFiles[j].Worst = severity
+			}
+		}
+	}
+
+	sort.SliceStable(sum.ByCategory, func(i, j int) bool {
+		return sum.ByCategory[i].Count > sum.ByCategory[j].Count
+	})
+	sort.SliceStable(sum.TopFiles, func(i, j int) bool {
+		a, b := sum.TopFiles[i], sum.TopFiles[j]
+		if a.Findings != b.Findings {
+			return a.Findings > b.Findings
+		}
+		return config.SeverityRank(a.Worst) > config.SeverityRank(b.Worst)
+	})
+	if len(sum.TopFiles) > maxTopFiles {
+		sum.TopFiles = sum.TopFiles[:maxTopFiles]
+	}
+
+	sum.RiskScore = min(score, 100)
+	switch {
+	case sum.RiskScore >= 60:
+		sum.RiskLevel = "CRITICAL"
+	case sum.RiskScore >= 30:
+		sum.RiskLevel = "HIGH"
+	case sum.RiskScore >= 10:
+		sum.RiskLevel = "MEDIUM"
+	case sum.RiskScore > 0:
+		sum.RiskLevel = "LOW"
+	default:
+		sum.RiskLevel = "NONE"
+	}
+	return sum
+}
@@ -0,0 +1,246 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/audit"
+	"github.com/pefman/sidekick/internal/backup"
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// diffContext is the number of unchanged lines kept around each change in
+// a fix patch, which must still match for the fix to apply.
+const diffContext = 3
+
+// AutofixOptions selects the suggested fixes Autofix applies and where the
+// original files are kept.
+type AutofixOptions struct {
+	MinSeverity   string // Fix findings at or above this severity
+	MinConfidence string // ... and at or above this confidence; "" accepts any
+	// Branch, when set, is a new git branch the fixes are committed on
+	// instead of backing up the files. The working tree must be clean.
+	Branch string
+	// DryRun only works out the diff: no file is written, backed up,
+	// committed or logged.
+	DryRun bool
+}
+
+// Selects reports whether issue has a suggested fix that meets the
+// thresholds.
+func (o AutofixOptions) Selects(issue SecurityIssue) bool {
+	if !issue.FixAvailable || strings.TrimSpace(issue.SuggestedFix) == "" {
+		return false
+	}
+	if config.SeverityRank(issue.Severity) < config.SeverityRank(o.MinSeverity) {
+		return false
+	}
+	// Confidence uses the same LOW < MEDIUM < HIGH scale as severity
+	return o.MinConfidence == "" || config.SeverityRank(issue.Confidence) >= config.SeverityRank(o.MinConfidence)
+}
+
+// AppliedFix is a suggested fix Autofix applied.
+type AppliedFix struct {
+	File  string
+	Issue SecurityIssue
+}
+
+// AutofixReport describes what Autofix changed.
+type AutofixReport struct {
+	Applied  []AppliedFix
+	Failed   []string // Fixes that couldn't be applied, and why
+	Files    []string // Changed files
+	Diff     string   // Unified diff of every change
+	Backup   string   // Backup session holding the original files
+	Branch   string   // Branch the fixes were committed on
+	AuditLog string   // Where the applied fixes were logged
+}
+
+// fileEdit replaces lines start to end (1-based, inclusive; end is start-1
+// for an insertion) of a file.
+type fileEdit struct {
+	start, end int
+	lines      []string
+	fix        AppliedFix
+}
+
+// Autofix applies every suggested fix in results selected by opts. The
+// fixes to a file are combined into one patch against its content, which
+// is only written if every hunk's context matches; a fix overlapping a
+// more severe one is skipped. Each file is backed up (or, with a branch,
+// committed) and every applied fix is logged like a review-mode decision.
+func Autofix(results []ScanResult, opts AutofixOptions) (*AutofixReport, error) {
+	byFile := make(map[string][]SecurityIssue)
+	var files []string
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			if !opts.Selects(issue) {
+				continue
+			}
+			file := issue.File
+			if file == "" {
+				file = result.FilePath
+			}
+			if byFile[file] == nil {
+				files = append(files, file)
+			}
+			byFile[file] = append(byFile[file], issue)
+		}
+	}
+	sort.Strings(files)
+
+	rep := &AutofixReport{}
+	if len(files) == 0 {
+		return rep, nil
+	}
+	if SafeMode() && !opts.DryRun {
+		return nil, ErrSafeMode
+	}
+
+	var root string
+	if opts.Branch != "" && !opts.DryRun {
+		var err error
+		if root, err = createAutofixBranch(filepath.Dir(files[0]), opts.Branch); err != nil {
+			return nil, err
+		}
+		rep.Branch = opts.Branch
+	}
+
+	backups := backup.NewSession()
+	auditLog := audit.New(backups.ID)
+	var diff strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		// Writing a fix would silently convert the file to UTF-8
+		if _, encoding, err := decodeSource(content); err != nil || encoding != "" {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("%s: file is not UTF-8; apply the fixes by hand", file))
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+
+		// Most severe first, so they win overlaps
+		issues := byFile[file]
+		sort.SliceStable(issues, func(i, j int) bool {
+			return config.SeverityRank(issues[i].Severity) > config.SeverityRank(issues[j].Severity)
+		})
+		var edits []fileEdit
+		for _, issue := range issues {
+			issue = prepareFix(issue)
+			start, end, fixLines, ok := fixChange(lines, issue)
+			if !ok {
+				rep.Failed = append(rep.Failed, fmt.Sprintf("%s:%d: %s: the suggested fix doesn't change the code", file, issue.LineStart, issue.Title))
+				continue
+			}
+			// An insertion (end = start-1) still claims the line it goes before
+			overlaps := false
+			for _, e := range edits {
+				if start <= max(e.end, e.start) && max(end, start) >= e.start {
+					overlaps = true
+					break
+				}
+			}
+			if overlaps {
+				rep.Failed = append(rep.Failed, fmt.Sprintf("%s:%d: %s overlaps another fix", file, issue.LineStart, issue.Title))
+				continue
+			}
+			edits = append(edits, fileEdit{start: start, end: end, lines: fixLines, fix: AppliedFix{File: file, Issue: issue}})
+		}
+		if len(edits) == 0 {
+			continue
+		}
+		sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+		patch := editPatch(lines, edits)
+		fixed, err := patch.Apply(string(content))
+		if err != nil {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+
+		if opts.DryRun {
+			rep.Files = append(rep.Files, file)
+			diff.WriteString(unifiedDiff(displayPath(file), patch))
+			for _, e := range edits {
+				rep.Applied = append(rep.Applied, e.fix)
+			}
+			continue
+		}
+		if opts.Branch == "" {
+			if _, err := backups.Save(file, content); err != nil {
+				return rep, err
+			}
+			rep.Backup = backups.ID
+		}
+		if err := os.WriteFile(file, []byte(fixed), 0644); err != nil {
+			rep.Failed = append(rep.Failed, fmt.Sprintf("%s: failed to write file: %v", file, err))
+			continue
+		}
+		rep.Files = append(rep.Files, file)
+		diff.WriteString(unifiedDiff(displayPath(file), patch))
+
+		for _, e := range edits {
+			rep.Applied = append(rep.Applied, e.fix)
+			d := audit.Decision{
+				Decision:    audit.Applied,
+				Fingerprint: e.fix.Issue.Fingerprint(file),
+				File:        file,
+				LineStart:   e.fix.Issue.LineStart,
+				LineEnd:     e.fix.Issue.LineEnd,
+				Severity:    e.fix.Issue.Severity,
+				Title:       e.fix.Issue.Title,
+				IssueID:     e.fix.Issue.IssueID,
+				Backup:      rep.Backup,
+			}
+			if path, err := auditLog.Record(d); err == nil {
+				rep.AuditLog = path
+			}
+		}
+	}
+	rep.Diff = diff.String()
+
+	if opts.Branch != "" && !opts.DryRun && len(rep.Files) > 0 {
+		args := append([]string{"add", "--"}, rep.Files...)
+		if _, err := git(root, args...); err != nil {
+			return rep, fmt.Errorf("failed to stage fixes: %w", err)
+		}
+		msg := fmt.Sprintf("Apply %d suggested security fix(es) from sidekick", len(rep.Applied))
+		if _, err := git(root, "commit", "-q", "-m", msg); err != nil {
+			return rep, fmt.Errorf("failed to commit fixes on %s: %w", opts.Branch, err)
+		}
+	}
+	return rep, nil
+}
+
+// createAutofixBranch checks out a new branch for the fixes in the
+// repository of dir, returning the repository root. It refuses to when the
+// working tree has changes, which would end up mixed with the fixes.
+func createAutofixBranch(dir, branch string) (string, error) {
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	status, err := git(root, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(string(status))) > 0 {
+		return "", fmt.Errorf("%s has uncommitted changes; commit or stash them before fixing on a branch", root)
+	}
+	if _, err := git(root, "checkout", "-q", "-b", branch); err != nil {
+		return "", fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+	return root, nil
+}
+
+// unifiedDiff renders p, a patch of the file at path, as a unified diff.
+func unifiedDiff(path string, p Patch) string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	return fmt.Sprintf("--- a/%s\n+++ b/%s\n", path, path) + p.String()
+}
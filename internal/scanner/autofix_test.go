@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAutofixKeepsLinesAroundMultiLineFix(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "db.go")
+	src := strings.Join([]string{
+		"package db",
+		"",
+		"func list(db *sql.DB, id string) error {",
+		"\trows, _ := db.Query(\"SELECT * FROM users WHERE id = \" + id)",
+		"\tdefer rows.Close()",
+		"\tprocess(rows)",
+		"\treturn nil",
+		"}",
+		"",
+	}, "\n")
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []ScanResult{{
+		FilePath: file,
+		Issues: []SecurityIssue{{
+			Severity:     "CRITICAL",
+			Confidence:   "HIGH",
+			Title:        "SQL Injection",
+			LineStart:    4,
+			LineEnd:      4,
+			FixAvailable: true,
+			SuggestedFix: "rows, err := db.Query(\"SELECT * FROM users WHERE id = ?\", id)\nif err != nil {\n\treturn err\n}",
+		}},
+	}}
+	rep, err := Autofix(results, AutofixOptions{MinSeverity: "LOW"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Applied) != 1 || len(rep.Failed) != 0 {
+		t.Fatalf("applied %d fix(es), failed: %v", len(rep.Applied), rep.Failed)
+	}
+
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"package db",
+		"",
+		"func list(db *sql.DB, id string) error {",
+		"\trows, err := db.Query(\"SELECT * FROM users WHERE id = ?\", id)",
+		"\tif err != nil {",
+		"\t\treturn err",
+		"\t}",
+		"\tdefer rows.Close()",
+		"\tprocess(rows)",
+		"\treturn nil",
+		"}",
+		"",
+	}, "\n")
+	if string(got) != want {
+		t.Errorf("fixed file:\n%s\nwant:\n%s", got, want)
+	}
+}
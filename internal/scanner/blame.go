@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SetBlame enables git blame attribution of findings to the last author and commit.
+func (s *Scanner) SetBlame(enabled bool) {
+	s.blame = enabled
+}
+
+// annotateBlame fills Author and Commit for each issue from git blame of its
+// flagged lines. Files outside a git repository are left untouched.
+func annotateBlame(filePath string, issues []SecurityIssue) {
+	for i := range issues {
+		path := filePath
+		if issues[i].File != "" {
+			path = issues[i].File
+		}
+		author, commit, err := blameLines(path, issues[i].LineStart, issues[i].LineEnd)
+		if err != nil {
+			continue
+		}
+		issues[i].Author = author
+		issues[i].Commit = commit
+	}
+}
+
+// blameLines returns the author and short commit hash of the most recent
+// change to the given line range.
+func blameLines(filePath string, lineStart, lineEnd int) (string, string, error) {
+	if lineStart < 1 {
+		return "", "", fmt.Errorf("invalid line range")
+	}
+	if lineEnd < lineStart {
+		lineEnd = lineStart
+	}
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	cmd := exec.Command("git", "-C", filepath.Dir(absPath), "blame", "--porcelain",
+		"-L", fmt.Sprintf("%d,%d", lineStart, lineEnd), "--", filepath.Base(absPath))
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("git blame failed: %w", err)
+	}
+
+	var latestCommit, latestAuthor, commit, author string
+	var latestTime int64 = -1
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// Source line; the header for the next line follows
+			commit, author = "", ""
+		case commit == "":
+			fields := strings.Fields(line)
+			if len(fields) > 0 {
+				commit = fields[0]
+			}
+		case strings.HasPrefix(line, "author "):
+			author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			t, _ := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
+			if strings.Trim(commit, "0") != "" && t > latestTime {
+				latestTime = t
+				latestCommit = commit
+				latestAuthor = author
+			}
+		}
+	}
+
+	if latestCommit == "" {
+		return "", "", fmt.Errorf("no committed lines in range")
+	}
+	if len(latestCommit) > 12 {
+		latestCommit = latestCommit[:12]
+	}
+	return latestAuthor, latestCommit, nil
+}
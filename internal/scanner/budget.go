@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pefman/sidekick/internal/ollama"
+)
+
+// defaultTriadRounds is the number of attacker/defender/auditor rounds run
+// when no budget is set.
+const defaultTriadRounds = 3
+
+// triadBudget bounds the cost of a triad scan. Zero values mean no limit
+// (rounds default to defaultTriadRounds).
+type triadBudget struct {
+	maxRounds int
+	maxTokens int
+	timeout   time.Duration
+}
+
+// SetTriadBudget limits triad scans to at most maxRounds rounds, maxTokens
+// total tokens and the given wall-clock time. Once a limit is hit the scan
+// stops and reports the last complete auditor verdict. Zero disables a limit.
+func (s *Scanner) SetTriadBudget(maxRounds, maxTokens int, timeout time.Duration) {
+	s.triadBudget = triadBudget{maxRounds: maxRounds, maxTokens: maxTokens, timeout: timeout}
+}
+
+func (b triadBudget) rounds() int {
+	if b.maxRounds <= 0 {
+		return defaultTriadRounds
+	}
+	return b.maxRounds
+}
+
+// exceeded describes the first limit that has been reached, or returns "".
+func (b triadBudget) exceeded(used ollama.TokenUsage, started time.Time) string {
+	if b.maxTokens > 0 && used.Total() >= b.maxTokens {
+		return fmt.Sprintf("token budget of %d reached (%d used)", b.maxTokens, used.Total())
+	}
+	if b.timeout > 0 && time.Since(started) >= b.timeout {
+		return fmt.Sprintf("time budget of %s reached", b.timeout)
+	}
+	return ""
+}
+
+// usageSoFar returns the tokens attributed to filePath without clearing them.
+func (s *Scanner) usageSoFar(filePath string) ollama.TokenUsage {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	return s.fileUsage[filePath]
+}
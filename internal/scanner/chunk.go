@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+const (
+	// DefaultChunkSize is the chunk size in bytes when none is configured;
+	// files up to this size are sent whole.
+	DefaultChunkSize = maxFileSize
+	// DefaultChunkOverlap is the number of lines repeated between chunks
+	// when none is configured.
+	DefaultChunkOverlap = 20
+	// maxScanFileSize is the largest file scanned at all, chunked or not.
+	// Anything bigger is almost always generated, minified or data.
+	maxScanFileSize = 2 << 20
+)
+
+// chunk is a part of a file scanned on its own. Lines are numbered from 1
+// within the chunk; startLine is the original line number of its first line.
+type chunk struct {
+	startLine int
+	content   string
+}
+
+// SetChunking sets the chunk size in bytes and the overlap in lines used for
+// files larger than one chunk. Zero values use the defaults.
+func (s *Scanner) SetChunking(size, overlap int) {
+	s.chunkSize = size
+	s.chunkOverlap = overlap
+}
+
+func (s *Scanner) chunking() (size, overlap int) {
+	size, overlap = s.chunkSize, s.chunkOverlap
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if overlap <= 0 {
+		overlap = DefaultChunkOverlap
+	}
+	return size, overlap
+}
+
+// splitChunks splits content into chunks of at most size bytes, preferring
+// to cut where a new top-level function or section begins, with overlap
+// lines of the previous chunk repeated at the start of the next one so code
+// near a cut is seen whole at least once. Content within size is one chunk.
+func splitChunks(content string, size, overlap int) []chunk {
+	if len(content) <= size {
+		return []chunk{{startLine: 1, content: content}}
+	}
+
+	lines := strings.Split(content, "\n")
+	var chunks []chunk
+	start := 0
+	for start < len(lines) {
+		// Take whole lines up to size bytes, at least one
+		end, bytes := start, 0
+		for end < len(lines) && (end == start || bytes+len(lines[end])+1 <= size) {
+			bytes += len(lines[end]) + 1
+			end++
+		}
+
+		// Move the cut back to the last boundary in the second half
+		if end < len(lines) {
+			for i := end; i > start+(end-start)/2; i-- {
+				if isChunkBoundary(lines, i) {
+					end = i
+					break
+				}
+			}
+		}
+
+		chunks = append(chunks, chunk{startLine: start + 1, content: strings.Join(lines[start:end], "\n")})
+		if end >= len(lines) {
+			break
+		}
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// isChunkBoundary reports whether a new top-level declaration or section
+// starts at line i: an unindented line after a blank line or a closing
+// brace/end.
+func isChunkBoundary(lines []string, i int) bool {
+	if i <= 0 || i >= len(lines) {
+		return false
+	}
+	line := lines[i]
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '}' || line[0] == ')' {
+		return false
+	}
+	prev := strings.TrimRight(lines[i-1], " \t\r")
+	return prev == "" || prev == "}" || prev == "end"
+}
+
+// offsetIssues moves issues found in a chunk to original file line numbers.
+func offsetIssues(issues []SecurityIssue, c chunk) {
+	for i := range issues {
+		issues[i].LineStart += c.startLine - 1
+		issues[i].LineEnd += c.startLine - 1
+	}
+}
+
+// mergeChunkFindings deduplicates findings reported by more than one chunk
+// (in an overlap) and stitches together parts of one finding that span a
+// cut: findings with the same title and issue ID whose line ranges overlap
+// or touch become one finding covering both ranges.
+func mergeChunkFindings(issues []SecurityIssue) []SecurityIssue {
+	var merged []SecurityIssue
+	for _, issue := range issues {
+		if issue.LineEnd < issue.LineStart {
+			issue.LineEnd = issue.LineStart
+		}
+		dup := false
+		for i := range merged {
+			m := &merged[i]
+			if !sameChunkFinding(*m, issue) || issue.LineStart > m.LineEnd+1 || issue.LineEnd < m.LineStart-1 {
+				continue
+			}
+			if config.SeverityRank(issue.Severity) > config.SeverityRank(m.Severity) ||
+				(m.CVSSVector == "" && issue.CVSSVector != "") {
+				m.Severity, m.CVSSVector = issue.Severity, issue.CVSSVector
+			}
+			if !m.FixAvailable && issue.FixAvailable && issue.LineStart == m.LineStart && issue.LineEnd == m.LineEnd {
+				m.FixAvailable, m.SuggestedFix = true, issue.SuggestedFix
+			}
+			if issue.LineStart < m.LineStart || issue.LineEnd > m.LineEnd {
+				// A fix for part of the stitched range would replace the wrong lines
+				m.LineStart = minInt(m.LineStart, issue.LineStart)
+				m.LineEnd = maxInt(m.LineEnd, issue.LineEnd)
+				m.FixAvailable, m.SuggestedFix = false, ""
+			}
+			dup = true
+			break
+		}
+		if !dup {
+			merged = append(merged, issue)
+		}
+	}
+	return merged
+}
+
+func sameChunkFinding(a, b SecurityIssue) bool {
+	return strings.EqualFold(strings.TrimSpace(a.Title), strings.TrimSpace(b.Title)) &&
+		strings.EqualFold(strings.TrimSpace(a.IssueID), strings.TrimSpace(b.IssueID))
+}
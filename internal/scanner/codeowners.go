@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CodeOwners maps repository paths to owners using a CODEOWNERS file.
+type CodeOwners struct {
+	root  string
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeOwnersLocations are checked in the same order GitHub uses.
+var codeOwnersLocations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+}
+
+// LoadCodeOwners reads the CODEOWNERS file for the repository at root.
+// It returns nil without error when no CODEOWNERS file exists.
+func LoadCodeOwners(root string) (*CodeOwners, error) {
+	for _, loc := range codeOwnersLocations {
+		f, err := os.Open(filepath.Join(root, loc))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		defer f.Close()
+
+		co := &CodeOwners{root: root}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			re, err := codeOwnersPattern(fields[0])
+			if err != nil {
+				continue
+			}
+			co.rules = append(co.rules, codeOwnersRule{pattern: re, owners: fields[1:]})
+		}
+		return co, sc.Err()
+	}
+	return nil, nil
+}
+
+// OwnerOf returns the owners of a file as a space-separated list. As in
+// CODEOWNERS, the last matching rule takes precedence.
+func (c *CodeOwners) OwnerOf(filePath string) string {
+	if c == nil {
+		return ""
+	}
+
+	rel := filePath
+	if filepath.IsAbs(filePath) {
+		r, err := filepath.Rel(c.root, filePath)
+		if err != nil {
+			return ""
+		}
+		rel = r
+	}
+	rel = filepath.ToSlash(rel)
+
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(rel) {
+			return strings.Join(c.rules[i].owners, " ")
+		}
+	}
+	return ""
+}
+
+// codeOwnersPattern converts a gitignore-style CODEOWNERS pattern to a regexp.
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	prefix := "^"
+	if !anchored {
+		prefix = "(^|.*/)"
+	}
+	return regexp.Compile(prefix + expr.String() + "(/.*)?$")
+}
@@ -0,0 +1,37 @@
+package scanner
+
+// ModelComparison splits the findings two models reported on the same
+// code into those both found and those only one of them found.
+type ModelComparison struct {
+	Both  [][2]SecurityIssue // Matching findings, first model's then second's
+	OnlyA []SecurityIssue    // Reported by the first model only
+	OnlyB []SecurityIssue    // Reported by the second model only
+}
+
+// CompareFindings matches the findings of two models on the same code. Two
+// findings match when they share a CWE/OWASP ID (or title) at roughly the
+// same lines, as for ensemble scans; each finding matches at most once.
+func CompareFindings(a, b []SecurityIssue) ModelComparison {
+	var cmp ModelComparison
+	matched := make([]bool, len(b))
+	for _, issueA := range a {
+		found := false
+		for j, issueB := range b {
+			if !matched[j] && sameFinding(issueA, issueB) {
+				matched[j] = true
+				cmp.Both = append(cmp.Both, [2]SecurityIssue{issueA, issueB})
+				found = true
+				break
+			}
+		}
+		if !found {
+			cmp.OnlyA = append(cmp.OnlyA, issueA)
+		}
+	}
+	for j, issueB := range b {
+		if !matched[j] {
+			cmp.OnlyB = append(cmp.OnlyB, issueB)
+		}
+	}
+	return cmp
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/pefman/sidekick/internal/ollama"
+)
+
+// consistencyLineSlack is how far apart two samples may place the same finding.
+const consistencyLineSlack = 3
+
+// samplingTemperature replaces a deterministic temperature of 0 when
+// sampling, since identical samples would defeat the majority vote.
+const samplingTemperature = 0.7
+
+// sampleOptions derives per-sample generation options: a non-zero
+// temperature and a distinct seed per sample so runs stay reproducible.
+func sampleOptions(base *ollama.Options, sample int) *ollama.Options {
+	opts := ollama.Options{}
+	if base != nil {
+		opts = *base
+	}
+
+	temperature := samplingTemperature
+	if opts.Temperature != nil && *opts.Temperature > 0 {
+		temperature = *opts.Temperature
+	}
+	opts.Temperature = &temperature
+
+	if opts.Seed != nil {
+		seed := *opts.Seed + sample
+		opts.Seed = &seed
+	}
+	return &opts
+}
+
+// SetSamples sets how many times each file's security scan is sampled.
+// With more than one sample, only findings reported by a majority are kept.
+func (s *Scanner) SetSamples(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.samples = n
+}
+
+// majorityFindings clusters equivalent findings across samples and keeps
+// those that appear in more than half of them. The first sample's wording
+// is used for each kept finding.
+func majorityFindings(samples [][]SecurityIssue) []SecurityIssue {
+	type cluster struct {
+		issue   SecurityIssue
+		samples map[int]bool
+	}
+	var clusters []*cluster
+
+	for sampleIdx, issues := range samples {
+		for _, issue := range issues {
+			var match *cluster
+			for _, c := range clusters {
+				if sameFinding(c.issue, issue) {
+					match = c
+					break
+				}
+			}
+			if match == nil {
+				match = &cluster{issue: issue, samples: make(map[int]bool)}
+				clusters = append(clusters, match)
+			}
+			match.samples[sampleIdx] = true
+		}
+	}
+
+	kept := make([]SecurityIssue, 0)
+	for _, c := range clusters {
+		if len(c.samples)*2 > len(samples) {
+			kept = append(kept, c.issue)
+		}
+	}
+	return kept
+}
+
+// sameFinding reports whether two findings describe the same issue: same
+// CWE/OWASP ID (or title when IDs are missing) at roughly the same lines.
+func sameFinding(a, b SecurityIssue) bool {
+	if a.IssueID != "" && b.IssueID != "" {
+		if !strings.EqualFold(a.IssueID, b.IssueID) {
+			return false
+		}
+	} else if !strings.EqualFold(strings.TrimSpace(a.Title), strings.TrimSpace(b.Title)) {
+		return false
+	}
+
+	aEnd := maxInt(a.LineEnd, a.LineStart)
+	bEnd := maxInt(b.LineEnd, b.LineStart)
+	return a.LineStart <= bEnd+consistencyLineSlack && b.LineStart <= aEnd+consistencyLineSlack
+}
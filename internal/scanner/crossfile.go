@@ -0,0 +1,508 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProjectContext describes how the files of a scan connect: the functions
+// each defines, the calls between them and what they import. Security
+// scans look at one file at a time; the part of this context that concerns
+// a file is added to its Stage 2 prompt, so input that crosses a file
+// boundary can still be traced.
+type ProjectContext struct {
+	root    string                  // Common directory of the files, for shorter names
+	funcs   map[string][]*crossFunc // File -> functions it defines
+	imports map[string][]string     // File -> imported packages or modules
+	deps    map[string][]string     // File -> project files it imports (Python, JavaScript, TypeScript)
+	calls   []crossCall             // Calls from one file to a function defined in another (Go)
+}
+
+// crossFunc is a function or method defined in a scanned file.
+type crossFunc struct {
+	file      string
+	line      int
+	name      string
+	recv      string // Receiver type of a Go method
+	signature string
+	exported  bool
+}
+
+// crossCall is a call to a function defined in another file.
+type crossCall struct {
+	file   string // Calling file
+	line   int
+	caller string // Enclosing function
+	callee *crossFunc
+}
+
+// Limits that keep the cross-file section of a prompt short.
+const (
+	maxCrossFileEntries = 12
+	maxCrossFileBytes   = 4000
+)
+
+// BuildProjectContext analyzes files for cross-file context: Go files with
+// go/ast (signatures, imports and calls between files), Python, JavaScript
+// and TypeScript files by their imports and top-level function signatures.
+// Files that can't be read or parsed, or are too large to scan, are left
+// out.
+func BuildProjectContext(files []string) *ProjectContext {
+	pc := &ProjectContext{
+		root:    commonDir(files),
+		funcs:   make(map[string][]*crossFunc),
+		imports: make(map[string][]string),
+		deps:    make(map[string][]string),
+	}
+	scanned := make(map[string]bool, len(files))
+	for _, f := range files {
+		scanned[f] = true
+	}
+
+	var goFiles []*goFile
+	for _, f := range files {
+		if info, err := os.Stat(f); err != nil || info.Size() > maxFileSize {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(f)) {
+		case ".go":
+			if gf := pc.parseGo(f); gf != nil {
+				goFiles = append(goFiles, gf)
+			}
+		case ".py":
+			pc.scanScript(f, pyImport, pySignature, func(imp string) []string { return pyCandidates(f, pc.root, imp) }, scanned)
+		case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts":
+			pc.scanScript(f, jsImport, jsSignature, func(imp string) []string { return jsCandidates(f, imp) }, scanned)
+		}
+	}
+	pc.linkGoCalls(goFiles)
+	return pc
+}
+
+// goFile is a parsed Go file awaiting call resolution. Only its call sites
+// are kept, not its syntax tree.
+type goFile struct {
+	path    string
+	dir     string
+	imports map[string]string // Local name -> package directory, for project packages
+	calls   []goCallSite
+}
+
+// goCallSite is a call in a Go file that may be to another file: a plain
+// call, a call to a function of an imported project package (pkgDir set),
+// or a method call (method set).
+type goCallSite struct {
+	line   int
+	decl   int    // Index of the enclosing function among the file's declarations
+	caller string // Enclosing function
+	name   string // Function or method called
+	pkgDir string
+	method bool
+}
+
+// parseGo records the functions, imports and call sites of a Go file.
+func (pc *ProjectContext) parseGo(path string) *goFile {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	gf := &goFile{path: path, dir: filepath.Dir(path), imports: make(map[string]string)}
+
+	modRoot, modPath := goModule(gf.dir)
+	for _, imp := range file.Imports {
+		importPath := strings.Trim(imp.Path.Value, `"`)
+		pc.imports[path] = append(pc.imports[path], importPath)
+		if modPath == "" || (importPath != modPath && !strings.HasPrefix(importPath, modPath+"/")) {
+			continue
+		}
+		name := filepath.Base(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name != "_" && name != "." {
+			gf.imports[name] = filepath.Join(modRoot, strings.TrimPrefix(importPath, modPath))
+		}
+	}
+
+	for i, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		gf.calls = append(gf.calls, goCallSites(fset, fd, i, gf.imports)...)
+		fn := &crossFunc{
+			file:      path,
+			line:      fset.Position(fd.Pos()).Line,
+			name:      fd.Name.Name,
+			signature: goSignature(fset, fd),
+			exported:  fd.Name.IsExported(),
+		}
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			fn.recv = receiverType(fd.Recv.List[0].Type)
+		}
+		pc.funcs[path] = append(pc.funcs[path], fn)
+	}
+	return gf
+}
+
+// goCallSites returns the calls in the body of fd, the decl-th declaration
+// of its file, that may be to functions of other files.
+func goCallSites(fset *token.FileSet, fd *ast.FuncDecl, decl int, imports map[string]string) []goCallSite {
+	if fd.Body == nil {
+		return nil
+	}
+	var sites []goCallSite
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		site := goCallSite{line: fset.Position(call.Pos()).Line, decl: decl, caller: fd.Name.Name}
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			site.name = fun.Name
+		case *ast.SelectorExpr:
+			site.name = fun.Sel.Name
+			site.method = true
+			if x, ok := fun.X.(*ast.Ident); ok {
+				if dir, ok := imports[x.Name]; ok {
+					site.pkgDir, site.method = dir, false
+				}
+			}
+		default:
+			return true
+		}
+		sites = append(sites, site)
+		return true
+	})
+	return sites
+}
+
+// goSignature renders a function declaration without its body or doc.
+func goSignature(fset *token.FileSet, fd *ast.FuncDecl) string {
+	decl := *fd
+	decl.Body, decl.Doc = nil, nil
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, fset, &decl); err != nil {
+		return "func " + fd.Name.Name
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// receiverType returns the type name of a method receiver, e.g. "Server"
+// for (s *Server).
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// linkGoCalls finds calls from each Go file to functions defined in other
+// files: plain calls within a package, calls to functions of imported
+// project packages, and method calls whose name only one method in the
+// project has.
+func (pc *ProjectContext) linkGoCalls(goFiles []*goFile) {
+	byDir := make(map[string]map[string]*crossFunc) // Package directory -> function name -> function
+	methods := make(map[string][]*crossFunc)        // Method name -> methods
+	for _, gf := range goFiles {
+		for _, fn := range pc.funcs[gf.path] {
+			if fn.recv != "" {
+				methods[fn.name] = append(methods[fn.name], fn)
+				continue
+			}
+			if byDir[gf.dir] == nil {
+				byDir[gf.dir] = make(map[string]*crossFunc)
+			}
+			byDir[gf.dir][fn.name] = fn
+		}
+	}
+
+	for _, gf := range goFiles {
+		seen := make(map[*crossFunc]bool)
+		decl := -1
+		for _, site := range gf.calls {
+			// Each function lists a callee once
+			if site.decl != decl {
+				decl = site.decl
+				clear(seen)
+			}
+			var callee *crossFunc
+			switch {
+			case site.pkgDir != "":
+				callee = byDir[site.pkgDir][site.name]
+			case site.method:
+				if ms := methods[site.name]; len(ms) == 1 {
+					callee = ms[0]
+				}
+			default:
+				callee = byDir[gf.dir][site.name]
+			}
+			if callee == nil || callee.file == gf.path || seen[callee] {
+				continue
+			}
+			seen[callee] = true
+			pc.calls = append(pc.calls, crossCall{
+				file:   gf.path,
+				line:   site.line,
+				caller: site.caller,
+				callee: callee,
+			})
+		}
+	}
+}
+
+// goModule returns the root and module path of the Go module containing
+// dir, or "" when there is none.
+func goModule(dir string) (root, path string) {
+	for d := dir; ; {
+		if f, err := os.Open(filepath.Join(d, "go.mod")); err == nil {
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				if fields := strings.Fields(sc.Text()); len(fields) == 2 && fields[0] == "module" {
+					return d, strings.Trim(fields[1], `"`)
+				}
+			}
+			return d, ""
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", ""
+		}
+		d = parent
+	}
+}
+
+var (
+	pyImport    = regexp.MustCompile(`^\s*(?:from\s+(\.*[\w.]*)\s+import|import\s+([\w.]+))`)
+	pySignature = regexp.MustCompile(`^(?:async\s+)?def\s+([A-Za-z_]\w*)\s*(\([^)]*\)(?:\s*->\s*[^:]+)?)`)
+	jsImport    = regexp.MustCompile(`(?:^\s*import\s[^'"]*from\s*|^\s*import\s*|require\(\s*)['"]([^'"]+)['"]`)
+	jsSignature = regexp.MustCompile(`^export\s+(?:default\s+)?(?:async\s+)?(?:function\s*\*?\s*([A-Za-z_$][\w$]*)\s*(\([^)]*\))|const\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s*)?(\([^)]*\))\s*=>)`)
+)
+
+// scanScript records the imports and top-level function signatures of a
+// Python, JavaScript or TypeScript file, and the scanned files its imports
+// resolve to.
+func (pc *ProjectContext) scanScript(path string, importRe, signatureRe *regexp.Regexp, candidates func(string) []string, scanned map[string]bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		if m := importRe.FindStringSubmatch(line); m != nil {
+			imp := firstNonEmpty(m[1:]...)
+			pc.imports[path] = append(pc.imports[path], imp)
+			for _, c := range candidates(imp) {
+				if scanned[c] && c != path {
+					pc.deps[path] = append(pc.deps[path], c)
+					break
+				}
+			}
+			continue
+		}
+		if m := signatureRe.FindStringSubmatch(line); m != nil {
+			// Alternatives of the pattern capture a name and its parameters each
+			var name, params string
+			for i := 1; i+1 < len(m); i += 2 {
+				if m[i] != "" {
+					name, params = m[i], m[i+1]
+					break
+				}
+			}
+			pc.funcs[path] = append(pc.funcs[path], &crossFunc{
+				file:      path,
+				line:      i + 1,
+				name:      name,
+				signature: name + params,
+				exported:  !strings.HasPrefix(name, "_"),
+			})
+		}
+	}
+}
+
+// pyCandidates returns the files a Python import may refer to: relative to
+// the importing file for ".mod", else relative to its directory or any
+// parent up to root.
+func pyCandidates(from, root, imp string) []string {
+	dots := len(imp) - len(strings.TrimLeft(imp, "."))
+	rel := strings.ReplaceAll(strings.TrimLeft(imp, "."), ".", string(filepath.Separator))
+	var dirs []string
+	if dots > 0 {
+		dir := filepath.Dir(from)
+		for i := 1; i < dots; i++ {
+			dir = filepath.Dir(dir)
+		}
+		dirs = []string{dir}
+	} else {
+		for d := filepath.Dir(from); ; d = filepath.Dir(d) {
+			dirs = append(dirs, d)
+			if d == root || !strings.HasPrefix(d, root) || filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	var paths []string
+	for _, d := range dirs {
+		paths = append(paths, filepath.Join(d, rel+".py"), filepath.Join(d, rel, "__init__.py"))
+	}
+	return paths
+}
+
+// jsCandidates returns the files a relative JavaScript or TypeScript
+// import may refer to. Package imports don't refer to scanned files.
+func jsCandidates(from, imp string) []string {
+	if !strings.HasPrefix(imp, ".") {
+		return nil
+	}
+	base := filepath.Join(filepath.Dir(from), imp)
+	paths := []string{base}
+	for _, ext := range []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts"} {
+		paths = append(paths, base+ext, filepath.Join(base, "index"+ext))
+	}
+	return paths
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// commonDir returns the deepest directory containing every file.
+func commonDir(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(files[0])
+	for _, f := range files[1:] {
+		for dir != filepath.Dir(dir) && !strings.HasPrefix(f, dir+string(filepath.Separator)) {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// name returns path relative to the project root.
+func (pc *ProjectContext) name(path string) string {
+	if rel, err := filepath.Rel(pc.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// For returns the cross-file context of filePath for its scan prompt, or
+// "" when it has none: its imports, the functions it calls in other files,
+// the other files calling into it, and the project files it imports or is
+// imported by, with their exported function signatures.
+func (pc *ProjectContext) For(filePath string) string {
+	if pc == nil {
+		return ""
+	}
+	var sections []string
+	add := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if len(lines) > maxCrossFileEntries {
+			lines = append(lines[:maxCrossFileEntries], fmt.Sprintf("- ... and %d more", len(lines)-maxCrossFileEntries))
+		}
+		sections = append(sections, title+"\n"+strings.Join(lines, "\n"))
+	}
+
+	if imports := pc.imports[filePath]; len(imports) > 0 {
+		sections = append(sections, "Imports: "+strings.Join(imports, ", "))
+	}
+
+	var callsOut, callsIn []string
+	for _, c := range pc.calls {
+		switch {
+		case c.file == filePath:
+			callsOut = append(callsOut, fmt.Sprintf("- line %d (in %s) calls %s, defined in %s:%d", c.line, c.caller, c.callee.signature, pc.name(c.callee.file), c.callee.line))
+		case c.callee.file == filePath:
+			callsIn = append(callsIn, fmt.Sprintf("- %s:%d (in %s) calls %s, defined at line %d", pc.name(c.file), c.line, c.caller, c.callee.name, c.callee.line))
+		}
+	}
+	add("Calls into other files (arguments passed here may come from untrusted input):", callsOut)
+	add("Called from other files (check what callers pass in):", callsIn)
+
+	var importsFrom, importedBy []string
+	for _, dep := range pc.deps[filePath] {
+		importsFrom = append(importsFrom, fmt.Sprintf("- %s: %s", pc.name(dep), pc.exportedSignatures(dep)))
+	}
+	for file, deps := range pc.deps {
+		for _, dep := range deps {
+			if dep == filePath {
+				importedBy = append(importedBy, "- "+pc.name(file))
+				break
+			}
+		}
+	}
+	sort.Strings(importedBy)
+	add("Project files it imports, with their functions:", importsFrom)
+	add("Imported by:", importedBy)
+
+	if len(sections) == 0 {
+		return ""
+	}
+	text := strings.Join(sections, "\n")
+	if len(text) > maxCrossFileBytes {
+		text = text[:strings.LastIndex(text[:maxCrossFileBytes], "\n")] + "\n- ... (truncated)"
+	}
+	return text
+}
+
+// exportedSignatures lists the exported functions of a file, or "no
+// functions".
+func (pc *ProjectContext) exportedSignatures(file string) string {
+	var sigs []string
+	for _, fn := range pc.funcs[file] {
+		if fn.exported {
+			sigs = append(sigs, fn.signature)
+		}
+	}
+	if len(sigs) == 0 {
+		return "no functions"
+	}
+	if len(sigs) > maxCrossFileEntries {
+		sigs = append(sigs[:maxCrossFileEntries], "...")
+	}
+	return strings.Join(sigs, "; ")
+}
+
+// SetProjectContext adds the cross-file context of each file to its Stage 2
+// security scan prompt. nil, the default, leaves it out.
+func (s *Scanner) SetProjectContext(pc *ProjectContext) {
+	s.projectContext = pc
+}
+
+// crossFileSection returns the cross-file context block of a scan prompt,
+// ending in a blank line, or "" when the file has none.
+func (s *Scanner) crossFileSection(filePath string) string {
+	text := s.projectContext.For(filePath)
+	if text == "" {
+		return ""
+	}
+	return "CROSS-FILE CONTEXT (how this file connects to the rest of the project; input can cross these boundaries):\n" + text + "\n\n"
+}
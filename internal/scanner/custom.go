@@ -1,49 +1,208 @@
 package scanner
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/pefman/sidekick/internal/prompts"
+	"github.com/pefman/sidekick/internal/ui"
 )
 
+// customPromptSpec is a parsed custom prompt. The raw prompt may start with
+// "MODE: ask|edit|plan" and "FIELDS: a, b, c" header lines.
+type customPromptSpec struct {
+	mode   string
+	fields []string // When set, the answer is requested as JSON rows with these fields
+	body   string
+}
+
+// Table is a structured custom-prompt answer: one row per item the model
+// reported, with a column per requested field.
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// maxTableCell bounds the width of a column in text output.
+const maxTableCell = 48
+
 func (s *Scanner) createCustomPrompt(filename, content string) string {
-	mode, userPrompt := parseCustomPrompt(s.customPrompt)
+	spec := parseCustomPrompt(s.customPrompt)
 
 	result, err := prompts.RenderCustomPrompt(prompts.CustomPromptData{
-		Mode:       mode,
-		UserPrompt: userPrompt,
+		Mode:       spec.mode,
+		UserPrompt: prompts.ExpandVars(spec.body, s.orgContext),
 		FilePath:   filename,
 		Code:       content,
+		Model:      s.modelName,
+		Context:    prompts.OrgContext(s.orgContext),
 	})
 	if err != nil {
-		return fmt.Sprintf("%s\n\nFILE: %s\nCODE:\n%s\n", s.customPrompt, filename, content)
+		result = fmt.Sprintf("%s%s\n\nFILE: %s\nCODE:\n%s\n", prompts.OrgContext(s.orgContext), spec.body, filename, content)
 	}
 
+	if len(spec.fields) > 0 {
+		result += structuredAnswerInstructions(spec.fields, s.modelName)
+	}
 	return result
 }
 
-func parseCustomPrompt(raw string) (string, string) {
-	trimmed := strings.TrimSpace(raw)
-	if trimmed == "" {
-		return "ask", ""
+// CustomPrompt builds a raw custom prompt with optional MODE and FIELDS
+// headers, as understood by the custom scan type.
+func CustomPrompt(mode string, fields []string, prompt string) string {
+	var b strings.Builder
+	if mode != "" {
+		fmt.Fprintf(&b, "MODE: %s\n", strings.ToUpper(mode))
+	}
+	if len(fields) > 0 {
+		fmt.Fprintf(&b, "FIELDS: %s\n", strings.Join(fields, ", "))
 	}
+	b.WriteString(prompt)
+	return b.String()
+}
+
+func parseCustomPrompt(raw string) customPromptSpec {
+	spec := customPromptSpec{mode: "ask"}
+	rest := strings.TrimSpace(raw)
+
+	for rest != "" {
+		lines := strings.SplitN(rest, "\n", 2)
+		head := strings.TrimSpace(lines[0])
+		upper := strings.ToUpper(head)
 
-	lines := strings.SplitN(trimmed, "\n", 2)
-	head := strings.TrimSpace(lines[0])
-	if strings.HasPrefix(strings.ToUpper(head), "MODE:") {
-		mode := strings.ToLower(strings.TrimSpace(head[len("MODE:"):]))
-		body := ""
+		switch {
+		case strings.HasPrefix(upper, "MODE:"):
+			mode := strings.ToLower(strings.TrimSpace(head[len("MODE:"):]))
+			if mode == "ask" || mode == "edit" || mode == "plan" {
+				spec.mode = mode
+			}
+		case strings.HasPrefix(upper, "FIELDS:"):
+			spec.fields = parseFields(head[len("FIELDS:"):])
+		default:
+			spec.body = rest
+			return spec
+		}
+
+		rest = ""
 		if len(lines) > 1 {
-			body = strings.TrimSpace(lines[1])
+			rest = strings.TrimSpace(lines[1])
 		}
-		if mode == "ask" || mode == "edit" || mode == "plan" {
-			return mode, body
+	}
+	return spec
+}
+
+// parseFields splits a comma-separated field list, dropping blanks and duplicates.
+func parseFields(list string) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[strings.ToLower(f)] {
+			continue
 		}
-		return "ask", body
+		seen[strings.ToLower(f)] = true
+		fields = append(fields, f)
 	}
+	return fields
+}
+
+// structuredAnswerInstructions asks for the answer as JSON rows.
+func structuredAnswerInstructions(fields []string, model string) string {
+	example := make([]string, len(fields))
+	for i, f := range fields {
+		example[i] = fmt.Sprintf("%q: \"...\"", f)
+	}
+	return fmt.Sprintf(`
+RESPONSE FORMAT:
+Answer as JSON with one row per item: {"rows": [{%s}]}
+- Use exactly these fields in every row: %s
+- Every value is a string; use "" when a field does not apply
+- If nothing applies to this file, return {"rows": []}
+
+%s
+`, strings.Join(example, ", "), strings.Join(fields, ", "), prompts.JSONInstructions(model))
+}
 
-	return "ask", trimmed
+// parseTable reads a structured answer into a table with the requested
+// columns. Field names are matched case-insensitively; non-string values
+// are kept as their JSON text.
+func parseTable(response string, fields []string) (*Table, error) {
+	var answer struct {
+		Rows []map[string]interface{} `json:"rows"`
+	}
+	if err := decodeModelJSON(response, &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse structured answer: %w", err)
+	}
+
+	table := &Table{Columns: fields, Rows: make([][]string, 0, len(answer.Rows))}
+	for _, row := range answer.Rows {
+		byName := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			byName[strings.ToLower(k)] = v
+		}
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			switch v := byName[strings.ToLower(f)].(type) {
+			case nil:
+			case string:
+				cells[i] = v
+			default:
+				data, _ := json.Marshal(v)
+				cells[i] = string(data)
+			}
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	return table, nil
+}
+
+// renderTable formats a table as aligned text columns.
+func renderTable(t *Table) string {
+	widths := make([]int, len(t.Columns))
+	cell := func(s string) string {
+		s = strings.Join(strings.Fields(s), " ")
+		return ui.Truncate(s, maxTableCell)
+	}
+
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = cell(strings.ToUpper(c))
+		widths[i] = ui.Width(header[i])
+	}
+	rows := make([][]string, len(t.Rows))
+	for r, row := range t.Rows {
+		rows[r] = make([]string, len(row))
+		for i, v := range row {
+			rows[r][i] = cell(v)
+			widths[i] = maxInt(widths[i], ui.Width(rows[r][i]))
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for i, c := range cells {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(c)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-ui.Width(c)))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(header)
+	rule := make([]string, len(widths))
+	for i, w := range widths {
+		rule[i] = strings.Repeat("-", w)
+	}
+	writeRow(rule)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // For custom prompts, we still parse as issues for now
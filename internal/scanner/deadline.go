@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStopped is returned by ScanFiles when Stop ended the scan early.
+var ErrStopped = errors.New("scan stopped")
+
+// SetFileTimeout bounds how long each file may take, from its first model
+// call to its last; time spent queued doesn't count. Files that run over
+// fail with a timeout; zero means no limit.
+func (s *Scanner) SetFileTimeout(d time.Duration) {
+	s.fileTimeout = d
+}
+
+// Stop ends the scan gracefully: files not yet started are left out, while
+// the files in flight finish. Later ScanFiles calls start no files. Batch
+// engines, which scan all files at once, only stop when their context is
+// cancelled.
+func (s *Scanner) Stop() {
+	s.stopped.Store(true)
+}
+
+// contextFor returns the context for model calls made for filePath: the
+// file's own deadline while it is in flight, otherwise that of the scan.
+func (s *Scanner) contextFor(filePath string) context.Context {
+	s.ctxMu.Lock()
+	ctx, ok := s.fileCtx[filePath]
+	s.ctxMu.Unlock()
+	if ok {
+		return ctx
+	}
+	return s.scanContext()
+}
+
+// scanContext returns the context of the running scan.
+func (s *Scanner) scanContext() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+// startFile gives filePath its own deadline, derived from the scan's
+// context. The returned function releases it once the file is done.
+func (s *Scanner) startFile(filePath string) func() {
+	ctx, cancel := s.contextFor(filePath), context.CancelFunc(func() {})
+	if s.fileTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.fileTimeout)
+	}
+	s.ctxMu.Lock()
+	s.fileCtx[filePath] = ctx
+	s.ctxMu.Unlock()
+	return func() {
+		s.ctxMu.Lock()
+		delete(s.fileCtx, filePath)
+		s.ctxMu.Unlock()
+		cancel()
+	}
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// dedupFiles groups files with identical content, such as vendored copies
+// and generated duplicates, so each content is scanned once. It returns the
+// files to scan, in their original order, and for each of them the other
+// paths sharing its content. Files are only grouped when everything else
+// that shapes their findings matches too: whether their name marks them
+// generated, the policy minimum severity, the path-scoped severity
+// overrides and, with --diff, the changed lines. The keys are kept for the
+// result cache, so files are hashed once.
+func (s *Scanner) dedupFiles(files []string) (unique []string, dups map[string][]string) {
+	dups = make(map[string][]string)
+	s.dedupKeys = make(map[string]string, len(files))
+	first := make(map[string]string) // Key -> first path with it
+	for _, filePath := range files {
+		key, ok := s.dedupKey(filePath)
+		if !ok {
+			unique = append(unique, filePath)
+			continue
+		}
+		s.dedupKeys[filePath] = key
+		if rep, seen := first[key]; seen {
+			dups[rep] = append(dups[rep], filePath)
+			continue
+		}
+		first[key] = filePath
+		unique = append(unique, filePath)
+	}
+	return unique, dups
+}
+
+// dedupKey returns the content hash of filePath combined with its
+// path-dependent scan settings. ok is false for files that are scanned on
+// their own: empty, too large or unreadable ones, which readScanFile
+// reports.
+func (s *Scanner) dedupKey(filePath string) (string, bool) {
+	info, err := os.Stat(filePath)
+	if err != nil || info.Size() == 0 || info.Size() > maxScanFileSize {
+		return "", false
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", false
+	}
+
+	var key strings.Builder
+	fmt.Fprintf(&key, "%x", sha256.Sum256(content))
+	// Generated files are skipped by name as well as by content; quarantine
+	// depends on the content alone
+	if reason := GeneratedName(filePath); reason != "" {
+		key.WriteString("|generated=" + reason)
+	}
+	if p := config.PolicyFor(s.policies, s.policyRoot, filePath); p != nil {
+		key.WriteString("|" + p.MinSeverity)
+	}
+	for i, rule := range s.severityOverrides {
+		if rule.Path == "" {
+			continue
+		}
+		baseMatch, _ := filepath.Match(rule.Path, filepath.Base(filePath))
+		fullMatch, _ := filepath.Match(rule.Path, filePath)
+		if baseMatch || fullMatch {
+			fmt.Fprintf(&key, "|o%d", i)
+		}
+	}
+	if s.diff != nil {
+		ranges, _ := s.diff.Changed(filePath)
+		fmt.Fprintf(&key, "|%v", ranges)
+	}
+	return key.String(), true
+}
+
+// expandDuplicates adds a result for every duplicate of a scanned file,
+// carrying its findings with the ownership and blame of the duplicate's own
+// path. Token usage stays with the scanned file.
+func (s *Scanner) expandDuplicates(results []ScanResult, dups map[string][]string) []ScanResult {
+	if len(dups) == 0 {
+		return results
+	}
+	expanded := make([]ScanResult, 0, len(results))
+	for _, result := range results {
+		expanded = append(expanded, result)
+		for _, dup := range dups[result.FilePath] {
+			copied := s.relabel(result, dup)
+			copied.DuplicateOf = result.FilePath
+			expanded = append(expanded, copied)
+		}
+	}
+	return expanded
+}
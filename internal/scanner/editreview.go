@@ -0,0 +1,263 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/audit"
+	"github.com/pefman/sidekick/internal/backup"
+)
+
+// editItem is the change an edit-mode answer proposes for one file.
+type editItem struct {
+	file  string
+	patch Patch
+}
+
+// ReviewEdits goes through the changes proposed by an edit-mode custom
+// prompt, one file at a time, with the keys and safeguards of review mode:
+// each change is shown as a diff and only written when applied, after the
+// file is backed up. Changes whose context no longer matches the file are
+// refused rather than written.
+func ReviewEdits(results []ScanResult) error {
+	var items []editItem
+	for _, result := range results {
+		if result.RawFindings == "" {
+			continue
+		}
+		// The model sees one file per request, so its diff is for that file;
+		// hunks naming another file are dropped
+		var patch Patch
+		for _, p := range ParsePatches(result.RawFindings) {
+			if p.Path != "" && !samePatchFile(result.FilePath, p.Path) {
+				fmt.Printf("⚠️  %s: ignoring a change proposed for %s\n", displayPath(result.FilePath), p.Path)
+				continue
+			}
+			patch.Hunks = append(patch.Hunks, p.Hunks...)
+		}
+		if len(patch.Hunks) > 0 {
+			patch.Path = result.FilePath
+			items = append(items, editItem{file: result.FilePath, patch: patch})
+		}
+	}
+	if len(items) == 0 {
+		fmt.Println("No proposed changes to review.")
+		return nil
+	}
+	if SafeMode() {
+		return ErrSafeMode
+	}
+
+	keys := reviewKeymap()
+	input := newReviewInput(keys)
+	defer input.Close()
+	pause := input.pause
+	backups := backup.NewSession()
+	auditLog := audit.New(backups.ID)
+	auditPath := ""
+	backedUp := make(map[string]string)
+	decisions := make(map[int]string)
+	currentIdx := 0
+
+	// Back up each file before its first change
+	backupFile := func(filePath string, content []byte) bool {
+		if backedUp[filePath] != "" {
+			return true
+		}
+		backupPath, err := backups.Save(filePath, content)
+		if err != nil {
+			fmt.Printf("\n\033[38;5;203m✗ Failed to create backup: %v\033[0m\n", err)
+			pause()
+			return false
+		}
+		backedUp[filePath] = backupPath
+		return true
+	}
+
+	record := func(idx int, decision string) {
+		decisions[idx] = decision
+		item := items[idx]
+		start, end := item.patch.Span()
+		d := audit.Decision{
+			Decision:  decision,
+			File:      item.file,
+			LineStart: start,
+			LineEnd:   end,
+			Title:     "Edit-mode change",
+		}
+		if backedUp[item.file] != "" {
+			d.Backup = backups.ID
+		}
+		path, err := auditLog.Record(d)
+		if err != nil {
+			fmt.Printf("\n\033[38;5;203m⚠ %v\033[0m\n", err)
+			return
+		}
+		auditPath = path
+	}
+	defer func() {
+		if auditPath != "" {
+			fmt.Printf("📝 Review decisions logged to %s\n", auditPath)
+		}
+	}()
+
+	// advance moves to the next change, reporting whether there is one
+	advance := func() bool {
+		if currentIdx < len(items)-1 {
+			currentIdx++
+			return true
+		}
+		fmt.Println("\n\033[38;5;82mAll changes reviewed!\033[0m")
+		return false
+	}
+
+	for {
+		item := items[currentIdx]
+		content, err := os.ReadFile(item.file)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		done := decisions[currentIdx] == audit.Applied || decisions[currentIdx] == audit.Edited
+
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("\n\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m\n")
+		fmt.Printf("\033[38;5;208m✏️  Edit Review\033[0m - Change %d of %d\n", currentIdx+1, len(items))
+		fmt.Printf("\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m\n\n")
+		start, end := item.patch.Span()
+		fmt.Printf("📁 File: \033[36m%s\033[0m\n", displayPath(item.file))
+		fmt.Printf("📍 Lines: \033[36m%d-%d\033[0m | %d hunk(s)\n", start, end, len(item.patch.Hunks))
+		showPatch(item.patch.String())
+
+		switch decisions[currentIdx] {
+		case audit.Applied:
+			fmt.Printf("\n\033[38;5;82m✓ Change applied\033[0m\n")
+		case audit.Edited:
+			fmt.Printf("\n\033[38;5;82m✓ Edited by hand\033[0m\n")
+		case audit.Ignored:
+			fmt.Printf("\n\033[38;5;203m✗ Skipped\033[0m\n")
+		}
+
+		fmt.Printf("\n\033[38;5;208m━━━ Actions ━━━\033[0m\n")
+		if !done {
+			fmt.Printf("  [%s] Apply change\n", keys.Apply[0])
+			fmt.Printf("  [%s] Edit the file yourself\n", keys.Edit[0])
+		}
+		fmt.Printf("  [%s] Skip this change\n", keys.Ignore[0])
+		if currentIdx < len(items)-1 {
+			fmt.Printf("  [%s/→] Next change\n", keys.Next[0])
+		}
+		if currentIdx > 0 {
+			fmt.Printf("  [%s/←] Previous change\n", keys.Previous[0])
+		}
+		fmt.Printf("  [%s] Quit\n", keys.Quit[0])
+		if len(items) > 1 {
+			fmt.Printf("  [1-%d] Jump to change (then Enter)\n", len(items))
+		}
+		fmt.Printf("\n\033[38;5;208mChoice:\033[0m ")
+
+		choice, jump, err := input.command()
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+
+		switch choice {
+		case "a":
+			if done {
+				fmt.Println("\n\033[38;5;203m⚠ Change already applied\033[0m")
+				pause()
+				continue
+			}
+			if _, err := item.patch.Apply(string(content)); err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ Cannot apply: %v; edit the file yourself instead\033[0m\n", err)
+				pause()
+				continue
+			}
+			if !backupFile(item.file, content) {
+				continue
+			}
+			if err := applyPatch(item.file, item.patch); err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ Failed to apply change: %v\033[0m\n", err)
+				pause()
+				continue
+			}
+			record(currentIdx, audit.Applied)
+			fmt.Printf("\n\033[38;5;82m✓ Change applied (backup: %s, undo with: sidekick restore %s)\033[0m\n", backedUp[item.file], backups.ID)
+			if !advance() {
+				return nil
+			}
+			time.Sleep(800 * time.Millisecond)
+
+		case "e":
+			if done {
+				fmt.Println("\n\033[38;5;203m⚠ Change already applied\033[0m")
+				pause()
+				continue
+			}
+			if !backupFile(item.file, content) {
+				continue
+			}
+			input.suspend()
+			err := openEditor(item.file, max(start, 1))
+			input.resume()
+			if err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ %v\033[0m\n", err)
+				pause()
+				continue
+			}
+			if edited, err := os.ReadFile(item.file); err == nil && string(edited) != string(content) {
+				record(currentIdx, audit.Edited)
+			}
+
+		case "i":
+			record(currentIdx, audit.Ignored)
+			if !advance() {
+				return nil
+			}
+
+		case "n":
+			if currentIdx < len(items)-1 {
+				currentIdx++
+			} else {
+				fmt.Println("\nAlready at last change")
+				pause()
+			}
+
+		case "p":
+			if currentIdx > 0 {
+				currentIdx--
+			} else {
+				fmt.Println("\nAlready at first change")
+				pause()
+			}
+
+		case "g":
+			if jump < 1 || jump > len(items) {
+				fmt.Printf("\n\033[38;5;203m⚠ No change %d (1-%d)\033[0m\n", jump, len(items))
+				pause()
+				continue
+			}
+			currentIdx = jump - 1
+
+		case "q":
+			fmt.Println("\n\033[38;5;208m👋 Exiting edit review\033[0m")
+			return nil
+
+		default:
+			fmt.Println("\n\033[38;5;203m⚠ Invalid choice\033[0m")
+			pause()
+		}
+	}
+}
+
+// samePatchFile reports whether the path in a patch header names file: the
+// model may give it relative to anywhere, so only trailing path elements
+// are compared.
+func samePatchFile(file, patchPath string) bool {
+	file = filepath.ToSlash(file)
+	patchPath = strings.TrimPrefix(filepath.ToSlash(patchPath), "./")
+	return file == patchPath || strings.HasSuffix(file, "/"+patchPath) || filepath.Base(file) == filepath.Base(patchPath)
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxControlRatio is the share of control characters above which a file
+// that isn't UTF-8 is taken for binary data rather than legacy-encoded text.
+const maxControlRatio = 0.01
+
+// windows1252 maps the bytes 0x80-0x9F, which are control characters in
+// ISO-8859-1, to the punctuation Windows-1252 puts there. Unassigned bytes
+// map to U+FFFD.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// decodeSource returns content as UTF-8 for the model. UTF-8 is returned
+// as is, without a byte order mark; UTF-16 with a byte order mark and
+// single-byte legacy text (Latin-1/Windows-1252) are transcoded, and
+// encoding names what was transcoded from. Content that is neither, such as
+// UTF-16 without a byte order mark or binary data, is an error: sending it
+// would only produce nonsense findings.
+func decodeSource(content []byte) (text []byte, encoding string, err error) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return content[3:], "", nil
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return decodeUTF16(content[2:], binary.LittleEndian), "UTF-16LE", nil
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return decodeUTF16(content[2:], binary.BigEndian), "UTF-16BE", nil
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, "", fmt.Errorf("binary data or UTF-16 without a byte order mark")
+	}
+	if utf8.Valid(content) {
+		return content, "", nil
+	}
+
+	controls := 0
+	var b bytes.Buffer
+	b.Grow(len(content) + len(content)/8)
+	for _, c := range content {
+		switch {
+		case c >= 0x80 && c <= 0x9F:
+			b.WriteRune(windows1252[c-0x80])
+		case c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f':
+			controls++
+			b.WriteByte(c)
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	if float64(controls) > maxControlRatio*float64(len(content)) {
+		return nil, "", fmt.Errorf("not UTF-8 and not legacy text")
+	}
+	return b.Bytes(), "Windows-1252", nil
+}
+
+// decodeUTF16 transcodes UTF-16 in the given byte order to UTF-8. A
+// trailing odd byte is dropped.
+func decodeUTF16(content []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[2*i:])
+	}
+	var b bytes.Buffer
+	b.Grow(len(content))
+	for _, r := range utf16.Decode(units) {
+		b.WriteRune(r)
+	}
+	return b.Bytes()
+}
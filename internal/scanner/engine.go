@@ -0,0 +1,171 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Engine is an analysis engine selected by scan type (e.g. "security").
+// An engine implements either FileEngine, to be run on each file by the
+// shared worker pool, or BatchEngine, to analyze the whole file set at once.
+type Engine interface {
+	Name() string
+	Description() string
+}
+
+// FileEngine analyzes one file at a time.
+type FileEngine interface {
+	Engine
+	// Stages is the number of progress stages per file, including reading it.
+	Stages() int
+	// ScanFile analyzes a file whose content has already been read.
+	ScanFile(s *Scanner, filePath string, content []byte, progress *Progress) (ScanResult, error)
+}
+
+// BatchEngine analyzes all files together.
+type BatchEngine interface {
+	Engine
+	ScanAll(s *Scanner, files []string) ([]ScanResult, error)
+}
+
+// OfflineEngine is implemented by engines that never call the model, so
+// scans of only their type need neither Ollama nor a model.
+type OfflineEngine interface {
+	Engine
+	Offline()
+}
+
+// UsesModel reports whether scans of scanType call the model. Unknown scan
+// types are assumed to.
+func UsesModel(scanType string) bool {
+	e, ok := LookupEngine(scanType)
+	if !ok {
+		return true
+	}
+	_, offline := e.(OfflineEngine)
+	return !offline
+}
+
+var (
+	enginesMu sync.RWMutex
+	engines   = make(map[string]Engine)
+)
+
+// RegisterEngine makes an engine available as a scan type. Registering the
+// same name twice panics.
+func RegisterEngine(e Engine) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	if _, dup := engines[e.Name()]; dup {
+		panic(fmt.Sprintf("scanner: engine %q registered twice", e.Name()))
+	}
+	engines[e.Name()] = e
+}
+
+// LookupEngine returns the engine registered for a scan type.
+func LookupEngine(name string) (Engine, bool) {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	e, ok := engines[name]
+	return e, ok
+}
+
+// Engines returns every registered engine, sorted by name.
+func Engines() []Engine {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	list := make([]Engine, 0, len(engines))
+	for _, e := range engines {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// EngineNames returns the registered scan types, sorted.
+func EngineNames() []string {
+	var names []string
+	for _, e := range Engines() {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// engine resolves the scanner's scan type to its engine.
+func (s *Scanner) engine() (Engine, error) {
+	e, ok := LookupEngine(s.scanType)
+	if !ok {
+		return nil, fmt.Errorf("unknown scan type %q (available: %s)", s.scanType, strings.Join(EngineNames(), ", "))
+	}
+	return e, nil
+}
+
+// Progress reports per-file stage progress as "[stage/total] message".
+type Progress struct {
+	stage  int
+	total  int
+	update func(string)
+}
+
+// Stage advances to the next stage and reports it.
+func (p *Progress) Stage(format string, args ...interface{}) {
+	p.stage++
+	p.Status(fmt.Sprintf(format, args...))
+}
+
+// Status reports a message for the current stage.
+func (p *Progress) Status(msg string) {
+	p.update(fmt.Sprintf("[%d/%d] %s", p.stage, p.total, msg))
+}
+
+type securityEngine struct{}
+
+func (securityEngine) Name() string { return "security" }
+func (securityEngine) Description() string {
+	return "Two-stage LLM scan: context analysis, then targeted vulnerability search"
+}
+func (securityEngine) Stages() int { return 3 }
+func (securityEngine) ScanFile(s *Scanner, filePath string, content []byte, progress *Progress) (ScanResult, error) {
+	return s.securityScanFile(filePath, content, progress)
+}
+func (securityEngine) Steps() []PipelineStep {
+	return []PipelineStep{
+		func(s *Scanner, filePath string, content []byte, _ interface{}, progress *Progress) (interface{}, error) {
+			return s.securityContext(filePath, content, progress)
+		},
+		func(s *Scanner, filePath string, content []byte, state interface{}, progress *Progress) (interface{}, error) {
+			return s.securityFindings(filePath, content, state.(*securityContextResult), progress)
+		},
+	}
+}
+
+type customEngine struct{}
+
+func (customEngine) Name() string        { return "custom" }
+func (customEngine) Description() string { return "Run a custom prompt against each file" }
+func (customEngine) Stages() int         { return 2 }
+func (customEngine) ScanFile(s *Scanner, filePath string, content []byte, progress *Progress) (ScanResult, error) {
+	return s.customScanFile(filePath, content, progress)
+}
+
+type triadEngine struct{}
+
+func (triadEngine) Name() string { return "triad" }
+func (triadEngine) Description() string {
+	return "Attacker/defender/auditor debate across all files, seeded by static analysis"
+}
+func (triadEngine) ScanAll(s *Scanner, files []string) ([]ScanResult, error) {
+	result, err := s.scanTriadFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return []ScanResult{result}, nil
+}
+
+func init() {
+	RegisterEngine(securityEngine{})
+	RegisterEngine(customEngine{})
+	RegisterEngine(triadEngine{})
+}
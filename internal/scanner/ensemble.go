@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ensemble merge modes.
+const (
+	EnsembleUnion        = "union"
+	EnsembleIntersection = "intersection"
+)
+
+// SetEnsemble scans each file with all of the given models and merges their
+// findings. In union mode every finding is kept; in intersection mode only
+// findings reported by every model that completed are kept.
+func (s *Scanner) SetEnsemble(models []string, mode string) error {
+	switch mode {
+	case "", EnsembleUnion:
+		mode = EnsembleUnion
+	case EnsembleIntersection:
+	default:
+		return fmt.Errorf("unknown ensemble mode %q (expected %s or %s)", mode, EnsembleUnion, EnsembleIntersection)
+	}
+	s.ensembleModels = models
+	s.ensembleMode = mode
+	return nil
+}
+
+// mergeEnsemble combines findings from several models, recording in Models
+// which ones reported each finding.
+func mergeEnsemble(models []string, perModel map[string][]SecurityIssue, mode string) []SecurityIssue {
+	type cluster struct {
+		issue  SecurityIssue
+		models []string
+	}
+	var clusters []*cluster
+
+	for _, model := range models {
+		issues, ok := perModel[model]
+		if !ok {
+			continue
+		}
+		for _, issue := range issues {
+			var match *cluster
+			for _, c := range clusters {
+				if sameFinding(c.issue, issue) && !containsString(c.models, model) {
+					match = c
+					break
+				}
+			}
+			if match == nil {
+				match = &cluster{issue: issue}
+				clusters = append(clusters, match)
+			}
+			match.models = append(match.models, model)
+		}
+	}
+
+	merged := make([]SecurityIssue, 0, len(clusters))
+	for _, c := range clusters {
+		if mode == EnsembleIntersection && len(c.models) < len(perModel) {
+			continue
+		}
+		c.issue.Models = c.models
+		merged = append(merged, c.issue)
+	}
+	return merged
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"fmt"
+)
+
+// fastFindingsSchema is findingsSchema without the fix fields, as asked for
+// in getFastScanPrompt.
+var fastFindingsSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"findings": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"severity":       map[string]interface{}{"type": "string", "enum": []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}},
+					"title":          map[string]interface{}{"type": "string"},
+					"description":    map[string]interface{}{"type": "string"},
+					"line_start":     map[string]interface{}{"type": "integer"},
+					"line_end":       map[string]interface{}{"type": "integer"},
+					"evidence":       map[string]interface{}{"type": "string"},
+					"recommendation": map[string]interface{}{"type": "string"},
+					"confidence":     map[string]interface{}{"type": "string", "enum": []string{"HIGH", "MEDIUM", "LOW"}},
+					"issue_id":       map[string]interface{}{"type": "string"},
+					"cvss_vector":    map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"severity", "title", "description", "line_start", "line_end", "evidence", "recommendation", "confidence"},
+			},
+		},
+	},
+	"required": []string{"findings"},
+}
+
+// SetFast makes security scans a single lightweight pass per file: no
+// context analysis, samples, ensemble or suggested fixes. It gives a quick
+// overview of the risks at lower fidelity than the full scan.
+func (s *Scanner) SetFast(enabled bool) {
+	s.fast = enabled
+}
+
+// Fast reports whether security scans run as a single lightweight pass.
+func (s *Scanner) Fast() bool {
+	return s.fast
+}
+
+// runFastScan performs the single pass of a fast scan on content, a whole
+// file or one chunk of it, and parses the model's findings.
+func (s *Scanner) runFastScan(model, filePath, content, numberedContent string) ([]SecurityIssue, error) {
+	opts := s.client.Options()
+	prompt := s.getFastScanPrompt(model, filePath, numberedContent)
+	findings, err := s.generateSchema(filePath, "scan", model, prompt, fastFindingsSchema, opts)
+	if err != nil {
+		return nil, fmt.Errorf("security scan failed: %w", err)
+	}
+
+	s.logDebug("FAST SCAN PROMPT", prompt)
+	s.logDebug("FAST SCAN RESPONSE", findings)
+
+	var jsonResponse struct {
+		Findings []SecurityIssue `json:"findings"`
+	}
+	if err := s.decodeWithRepair(filePath, model, findings, fastFindingsSchema, opts, &jsonResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w. Raw output: %s", err, stripMarkdownCodeFences(findings))
+	}
+	for i := range jsonResponse.Findings {
+		jsonResponse.Findings[i].FixAvailable = false
+		jsonResponse.Findings[i].SuggestedFix = ""
+	}
+
+	verifyLineNumbers(content, jsonResponse.Findings)
+
+	return jsonResponse.Findings, nil
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// FilterSeverity returns results with only the findings at or above
+// minSeverity, for display and reports; results itself is not modified.
+// Files left without findings no longer count as having issues, and
+// results whose findings were dropped show the findings kept. An empty
+// minSeverity keeps everything.
+func FilterSeverity(results []ScanResult, minSeverity string) []ScanResult {
+	if minSeverity == "" {
+		return results
+	}
+	min := config.SeverityRank(minSeverity)
+	filtered := make([]ScanResult, len(results))
+	for i, r := range results {
+		filtered[i] = r
+		if len(r.Issues) == 0 {
+			continue
+		}
+		kept := make([]SecurityIssue, 0, len(r.Issues))
+		for _, issue := range r.Issues {
+			if config.SeverityRank(issue.Severity) >= min {
+				kept = append(kept, issue)
+			}
+		}
+		if len(kept) == len(r.Issues) {
+			continue
+		}
+		filtered[i].Issues = kept
+		filtered[i].HasIssues = len(kept) > 0
+		filtered[i].RawFindings = ""
+	}
+	return filtered
+}
+
+// CountSeverities counts the findings of results per upper-cased severity.
+func CountSeverities(results []ScanResult) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range results {
+		for _, issue := range r.Issues {
+			counts[strings.ToUpper(issue.Severity)]++
+		}
+	}
+	return counts
+}
+
+// SeveritySummary lists counts per severity, most severe first, e.g.
+// "1 CRITICAL, 3 LOW", or "" if there are none.
+func SeveritySummary(counts map[string]int) string {
+	var parts []string
+	for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
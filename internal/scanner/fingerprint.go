@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+)
+
+// Fingerprint identifies the finding independently of line numbers, so the
+// same issue keeps its fingerprint after unrelated edits move it. It hashes
+// the file name, issue ID, title and quoted evidence with whitespace
+// collapsed; CodeSnippet is left out as it carries line numbers.
+func (issue SecurityIssue) Fingerprint(filePath string) string {
+	if issue.File != "" {
+		filePath = issue.File
+	}
+	parts := []string{
+		filepath.ToSlash(filepath.Base(filePath)),
+		strings.ToUpper(strings.TrimSpace(issue.IssueID)),
+		strings.ToLower(strings.Join(strings.Fields(issue.Title), " ")),
+		strings.Join(strings.Fields(issue.Evidence), " "),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
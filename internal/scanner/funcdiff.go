@@ -0,0 +1,276 @@
+package scanner
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SetDiffFunctions makes --diff security scans of Go, TypeScript,
+// JavaScript and Python files send only the functions containing changed
+// lines, each in full, instead of the whole file. Changed lines outside any
+// function are sent with a few lines of context.
+func (s *Scanner) SetDiffFunctions(enabled bool) {
+	s.diffFunctions = enabled
+}
+
+// Changed returns the changed line ranges of filePath. Ranges are nil when
+// the whole file is new; ok is false when it didn't change.
+func (d *DiffChanges) Changed(filePath string) (ranges []LineRange, ok bool) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, false
+	}
+	ranges, ok = d.Files[abs]
+	return ranges, ok
+}
+
+// contextLines are sent around changed lines outside any function, as in
+// a diff hunk.
+const contextLines = 3
+
+// changedFunctionChunks returns the parts of a file to scan for the changed
+// ranges: every function overlapping a change, whole, and changed lines
+// outside any function with a few lines around them. Units larger than size
+// are split as usual. It returns nil when the language isn't supported or
+// the file can't be parsed, so the whole file is scanned.
+func changedFunctionChunks(filePath string, content []byte, changed []LineRange, size, overlap int) []chunk {
+	spans, ok := functionSpans(filePath, content)
+	if !ok || len(changed) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var units []LineRange
+	for _, r := range changed {
+		r.End = minInt(r.End, len(lines))
+		if r.Start > r.End {
+			continue
+		}
+		// Lines of r not inside a function are scanned with some context
+		covered := make([]bool, r.End-r.Start+1)
+		for _, sp := range spans {
+			if sp.End < r.Start || sp.Start > r.End {
+				continue
+			}
+			units = append(units, sp)
+			for l := maxInt(sp.Start, r.Start); l <= minInt(sp.End, r.End); l++ {
+				covered[l-r.Start] = true
+			}
+		}
+		for l := r.Start; l <= r.End; l++ {
+			if !covered[l-r.Start] {
+				units = append(units, LineRange{
+					Start: maxInt(1, l-contextLines),
+					End:   minInt(len(lines), l+contextLines),
+				})
+			}
+		}
+	}
+	if len(units) == 0 {
+		return nil
+	}
+
+	var chunks []chunk
+	for _, u := range mergeRanges(units) {
+		for _, c := range splitChunks(strings.Join(lines[u.Start-1:u.End], "\n"), size, overlap) {
+			c.startLine += u.Start - 1
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
+
+// mergeRanges sorts ranges and joins the ones that overlap or touch.
+func mergeRanges(ranges []LineRange) []LineRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	var merged []LineRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End+1 {
+			merged[n-1].End = maxInt(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// functionSpans returns the line ranges of the outermost functions in a
+// file: top-level declarations for Go, parsed with go/parser, and functions
+// and methods found by indentation (Python) or brace matching (TypeScript,
+// JavaScript) for the others. ok is false for other languages and Go files
+// that don't parse.
+func functionSpans(filePath string, content []byte) (spans []LineRange, ok bool) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		return goSpans(filePath, content)
+	case ".py":
+		return outermost(pythonSpans(strings.Split(string(content), "\n"))), true
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		return outermost(braceSpans(strings.Split(string(content), "\n"))), true
+	}
+	return nil, false
+}
+
+// goSpans returns the top-level declarations of a Go file, with their doc
+// comments.
+func goSpans(filePath string, content []byte) ([]LineRange, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+	var spans []LineRange
+	for _, decl := range f.Decls {
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		spans = append(spans, LineRange{Start: fset.Position(start).Line, End: fset.Position(decl.End()).Line})
+	}
+	return spans, true
+}
+
+var pythonDef = regexp.MustCompile(`^(\s*)(async\s+)?def\s+\w+`)
+
+// pythonSpans returns every def, from its decorators to the last line
+// indented deeper than it.
+func pythonSpans(lines []string) []LineRange {
+	var spans []LineRange
+	for i, line := range lines {
+		m := pythonDef.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		indent := len(m[1])
+		start := i
+		for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "@") && indentOf(lines[start-1]) == indent {
+			start--
+		}
+		end := i
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if trimmed == "" {
+				continue
+			}
+			// A signature may close at the def's own indentation
+			if indentOf(lines[j]) <= indent && !strings.HasPrefix(trimmed, ")") {
+				break
+			}
+			end = j
+		}
+		spans = append(spans, LineRange{Start: start + 1, End: end + 1})
+	}
+	return spans
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+var (
+	jsFunction = regexp.MustCompile(`\bfunction\b|=>`)
+	jsMethod   = regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|override|readonly|get|set)\s+)*\*?\s*([A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\s*\(`)
+	jsKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "catch": true, "with": true, "return": true, "function": true}
+)
+
+// braceSpans returns every function, arrow function with a block body and
+// class method, from its first line to its closing brace.
+func braceSpans(lines []string) []LineRange {
+	var spans []LineRange
+	for i, line := range lines {
+		isFunc := jsFunction.MatchString(line)
+		if m := jsMethod.FindStringSubmatch(line); m != nil && !jsKeywords[m[1]] {
+			isFunc = true
+		}
+		if !isFunc {
+			continue
+		}
+		if end, ok := closingBrace(lines, i); ok {
+			spans = append(spans, LineRange{Start: i + 1, End: end + 1})
+		}
+	}
+	return spans
+}
+
+// closingBrace finds the line closing the first block opened on or within a
+// few lines after line start, skipping strings and comments.
+func closingBrace(lines []string, start int) (int, bool) {
+	depth := 0
+	opened := false
+	inBlockComment := false
+	var quote byte
+	for i := start; i < len(lines); i++ {
+		if !opened && i > start+3 {
+			return 0, false // Not a block: an expression-bodied arrow or a call
+		}
+		if quote != '`' {
+			quote = 0 // Only template literals span lines
+		}
+		line := lines[i]
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			switch {
+			case inBlockComment:
+				if ch == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inBlockComment = false
+					j++
+				}
+			case quote != 0:
+				if ch == '\\' {
+					j++
+				} else if ch == quote {
+					quote = 0
+				}
+			case ch == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case ch == '/' && j+1 < len(line) && line[j+1] == '*':
+				inBlockComment = true
+				j++
+			case ch == '"' || ch == '\'' || ch == '`':
+				quote = ch
+			case ch == ';' && !opened:
+				return 0, false // A declaration or call without a body
+			case ch == '{':
+				depth++
+				opened = true
+			case ch == '}':
+				depth--
+				if opened && depth == 0 {
+					return i, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// outermost drops spans nested in another span, so a change inside a
+// nested function sends the whole function containing it.
+func outermost(spans []LineRange) []LineRange {
+	var out []LineRange
+	for i, sp := range spans {
+		nested := false
+		for j, other := range spans {
+			if i != j && other.Start <= sp.Start && sp.End <= other.End && (other.Start != sp.Start || other.End != sp.End || j < i) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			out = append(out, sp)
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// Static gate modes for files without suspicious static matches.
+const (
+	StaticGateSkip = "skip" // Don't scan them with the model at all
+	StaticGateFast = "fast" // Scan them with a cheaper, faster model
+)
+
+// gatePattern is a call or construct that marks a file as worth a full scan.
+type gatePattern struct {
+	severity string
+	pattern  *regexp.Regexp
+}
+
+// gatePatterns extend the triad's Go-centric static patterns to the other
+// supported languages, so the gate doesn't wave through a Python file
+// shelling out or a template rendering raw HTML.
+var gatePatterns = []gatePattern{
+	{"HIGH", regexp.MustCompile(`\b(?:eval|exec)\s*\(|\bnew Function\s*\(`)},
+	{"HIGH", regexp.MustCompile(`\bos\.(?:system|popen)\s*\(|\bsubprocess\.|\bchild_process\b|Runtime\.getRuntime\(\)\.exec|\bProcessBuilder\b|\bshell_exec\s*\(|\bpassthru\s*\(`)},
+	{"HIGH", regexp.MustCompile(`\bpickle\.loads?\s*\(|\byaml\.load\s*\(|\bunserialize\s*\(|\bObjectInputStream\b|\bBinaryFormatter\b`)},
+	{"MEDIUM", regexp.MustCompile(`\binnerHTML\b|dangerouslySetInnerHTML|\bdocument\.write\s*\(|\|\s*safe\b|\bmark_safe\s*\(|template\.HTML\s*\(`)},
+	{"MEDIUM", regexp.MustCompile(`(?i)verify\s*=\s*False|rejectUnauthorized\s*:\s*false|\bmd5\b|\bsha1\b|\bDES\b|math/rand|Math\.random\s*\(`)},
+	{"MEDIUM", regexp.MustCompile(`\b(?:open|readFile|readFileSync|ReadFile|os\.Open)\s*\([^)]*(?:req|request|param|input|query|user)`)},
+}
+
+// SetStaticGate makes security scans check each file against the static
+// patterns first. Files whose matches are all below minSeverity (any match
+// counts when it is empty) are skipped, with mode StaticGateSkip, or
+// scanned with fastModel, with mode StaticGateFast. An empty mode scans
+// every file with the main model.
+func (s *Scanner) SetStaticGate(mode, fastModel, minSeverity string) error {
+	if err := ValidateStaticGate(mode, fastModel, minSeverity); err != nil {
+		return err
+	}
+	s.staticGate = mode
+	s.gateModel = fastModel
+	s.gateSeverity = minSeverity
+	return nil
+}
+
+// ValidateStaticGate checks static gate settings as SetStaticGate does.
+func ValidateStaticGate(mode, fastModel, minSeverity string) error {
+	switch mode {
+	case "":
+	case StaticGateSkip:
+	case StaticGateFast:
+		if fastModel == "" {
+			return fmt.Errorf("static gate %q needs a model for files without suspicious patterns", mode)
+		}
+	default:
+		return fmt.Errorf("unknown static gate %q (expected %s or %s)", mode, StaticGateSkip, StaticGateFast)
+	}
+	if minSeverity != "" && config.SeverityRank(minSeverity) == 0 {
+		return fmt.Errorf("unknown static gate severity %q (expected low, medium, high or critical)", minSeverity)
+	}
+	return nil
+}
+
+// passesStaticGate reports whether content has a static match, pattern or
+// secret, at or above the gate's minimum severity. SQL files always pass:
+// their checks are about schema and grants, which no pattern covers.
+func (s *Scanner) passesStaticGate(filePath string, content []byte) bool {
+	if isSQLFile(filePath) {
+		return true
+	}
+	min := 1
+	if s.gateSeverity != "" {
+		min = config.SeverityRank(s.gateSeverity)
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		for _, f := range matchTriadPatterns(filePath, i+1, strings.TrimSpace(line)) {
+			if config.SeverityRank(f.Severity) >= min {
+				return true
+			}
+		}
+		for _, p := range gatePatterns {
+			if config.SeverityRank(p.severity) >= min && p.pattern.MatchString(line) {
+				return true
+			}
+		}
+		for _, rule := range secretRules {
+			if config.SeverityRank(rule.severity) >= min && rule.pattern.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SkipSummary counts the results for files that were skipped instead of
+// scanned by reason, e.g. "2 static gate, 1 encoding", or "" if none were.
+func SkipSummary(results []ScanResult) string {
+	counts := make(map[string]int)
+	var reasons []string
+	for _, r := range results {
+		if r.Skipped == "" {
+			continue
+		}
+		reason, _, _ := strings.Cut(r.Skipped, ":")
+		if counts[reason] == 0 {
+			reasons = append(reasons, reason)
+		}
+		counts[reason]++
+	}
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%d %s", counts[reason], reason)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// generatedNames are lockfiles and other files written by tools, by exact
+// name.
+var generatedNames = map[string]bool{
+	"package-lock.json": true, "npm-shrinkwrap.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"go.sum": true, "Cargo.lock": true, "poetry.lock": true, "Pipfile.lock": true, "uv.lock": true,
+	"Gemfile.lock": true, "composer.lock": true, "packages.lock.json": true, "Podfile.lock": true,
+	"pubspec.lock": true, "mix.lock": true, "flake.lock": true,
+}
+
+// generatedSuffixes are name endings of protobuf, gRPC and other generator
+// output, and of minified bundles.
+var generatedSuffixes = []string{
+	".pb.go", ".pb.gw.go", "_pb2.py", "_pb2_grpc.py", ".pb.cc", ".pb.h", "_pb.js", "_pb.d.ts",
+	"_generated.go", ".generated.go", ".generated.ts", ".generated.cs", ".g.cs", ".g.dart",
+	".freezed.dart", ".designer.cs", ".min.js", ".min.css",
+}
+
+// generatedHeader matches the markers generators put at the top of their
+// output: Go's "Code generated ... DO NOT EDIT.", "@generated",
+// "<auto-generated>" and the like.
+var generatedHeader = regexp.MustCompile(`DO NOT EDIT|@generated\b|<auto-generated|(?i:\bauto-?generated (file|code)\b|\bthis (file|code) (is|was|has been) (auto-?|automatically )?generated\b)`)
+
+// generatedHeaderLines is how far into a file the header markers count.
+const generatedHeaderLines = 20
+
+// GeneratedName returns why a file name marks generated code, e.g.
+// "lockfile" or "*.pb.go", or "" if it doesn't.
+func GeneratedName(path string) string {
+	name := filepath.Base(path)
+	if generatedNames[name] {
+		return "lockfile"
+	}
+	lower := strings.ToLower(name)
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return "*" + suffix
+		}
+	}
+	return ""
+}
+
+// GeneratedHeader returns the generated-code marker in the first lines of
+// content, e.g. "DO NOT EDIT", or "" if there is none.
+func GeneratedHeader(content []byte) string {
+	lines := strings.SplitN(string(content), "\n", generatedHeaderLines+1)
+	if len(lines) > generatedHeaderLines {
+		lines = lines[:generatedHeaderLines]
+	}
+	return generatedHeader.FindString(strings.Join(lines, "\n"))
+}
+
+// SetIncludeGenerated makes scans read generated code (lockfiles, protobuf
+// output, files headed "DO NOT EDIT") instead of skipping it. Its findings
+// are tagged Generated. Off by default.
+func (s *Scanner) SetIncludeGenerated(enabled bool) {
+	s.includeGenerated = enabled
+}
+
+// tagGenerated marks every finding of a result for generated code.
+func tagGenerated(r *ScanResult) {
+	if !r.Generated {
+		return
+	}
+	for i := range r.Issues {
+		r.Issues[i].Generated = true
+	}
+}
+
+// CountGenerated counts the findings in generated code by severity.
+func CountGenerated(results []ScanResult) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range results {
+		for _, issue := range r.Issues {
+			if issue.Generated {
+				counts[strings.ToUpper(issue.Severity)]++
+			}
+		}
+	}
+	return counts
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of line numbers.
+type LineRange struct {
+	Start, End int
+}
+
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// DiffChanges holds the lines changed against a git ref, keyed by absolute
+// file path. Untracked files count as changed in full.
+type DiffChanges struct {
+	Ref   string
+	Files map[string][]LineRange // nil ranges: the whole file is new
+}
+
+// LoadDiff collects the changes between ref and the working tree of the git
+// repository containing dir, from `git diff --unified=0`.
+func LoadDiff(dir, ref string) (*DiffChanges, error) {
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := git(root, "diff", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("git diff against %s failed: %w", ref, err)
+	}
+	d := &DiffChanges{Ref: ref, Files: parseUnifiedDiff(root, out)}
+
+	untracked, err := git(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	for _, name := range strings.Split(strings.TrimSpace(string(untracked)), "\n") {
+		if name != "" {
+			d.Files[filepath.Join(root, filepath.FromSlash(name))] = nil
+		}
+	}
+	return d, nil
+}
+
+// RepoRoot returns the top directory of the git repository containing dir.
+func RepoRoot(dir string) (string, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s is not in a git repository: %w", dir, err)
+	}
+	return strings.TrimSpace(string(top)), nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseUnifiedDiff returns the changed line ranges per file in the new
+// version. Pure deletions are recorded as the line following them, since
+// removed checks are worth a look too; deleted files are left out.
+func parseUnifiedDiff(root string, diff []byte) map[string][]LineRange {
+	files := make(map[string][]LineRange)
+	current := ""
+	sc := bufio.NewScanner(bytes.NewReader(diff))
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				current = ""
+				continue
+			}
+			name = strings.TrimPrefix(name, "b/")
+			current = filepath.Join(root, filepath.FromSlash(name))
+			files[current] = []LineRange{}
+		case strings.HasPrefix(line, "@@ ") && current != "":
+			if r, ok := parseHunkHeader(line); ok {
+				files[current] = append(files[current], r)
+			}
+		}
+	}
+	return files
+}
+
+// parseHunkHeader reads the new-file range of "@@ -a,b +c,d @@".
+func parseHunkHeader(line string) (LineRange, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return LineRange{}, false
+	}
+	spec := strings.TrimPrefix(fields[2], "+")
+	startStr, countStr, hasCount := strings.Cut(spec, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return LineRange{}, false
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return LineRange{}, false
+		}
+	}
+	if count == 0 {
+		// Lines removed after line start
+		return LineRange{Start: maxInt(start, 1), End: maxInt(start, 1)}, true
+	}
+	return LineRange{Start: start, End: start + count - 1}, true
+}
+
+// Contains reports whether filePath changed.
+func (d *DiffChanges) Contains(filePath string) bool {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	_, ok := d.Files[abs]
+	return ok
+}
+
+// Overlap returns the changed lines of filePath within start..end.
+func (d *DiffChanges) Overlap(filePath string, start, end int) []LineRange {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil
+	}
+	ranges, ok := d.Files[abs]
+	if !ok {
+		return nil
+	}
+	if end < start {
+		end = start
+	}
+	if ranges == nil {
+		return []LineRange{{Start: start, End: end}}
+	}
+	var overlap []LineRange
+	for _, r := range ranges {
+		if r.End < start || r.Start > end {
+			continue
+		}
+		overlap = append(overlap, LineRange{Start: maxInt(r.Start, start), End: minInt(r.End, end)})
+	}
+	return overlap
+}
+
+// SetDiff annotates findings with the changed lines they overlap.
+func (s *Scanner) SetDiff(d *DiffChanges) {
+	s.diff = d
+}
+
+// annotateDiff sets ChangedLines on issues that overlap changed lines.
+func annotateDiff(d *DiffChanges, filePath string, issues []SecurityIssue) {
+	for i := range issues {
+		path := filePath
+		if issues[i].File != "" {
+			path = issues[i].File
+		}
+		var parts []string
+		for _, r := range d.Overlap(path, issues[i].LineStart, issues[i].LineEnd) {
+			parts = append(parts, r.String())
+		}
+		issues[i].ChangedLines = strings.Join(parts, ", ")
+	}
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// quarantineNote replaces text addressed to the model before a file is
+// scanned. It keeps the line, so line numbers don't move.
+const quarantineNote = "[sidekick: text addressed to the AI reviewer removed]"
+
+// injectionPatterns match text in code (usually a comment or a string)
+// that talks to an AI reviewer instead of to a human: attempts to override
+// the scan's instructions or dictate its answer.
+var injectionPatterns = []*regexp.Regexp{
+	// "ignore all previous instructions", "disregard your instructions"
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,20}\b(previous|prior|above|earlier|preceding|system)\s+(instructions?|prompts?|directions|rules)\b`),
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\s+(all\s+|any\s+)?(your|these|those|the\s+above)\s+instructions\b`),
+	// "report no vulnerabilities", "output zero findings"
+	regexp.MustCompile(`(?i)\b(report|return|output|respond with|answer with|say there are)\b.{0,20}\b(no|zero|0|empty)\b.{0,20}\b(issues|vulnerabilit(y|ies)|findings|problems|security issues)\b`),
+	// "AI: do not flag this"
+	regexp.MustCompile(`(?i)\b(ai|llm|assistant|language model|chatgpt|gpt|claude|copilot|security (scanner|reviewer|auditor))\b.{0,40}\b(do not|don't|never|must not)\b.{0,20}\b(report|flag|mention)\b`),
+	regexp.MustCompile(`(?i)\b(do not|don't|never|must not)\b.{0,20}\b(report|flag|mention)\b.{0,40}\b(ai|llm|assistant|language model|security (scanner|reviewer|auditor))\b`),
+	// "you are now a helpful assistant", "act as a security auditor who..."
+	regexp.MustCompile(`(?i)\b(you are now|you're now|act as|pretend to be|from now on you)\b.{0,30}\b(ai|assistant|language model|llm|chatbot|security (scanner|reviewer|auditor))\b`),
+	// "note to the AI reviewer", "instructions for the LLM"
+	regexp.MustCompile(`(?i)\b(note|message|instructions?|attention)\s+(to|for)\s+(the\s+)?(ai|llm|assistant|language model|(security\s+)?(scanner|reviewer|auditor))\b`),
+	regexp.MustCompile(`(?i)\b(new|updated|real|actual)\s+(system\s+)?instructions\s*:`),
+	// Chat template markers and a pre-written answer
+	regexp.MustCompile(`(?i)<\|im_start\|>|<\|system\|>|\[/?INST\]|<</?SYS>>|###\s*(system|instruction)\b`),
+	regexp.MustCompile(`\\?"findings\\?"\s*:\s*\[\s*\]`),
+}
+
+// SetQuarantine enables neutralizing text addressed to the model before a
+// file is scanned. Affected lines are listed in the file's result either
+// way. On by default.
+func (s *Scanner) SetQuarantine(enabled bool) {
+	s.quarantine = enabled
+}
+
+// quarantineContent finds lines of content with text addressed to the
+// model. With quarantine on, the text from the match to the end of the line
+// is replaced by a note, keeping any code before it; content is returned
+// unchanged otherwise. lines are 1-based.
+func (s *Scanner) quarantineContent(content []byte) (neutralized []byte, lines []int) {
+	src := strings.Split(string(content), "\n")
+	changed := false
+	for i, line := range src {
+		start := -1
+		for _, p := range injectionPatterns {
+			if loc := p.FindStringIndex(line); loc != nil && (start < 0 || loc[0] < start) {
+				start = loc[0]
+			}
+		}
+		if start < 0 {
+			continue
+		}
+		lines = append(lines, i+1)
+		if s.quarantine {
+			src[i] = line[:start] + quarantineNote
+			changed = true
+		}
+	}
+	if !changed {
+		return content, lines
+	}
+	return []byte(strings.Join(src, "\n")), lines
+}
+
+// FormatLines lists sorted line numbers with consecutive ones as ranges,
+// e.g. "3, 10-12".
+func FormatLines(lines []int) string {
+	var parts []string
+	for i := 0; i < len(lines); {
+		r := LineRange{Start: lines[i], End: lines[i]}
+		for i++; i < len(lines) && lines[i] == r.End+1; i++ {
+			r.End++
+		}
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// InjectionWarnings returns a line for every result with text addressed to
+// the model, for the scan summary.
+func InjectionWarnings(results []ScanResult) []string {
+	var warnings []string
+	for _, r := range results {
+		if len(r.Quarantined) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: line(s) %s", r.FilePath, FormatLines(r.Quarantined)))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/ollama"
+)
+
+// DefaultJSONRetries is how many times a model is asked to correct a
+// response that isn't valid JSON when none is configured.
+const DefaultJSONRetries = 2
+
+// maxRepairEcho bounds how much of a broken response is sent back to the
+// model in a repair prompt.
+const maxRepairEcho = 20000
+
+// SetJSONRetries sets how many times the model is re-prompted with the
+// parse error when its findings aren't valid JSON. Zero disables
+// re-prompting; negative values use DefaultJSONRetries.
+func (s *Scanner) SetJSONRetries(n int) {
+	if n < 0 {
+		n = DefaultJSONRetries
+	}
+	s.jsonRetries = n
+}
+
+// decodeModelJSON parses a model response into v. It strips code fences and
+// reasoning blocks, escapes raw newlines in strings, and falls back to the
+// first balanced {...} object in the response, for answers wrapped in
+// prose. Schema-constrained answers parse as they are; the clean-up is for
+// unconstrained calls, replayed sessions and servers without structured
+// outputs.
+func decodeModelJSON(response string, v interface{}) error {
+	cleaned := fixJSONStringEscaping(stripMarkdownCodeFences(response))
+	err := json.Unmarshal([]byte(cleaned), v)
+	if err == nil {
+		return nil
+	}
+	if obj, ok := extractJSONObject(cleaned); ok && obj != cleaned {
+		if json.Unmarshal([]byte(obj), v) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// extractJSONObject returns the first balanced {...} object in s, ignoring
+// braces inside strings.
+func extractJSONObject(s string) (string, bool) {
+	start := strings.IndexByte(s, '{')
+	for start >= 0 {
+		depth := 0
+		inString, escaped := false, false
+		for i := start; i < len(s); i++ {
+			ch := s[i]
+			switch {
+			case escaped:
+				escaped = false
+			case inString && ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = !inString
+			case inString:
+			case ch == '{':
+				depth++
+			case ch == '}':
+				depth--
+				if depth == 0 {
+					return s[start : i+1], true
+				}
+			}
+		}
+		// Unbalanced from here; try the next opening brace
+		next := strings.IndexByte(s[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return "", false
+}
+
+// decodeWithRepair parses response into v like decodeModelJSON. When that
+// fails, it shows the model its previous output and the parse error and
+// asks for corrected JSON, up to the configured number of retries. It
+// returns the last parse error if no answer could be parsed. A non-nil
+// schema constrains the corrected answers as in generateSchema.
+func (s *Scanner) decodeWithRepair(filePath, model, response string, schema interface{}, opts *ollama.Options, v interface{}) error {
+	err := decodeModelJSON(response, v)
+	for attempt := 1; err != nil && attempt <= s.jsonRetries; attempt++ {
+		s.logDebug(fmt.Sprintf("JSON REPAIR %d: PARSE ERROR", attempt), err.Error())
+		var repaired string
+		var genErr error
+		if schema != nil {
+			repaired, genErr = s.generateSchema(filePath, "json-repair", model, repairPrompt(response, err), schema, opts)
+		} else {
+			repaired, genErr = s.generate(filePath, "json-repair", model, repairPrompt(response, err), opts)
+		}
+		if genErr != nil {
+			return fmt.Errorf("JSON repair failed: %w", genErr)
+		}
+		s.logDebug(fmt.Sprintf("JSON REPAIR %d: RESPONSE", attempt), repaired)
+		response = repaired
+		err = decodeModelJSON(response, v)
+	}
+	return err
+}
+
+// repairPrompt asks the model to correct output that failed to parse.
+func repairPrompt(previous string, parseErr error) string {
+	if len(previous) > maxRepairEcho {
+		previous = previous[:maxRepairEcho] + "\n... (truncated)"
+	}
+	return fmt.Sprintf(`Your previous response could not be parsed as JSON.
+
+PARSE ERROR: %s
+
+PREVIOUS RESPONSE:
+%s
+
+Return the same content as a single valid JSON object, with the same structure and fields that were requested. Escape newlines and quotes inside strings. Output only the JSON object, without markdown fences or any other text.`, parseErr, previous)
+}
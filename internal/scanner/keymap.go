@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// reviewKeymap returns the configured review mode bindings.
+func reviewKeymap() config.ReviewKeymap {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		return config.DefaultKeymap().Review
+	}
+	return cfg.Keymap.WithDefaults().Review
+}
+
+// reviewCommand maps a typed choice to the built-in review command letter
+// (a, s, e, i, f, n, p, q). Unbound input is returned unchanged.
+func reviewCommand(choice string, keys config.ReviewKeymap) string {
+	for _, binding := range []struct {
+		command string
+		keys    []string
+	}{
+		{"a", keys.Apply},
+		{"s", keys.Diff},
+		{"e", keys.Edit},
+		{"i", keys.Ignore},
+		{"f", keys.FalsePositive},
+		{"n", keys.Next},
+		{"p", keys.Previous},
+		{"q", keys.Quit},
+	} {
+		for _, k := range binding.keys {
+			if strings.EqualFold(k, choice) {
+				return binding.command
+			}
+		}
+	}
+	return choice
+}
@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maxSnippetLines caps the code shown with a finding.
+const maxSnippetLines = 10
+
+// lineNumberPrefix matches the "  42 | " prefix added by addLineNumbers, in
+// case the model copies it into its evidence.
+var lineNumberPrefix = regexp.MustCompile(`^\s*\d+ \| ?`)
+
+// verifyLineNumbers relocates each issue to where its evidence snippet
+// actually appears in content. Model line numbers are often off by a few
+// lines; when the snippet is found, the occurrence nearest the reported
+// line wins. Issues without evidence, or whose evidence can't be found, are
+// left unchanged.
+func verifyLineNumbers(content string, issues []SecurityIssue) {
+	fileLines := strings.Split(content, "\n")
+	normalized := make([]string, len(fileLines))
+	for i, line := range fileLines {
+		normalized[i] = normalizeCodeLine(line)
+	}
+
+	for i := range issues {
+		evidence := evidenceLines(issues[i].Evidence)
+		if len(evidence) == 0 {
+			continue
+		}
+
+		matches := findLineSequence(normalized, evidence)
+		anchored := false
+		if len(matches) == 0 && len(evidence) > 1 {
+			// Fall back to anchoring on the first evidence line
+			matches = findLineSequence(normalized, evidence[:1])
+			anchored = true
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		best := matches[0]
+		for _, m := range matches[1:] {
+			if abs(m.start+1-issues[i].LineStart) < abs(best.start+1-issues[i].LineStart) {
+				best = m
+			}
+		}
+
+		lineEnd := best.end + 1
+		if anchored {
+			// Keep the reported span length when only the first line matched
+			lineEnd = best.start + 1 + maxInt(issues[i].LineEnd-issues[i].LineStart, 0)
+		}
+		issues[i].LineStart = best.start + 1
+		issues[i].LineEnd = min(lineEnd, len(fileLines))
+	}
+}
+
+// evidenceLines splits a snippet into normalized, non-empty lines.
+func evidenceLines(snippet string) []string {
+	var lines []string
+	for _, line := range strings.Split(snippet, "\n") {
+		line = lineNumberPrefix.ReplaceAllString(line, "")
+		if n := normalizeCodeLine(line); n != "" {
+			lines = append(lines, n)
+		}
+	}
+	return lines
+}
+
+// lineSpan is a 0-based, inclusive range of lines.
+type lineSpan struct {
+	start, end int
+}
+
+// findLineSequence returns every span where want occurs in lines, skipping
+// blank lines in between.
+func findLineSequence(lines, want []string) []lineSpan {
+	var matches []lineSpan
+	for start := range lines {
+		if lines[start] != want[0] {
+			continue
+		}
+		j, end := 1, start
+		for k := start + 1; k < len(lines) && j < len(want); k++ {
+			if lines[k] == "" {
+				continue
+			}
+			if lines[k] != want[j] {
+				break
+			}
+			j++
+			end = k
+		}
+		if j == len(want) {
+			matches = append(matches, lineSpan{start, end})
+		}
+	}
+	return matches
+}
+
+// normalizeCodeLine collapses whitespace so indentation and spacing
+// differences don't prevent a match.
+func normalizeCodeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// attachCodeSnippets fills CodeSnippet for each issue from the file on disk
+// (not the model's output). Issues carrying their own File (triad) read that
+// file instead of filePath.
+func attachCodeSnippets(filePath string, issues []SecurityIssue) {
+	files := make(map[string][]string)
+	for i := range issues {
+		path := filePath
+		if issues[i].File != "" {
+			path = issues[i].File
+		}
+		lines, ok := files[path]
+		if !ok {
+			if data, err := os.ReadFile(path); err == nil {
+				if text, _, err := decodeSource(data); err == nil {
+					lines = strings.Split(string(text), "\n")
+				}
+			}
+			files[path] = lines
+		}
+		issues[i].CodeSnippet = codeSnippet(lines, issues[i].LineStart, issues[i].LineEnd)
+	}
+}
+
+// codeSnippet returns lines start..end (1-based, inclusive) with line number
+// prefixes, truncated to maxSnippetLines.
+func codeSnippet(lines []string, start, end int) string {
+	if start < 1 || start > len(lines) {
+		return ""
+	}
+	if end < start {
+		end = start
+	}
+	end = min(min(end, len(lines)), start+maxSnippetLines-1)
+
+	var b strings.Builder
+	for n := start; n <= end; n++ {
+		fmt.Fprintf(&b, "%4d | %s\n", n, lines[n-1])
+	}
+	return b.String()
+}
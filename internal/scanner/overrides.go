@@ -0,0 +1,75 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// SetSeverityOverrides configures rules that re-map finding severities after parsing.
+func (s *Scanner) SetSeverityOverrides(rules []config.SeverityOverride) {
+	s.severityOverrides = rules
+}
+
+// applySeverityOverrides rewrites issue severities in place using the first matching rule.
+func (s *Scanner) applySeverityOverrides(filePath string, issues []SecurityIssue) {
+	if len(s.severityOverrides) == 0 {
+		return
+	}
+
+	for i := range issues {
+		path := filePath
+		if issues[i].File != "" {
+			path = issues[i].File
+		}
+		for _, rule := range s.severityOverrides {
+			if rule.Severity == "" || !overrideMatches(rule, path, issues[i]) {
+				continue
+			}
+			issues[i].Severity = strings.ToUpper(rule.Severity)
+			break
+		}
+	}
+}
+
+func overrideMatches(rule config.SeverityOverride, path string, issue SecurityIssue) bool {
+	if rule.IssueID == "" && rule.Title == "" && rule.Path == "" {
+		return false
+	}
+	if rule.IssueID != "" && !strings.EqualFold(rule.IssueID, issue.IssueID) {
+		return false
+	}
+	if rule.Title != "" && !strings.Contains(strings.ToLower(issue.Title), strings.ToLower(rule.Title)) {
+		return false
+	}
+	if rule.Path != "" {
+		baseMatch, _ := filepath.Match(rule.Path, filepath.Base(path))
+		fullMatch, _ := filepath.Match(rule.Path, path)
+		if !baseMatch && !fullMatch {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPolicySeverity drops issues below the minimum severity of the policy
+// covering their file.
+func (s *Scanner) applyPolicySeverity(filePath string, issues []SecurityIssue) []SecurityIssue {
+	if len(s.policies) == 0 {
+		return issues
+	}
+	kept := issues[:0]
+	for _, issue := range issues {
+		path := filePath
+		if issue.File != "" {
+			path = issue.File
+		}
+		if p := config.PolicyFor(s.policies, s.policyRoot, path); p != nil && p.MinSeverity != "" &&
+			config.SeverityRank(issue.Severity) < config.SeverityRank(p.MinSeverity) {
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
@@ -0,0 +1,400 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Patch is the unified diff of one file, as proposed by the model.
+type Patch struct {
+	Path  string // From the +++ header without its b/ prefix; "" if the model left it out
+	Hunks []Hunk
+}
+
+// Hunk is one @@ section of a patch. Lines keep their ' ', '-' or '+'
+// prefix.
+type Hunk struct {
+	OldStart int
+	Lines    []string
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// ParsePatches reads the unified diffs in text, ignoring anything around
+// them such as markdown fences or commentary. Hunk line counts are ignored,
+// since models often get them wrong: a hunk runs until a line that isn't
+// part of a diff. A hunk without a file header belongs to a patch with an
+// empty path.
+func ParsePatches(text string) []Patch {
+	var patches []Patch
+	var hunk *Hunk
+
+	current := func() *Patch {
+		if len(patches) == 0 {
+			patches = append(patches, Patch{})
+		}
+		return &patches[len(patches)-1]
+	}
+	endHunk := func() {
+		if hunk != nil {
+			// Models often drop the space of context lines, so blank ones are
+			// taken as context; those trailing the hunk aren't part of it
+			for len(hunk.Lines) > 0 && hunk.Lines[len(hunk.Lines)-1] == " " {
+				hunk.Lines = hunk.Lines[:len(hunk.Lines)-1]
+			}
+			if len(hunk.Lines) > 0 {
+				p := current()
+				p.Hunks = append(p.Hunks, *hunk)
+			}
+		}
+		hunk = nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if hunk != nil {
+			prefix := byte(' ')
+			if line != "" {
+				prefix = line[0]
+			}
+			switch {
+			case line == "" || prefix == ' ':
+				hunk.Lines = append(hunk.Lines, " "+strings.TrimPrefix(line, " "))
+				continue
+			case prefix == '\t':
+				// A context line that lost its space
+				hunk.Lines = append(hunk.Lines, " "+line)
+				continue
+			case prefix == '-' && !(strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")):
+				hunk.Lines = append(hunk.Lines, line)
+				continue
+			case prefix == '+':
+				hunk.Lines = append(hunk.Lines, line)
+				continue
+			case prefix == '\\':
+				continue // "\ No newline at end of file"
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			endHunk()
+			patches = append(patches, Patch{Path: patchPath(lines[i+1][len("+++ "):])})
+			i++
+		case hunkHeader.MatchString(line):
+			endHunk()
+			m := hunkHeader.FindStringSubmatch(line)
+			start, _ := strconv.Atoi(m[1])
+			hunk = &Hunk{OldStart: start}
+		default:
+			endHunk()
+		}
+	}
+	endHunk()
+
+	kept := patches[:0]
+	for _, p := range patches {
+		if len(p.Hunks) > 0 {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// patchPath strips the b/ prefix and any timestamp from a +++ header path.
+func patchPath(s string) string {
+	if tab := strings.IndexByte(s, '\t'); tab >= 0 {
+		s = s[:tab]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, "b/")
+}
+
+// Apply applies p to content. Hunk line numbers are only a hint: each hunk
+// goes where its context and removed lines match the file (ignoring
+// trailing whitespace), closest to the line it names. If any hunk doesn't
+// match, nothing is applied.
+func (p Patch) Apply(content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	var out []string
+	next := 0 // First line of lines not yet copied to out
+	for i, h := range p.Hunks {
+		var old, repl []string
+		for _, l := range h.Lines {
+			switch l[0] {
+			case ' ':
+				old = append(old, l[1:])
+				repl = append(repl, l[1:])
+			case '-':
+				old = append(old, l[1:])
+			case '+':
+				repl = append(repl, l[1:])
+			}
+		}
+
+		pos := matchHunk(lines, old, h.OldStart-1, next)
+		if pos < 0 {
+			return "", fmt.Errorf("hunk %d (line %d) doesn't match the file", i+1, h.OldStart)
+		}
+		out = append(out, lines[next:pos]...)
+		out = append(out, repl...)
+		next = pos + len(old)
+	}
+	out = append(out, lines[next:]...)
+	return strings.Join(out, "\n"), nil
+}
+
+// matchHunk returns the position at or after from where old matches lines,
+// closest to want, or -1.
+func matchHunk(lines, old []string, want, from int) int {
+	if len(old) == 0 {
+		// A pure insertion without context can only go where it says
+		return max(from, min(want, len(lines)))
+	}
+	matches := func(pos int) bool {
+		if pos < from || pos+len(old) > len(lines) {
+			return false
+		}
+		for j, l := range old {
+			if strings.TrimRight(lines[pos+j], " \t\r") != strings.TrimRight(l, " \t\r") {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; d <= len(lines); d++ {
+		if matches(want - d) {
+			return want - d
+		}
+		if matches(want + d) {
+			return want + d
+		}
+	}
+	return -1
+}
+
+// Span returns the first and last line of the original file p changes.
+func (p Patch) Span() (int, int) {
+	if len(p.Hunks) == 0 {
+		return 0, 0
+	}
+	last := p.Hunks[len(p.Hunks)-1]
+	old := 0
+	for _, l := range last.Lines {
+		if l[0] != '+' {
+			old++
+		}
+	}
+	return p.Hunks[0].OldStart, last.OldStart + max(old, 1) - 1
+}
+
+// String renders the hunks of p, with their line counts recomputed.
+func (p Patch) String() string {
+	var b strings.Builder
+	delta := 0
+	for _, h := range p.Hunks {
+		oldCount, newCount := 0, 0
+		for _, l := range h.Lines {
+			if l[0] != '+' {
+				oldCount++
+			}
+			if l[0] != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", h.OldStart, oldCount, h.OldStart+delta, newCount)
+		delta += newCount - oldCount
+		for _, l := range h.Lines {
+			b.WriteString(l + "\n")
+		}
+	}
+	return b.String()
+}
+
+// applyPatch applies p to the file at filePath.
+func applyPatch(filePath string, p Patch) error {
+	if SafeMode() {
+		return ErrSafeMode
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	// Writing the change would silently convert the file to UTF-8
+	if _, encoding, err := decodeSource(content); err != nil || encoding != "" {
+		return fmt.Errorf("file is not UTF-8; apply the change by hand")
+	}
+	patched, err := p.Apply(string(content))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, []byte(patched), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// showPatch prints a unified diff with removed lines in red and added ones
+// in green.
+func showPatch(diff string) {
+	fmt.Printf("\n\033[38;5;208m━━━ Proposed Change ━━━\033[0m\n\n")
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			fmt.Printf("\033[36m%s\033[0m\n", line)
+		case strings.HasPrefix(line, "+"):
+			fmt.Printf("\033[38;5;82m%s\033[0m\n", line)
+		case strings.HasPrefix(line, "-"):
+			fmt.Printf("\033[38;5;203m%s\033[0m\n", line)
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+// fixChange returns the part of the file issue's suggested fix changes:
+// lines start to end (1-based, inclusive; end is start-1 when the fix only
+// inserts) and what replaces them. Models often repeat the code around the
+// flagged lines in a fix, so lines it shares with the file just before or
+// after them (ignoring whitespace) are taken as unchanged rather than
+// duplicated or re-indented. Any other lines of a fix longer than the lines
+// it replaces are inserted; lines outside the flagged range are never
+// replaced. ok is false when the fix changes nothing.
+func fixChange(lines []string, issue SecurityIssue) (start, end int, repl []string, ok bool) {
+	start, end, repl = FixEdit(lines, issue)
+	front := 0
+	for k := min(len(repl), start-1); k > 0; k-- {
+		if sameLines(lines[start-1-k:start-1], repl[:k]) {
+			front = k
+			break
+		}
+	}
+	start -= front
+	for k := min(len(repl)-front, len(lines)-end); k > 0; k-- {
+		if sameLines(lines[end:end+k], repl[len(repl)-k:]) {
+			end += k
+			break
+		}
+	}
+
+	for start <= end && len(repl) > 0 && sameLine(lines[start-1], repl[0]) {
+		start++
+		repl = repl[1:]
+	}
+	for start <= end && len(repl) > 0 && sameLine(lines[end-1], repl[len(repl)-1]) {
+		end--
+		repl = repl[:len(repl)-1]
+	}
+	return start, end, repl, start <= end || len(repl) > 0
+}
+
+// fixPatch returns issue's suggested fix as a patch of lines, with context
+// around the change so it only applies where that code still is.
+func fixPatch(lines []string, issue SecurityIssue) (Patch, error) {
+	start, end, repl, ok := fixChange(lines, issue)
+	if !ok {
+		return Patch{}, fmt.Errorf("the suggested fix doesn't change the code")
+	}
+	return editPatch(lines, []fileEdit{{start: start, end: end, lines: repl}}), nil
+}
+
+// editPatch turns edits to lines, sorted by line and not overlapping, into
+// a patch with diffContext lines of context. Edits whose context overlaps
+// share a hunk.
+func editPatch(lines []string, edits []fileEdit) Patch {
+	var p Patch
+	// A final newline leaves an empty last element that isn't a line
+	n := len(lines)
+	if n > 1 && lines[n-1] == "" {
+		n--
+	}
+
+	for i := 0; i < len(edits); {
+		j := i + 1
+		for j < len(edits) && edits[j].start-edits[j-1].end-1 <= 2*diffContext {
+			j++
+		}
+		from := max(1, edits[i].start-diffContext)
+		to := min(n, edits[j-1].end+diffContext)
+
+		h := Hunk{OldStart: from}
+		line := from
+		for _, e := range edits[i:j] {
+			for ; line < e.start; line++ {
+				h.Lines = append(h.Lines, " "+lines[line-1])
+			}
+			h.Lines = append(h.Lines, diffLines(lines[e.start-1:e.end], e.lines)...)
+			line = e.end + 1
+		}
+		for ; line <= to; line++ {
+			h.Lines = append(h.Lines, " "+lines[line-1])
+		}
+		p.Hunks = append(p.Hunks, h)
+		i = j
+	}
+	return p
+}
+
+// diffLines returns the shortest edit script from old to new as diff lines
+// prefixed ' ', '-' or '+', matching lines that differ only in whitespace.
+// Matched lines keep their old text.
+func diffLines(old, new []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of old[i:]
+	// and new[j:]
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if sameLine(old[i], new[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && sameLine(old[i], new[j]):
+			out = append(out, " "+old[i])
+			i++
+			j++
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "-"+old[i])
+			i++
+		default:
+			out = append(out, "+"+new[j])
+			j++
+		}
+	}
+	return out
+}
+
+// sameLines reports whether a and b are the same lines, ignoring whitespace.
+func sameLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameLine(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameLine reports whether two lines differ only in whitespace.
+func sameLine(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
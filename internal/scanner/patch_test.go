@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFixPatchLongerThanFlaggedRange(t *testing.T) {
+	src := strings.Join([]string{
+		"func list(db *sql.DB, id string) error {",
+		"\tq := \"SELECT * FROM users WHERE id = \" + id",
+		"\trows, _ := db.Query(q)",
+		"\tdefer rows.Close()",
+		"\tprocess(rows)",
+		"\tlog(\"done\")",
+		"\treturn nil",
+		"}",
+		"",
+	}, "\n")
+	issue := SecurityIssue{
+		LineStart: 3,
+		LineEnd:   3,
+		SuggestedFix: strings.Join([]string{
+			"rows, err := db.Query(\"SELECT * FROM users WHERE id = ?\", id)",
+			"if err != nil {",
+			"\treturn err",
+			"}",
+		}, "\n"),
+	}
+
+	patch, err := fixPatch(strings.Split(src, "\n"), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := patch.Apply(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"func list(db *sql.DB, id string) error {",
+		"\tq := \"SELECT * FROM users WHERE id = \" + id",
+		"\trows, err := db.Query(\"SELECT * FROM users WHERE id = ?\", id)",
+		"\tif err != nil {",
+		"\t\treturn err",
+		"\t}",
+		"\tdefer rows.Close()",
+		"\tprocess(rows)",
+		"\tlog(\"done\")",
+		"\treturn nil",
+		"}",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("fixed file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFixChangeRepeatedContext(t *testing.T) {
+	lines := []string{"a := 1", "b := query(a)", "c := 3"}
+	issue := SecurityIssue{LineStart: 2, LineEnd: 2, SuggestedFix: "a := 1\nb := safeQuery(a)\nc := 3"}
+
+	start, end, repl, ok := fixChange(lines, issue)
+	if !ok || start != 2 || end != 2 || len(repl) != 1 || repl[0] != "b := safeQuery(a)" {
+		t.Errorf("fixChange = %d, %d, %q, %v; want 2, 2, [\"b := safeQuery(a)\"], true", start, end, repl, ok)
+	}
+}
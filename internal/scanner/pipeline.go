@@ -0,0 +1,327 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/ui"
+)
+
+const (
+	// DefaultConcurrency is the number of workers per pipeline step when
+	// none is configured.
+	DefaultConcurrency = 3
+	// MaxConcurrency bounds the workers per pipeline step.
+	MaxConcurrency = config.MaxConcurrency
+)
+
+// maxStatusWidth bounds the combined per-worker status shown on the spinner,
+// in terminal columns.
+const maxStatusWidth = 160
+
+// PipelineEngine is a FileEngine whose per-file work is split into steps,
+// typically one per model call. Each step gets its own workers, so while one
+// file waits on its second call the next file's first call is already
+// queued and the model is never left idle between a file's stages.
+type PipelineEngine interface {
+	FileEngine
+	Steps() []PipelineStep
+}
+
+// PipelineStep is one step of a PipelineEngine. It receives the state
+// returned by the previous step (nil for the first) and returns the state
+// for the next one; the last step must return a ScanResult.
+type PipelineStep func(s *Scanner, filePath string, content []byte, state interface{}, progress *Progress) (interface{}, error)
+
+// pipelineJob carries one file through the pipeline.
+type pipelineJob struct {
+	filePath string
+	content  []byte
+	progress *Progress
+	state    interface{}
+	result   ScanResult
+	err      error
+	done     bool   // Skipped or failed; later steps pass it through
+	finish   func() // Releases the file's deadline once it is in flight
+	cacheKey string // Result cache key, when the result is to be cached
+}
+
+// scanPipelined scans files with engine. Reading a file is the first step;
+// plain FileEngines then run ScanFile as a single step, PipelineEngines run
+// each of their steps in turn. Files that fail are reported and left out.
+func (s *Scanner) scanPipelined(engine FileEngine, files []string) []ScanResult {
+	steps := []PipelineStep{func(s *Scanner, filePath string, content []byte, _ interface{}, progress *Progress) (interface{}, error) {
+		return engine.ScanFile(s, filePath, content, progress)
+	}}
+	if pe, ok := engine.(PipelineEngine); ok {
+		steps = pe.Steps()
+	}
+
+	// Streamed answers are printed in file order, one file at a time
+	workers := s.workers()
+	if s.stream != nil {
+		workers = 1
+	}
+
+	// Progress tracking with single spinner showing every worker's status
+	var started int
+	var progressMu sync.Mutex
+	spinner := ui.NewSpinner("")
+	board := &statusBoard{spinner: spinner, lines: make(map[string]string), quiet: s.stream != nil}
+
+	// One channel in front of each step, plus one for finished files. They
+	// hold about a job per worker, so files are read as Stage 1 takes them
+	// rather than all ahead of the model
+	queues := make([]chan *pipelineJob, len(steps)+1)
+	for i := range queues {
+		queues[i] = make(chan *pipelineJob, workers)
+	}
+
+	// Read files into the first queue
+	var readers sync.WaitGroup
+	paths := make(chan string, workers)
+	for w := 0; w < workers; w++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for filePath := range paths {
+				// Once the scan is cancelled or stopped, queued files are not
+				// started
+				err := s.scanContext().Err()
+				if err == nil && s.stopped.Load() {
+					err = ErrStopped
+				}
+				if err != nil {
+					queues[0] <- &pipelineJob{filePath: filePath, err: err, done: true, finish: func() {}}
+					continue
+				}
+				progressMu.Lock()
+				started++
+				if started == 1 && s.stream == nil {
+					spinner.Start()
+				}
+				stagesPerFile := engine.Stages()
+				progress := &Progress{stage: (started - 1) * stagesPerFile, total: len(files) * stagesPerFile, update: board.updater(filePath)}
+				progressMu.Unlock()
+
+				content, result, err := s.readScanFile(filePath, progress)
+				queues[0] <- &pipelineJob{
+					filePath: filePath,
+					content:  content,
+					progress: progress,
+					result:   result,
+					err:      err,
+					done:     err != nil || content == nil,
+					finish:   func() {},
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, f := range files {
+			paths <- f
+		}
+		close(paths)
+	}()
+	go func() {
+		readers.Wait()
+		close(queues[0])
+	}()
+
+	// Run each step with its own workers
+	for i, step := range steps {
+		in, out := queues[i], queues[i+1]
+		first, last := i == 0, i == len(steps)-1
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range in {
+					// A file is in flight, and its deadline runs, from its
+					// first step; after Stop, files still waiting are dropped
+					if first && !job.done {
+						if s.stopped.Load() {
+							job.err, job.done = ErrStopped, true
+						} else if key, ok := s.resultCacheKey(job.filePath); ok {
+							if result, hit := s.cachedResult(key, job.filePath); hit {
+								job.result, job.content, job.done = result, nil, true
+								s.cacheHits.Add(1)
+							} else {
+								job.cacheKey = key
+							}
+						}
+						if !job.done {
+							job.finish = s.startFile(job.filePath)
+						}
+					}
+					if !job.done {
+						if err := s.contextFor(job.filePath).Err(); err != nil {
+							job.err, job.done = err, true
+						} else {
+							s.runPipelineStep(step, job, last)
+						}
+					}
+					out <- job
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+	}
+
+	results := make([]ScanResult, 0)
+	for job := range queues[len(steps)] {
+		job.finish()
+		board.done(job.filePath)
+		if job.err != nil {
+			switch {
+			case s.scanContext().Err() != nil, errors.Is(job.err, ErrStopped):
+				// The scan was cancelled or stopped; ScanFiles reports that once
+			case errors.Is(job.err, context.DeadlineExceeded):
+				ui.Eprintf("⚠️  Failed to scan %s: timed out after %s\n", job.filePath, s.fileTimeout)
+				s.recordFailure(job.filePath, fmt.Sprintf("timed out after %s", s.fileTimeout))
+			default:
+				ui.Eprintf("⚠️  Failed to scan %s: %v\n", job.filePath, job.err)
+				s.recordFailure(job.filePath, job.err.Error())
+			}
+			continue
+		}
+		// Always append results (even with no issues)
+		results = append(results, job.result)
+	}
+	spinner.Stop()
+
+	return results
+}
+
+// runPipelineStep runs one step on job, storing the result after the last.
+func (s *Scanner) runPipelineStep(step PipelineStep, job *pipelineJob, last bool) {
+	state, err := step(s, job.filePath, job.content, job.state, job.progress)
+	if err != nil {
+		job.err = err
+		job.done = true
+		return
+	}
+	job.state = state
+	if !last {
+		return
+	}
+
+	result, ok := state.(ScanResult)
+	if !ok {
+		job.err = fmt.Errorf("pipeline step returned %T, not a scan result", state)
+		return
+	}
+	result.Quarantined = job.result.Quarantined
+	result.Generated = job.result.Generated
+	tagGenerated(&result)
+	job.result = result
+	job.content = nil
+	s.recordUsage(&job.result)
+	if job.cacheKey != "" {
+		s.cacheResult(job.cacheKey, job.result)
+	}
+}
+
+// workers returns the configured number of workers per pipeline step.
+func (s *Scanner) workers() int {
+	if s.concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return minInt(s.concurrency, MaxConcurrency)
+}
+
+// SetConcurrency sets how many files are worked on at once per pipeline
+// step. Values outside 1..MaxConcurrency use the default or the maximum.
+func (s *Scanner) SetConcurrency(n int) {
+	s.concurrency = n
+}
+
+// statusBoard combines the latest status of every file in flight into one
+// spinner message, e.g. "[2/6] Identifying ... a.go · [4/6] Reading b.go".
+type statusBoard struct {
+	mu      sync.Mutex
+	spinner *ui.Spinner
+	order   []string // Files in flight, oldest first
+	lines   map[string]string
+	quiet   bool
+}
+
+// updater returns the Progress callback for filePath.
+func (b *statusBoard) updater(filePath string) func(string) {
+	return func(msg string) {
+		if b.quiet {
+			return
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.lines[filePath]; !ok {
+			b.order = append(b.order, filePath)
+		}
+		b.lines[filePath] = msg
+		if ui.Plain() {
+			// One line per change; a combined line would repeat every worker
+			b.spinner.UpdateMessage(msg)
+			return
+		}
+		b.render()
+	}
+}
+
+// done removes a finished file from the board.
+func (b *statusBoard) done(filePath string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.lines[filePath]; !ok {
+		return
+	}
+	delete(b.lines, filePath)
+	for i, f := range b.order {
+		if f == filePath {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+	if !b.quiet && !ui.Plain() && len(b.order) > 0 {
+		b.render()
+	}
+}
+
+func (b *statusBoard) render() {
+	parts := make([]string, len(b.order))
+	for i, f := range b.order {
+		parts[i] = b.lines[f]
+	}
+	// Wide characters (CJK, emoji) take two columns
+	b.spinner.UpdateMessage(ui.Truncate(strings.Join(parts, " · "), maxStatusWidth))
+}
+
+// recordFailure notes that filePath failed to scan, and why.
+func (s *Scanner) recordFailure(filePath, reason string) {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+	if s.failed == nil {
+		s.failed = make(map[string]string)
+	}
+	s.failed[filePath] = reason
+}
+
+// Failed returns the files that failed to scan, e.g. on a timeout or an
+// answer that wasn't valid JSON, mapped to why. Files of a cancelled or
+// stopped scan that never finished are not included.
+func (s *Scanner) Failed() map[string]string {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+	failed := make(map[string]string, len(s.failed))
+	for file, reason := range s.failed {
+		failed[file] = reason
+	}
+	return failed
+}
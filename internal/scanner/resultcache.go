@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/ollama"
+)
+
+// PromptVersion identifies the scan prompts. Bump it whenever a prompt or
+// the parsing of its answer changes, so cached results from the old
+// prompts are not reused.
+const PromptVersion = "3"
+
+// SetResultCache enables reusing the results of earlier scans of files
+// whose content, model, scan type, prompts and settings are unchanged.
+// Leave it off for mock, recorded or replayed sessions.
+func (s *Scanner) SetResultCache(enabled bool) {
+	s.resultCache = enabled
+}
+
+// ResultCacheDir returns the directory holding cached scan results.
+func ResultCacheDir() (string, error) {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "results"), nil
+}
+
+// resultCacheKey returns the cache key of filePath: its content and
+// path-dependent settings, as for deduplication, plus everything else that
+// shapes the findings. ok is false for files that aren't cached.
+func (s *Scanner) resultCacheKey(filePath string) (string, bool) {
+	if !s.resultCache {
+		return "", false
+	}
+	key, ok := s.dedupKeys[filePath]
+	if !ok {
+		if key, ok = s.dedupKey(filePath); !ok {
+			return "", false
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(key)
+	fmt.Fprintf(&b, "|prompt=%s|type=%s|model=%s|custom=%q", PromptVersion, s.scanType, s.modelName, s.customPrompt)
+	fmt.Fprintf(&b, "|gen=%s|schema=%t|quarantine=%t|fast=%t", s.client.Options(), s.structuredOutput, s.quarantine, s.fast)
+	fmt.Fprintf(&b, "|chunk=%d/%d|funcs=%t", s.chunkSize, s.chunkOverlap, s.diffFunctions)
+	fmt.Fprintf(&b, "|samples=%d|ensemble=%v/%s", s.samples, s.ensembleModels, s.ensembleMode)
+	fmt.Fprintf(&b, "|gate=%s/%s/%s|overrides=%v", s.staticGate, s.gateModel, s.gateSeverity, s.severityOverrides)
+	if cross := s.projectContext.For(filePath); cross != "" {
+		fmt.Fprintf(&b, "|crossfile=%x", sha256.Sum256([]byte(cross)))
+	}
+	if s.scanType == "license" {
+		fmt.Fprintf(&b, "|license=%s", s.projectLicense(filePath))
+	}
+	names := make([]string, 0, len(s.orgContext))
+	for name := range s.orgContext {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "|ctx.%s=%q", name, s.orgContext[name])
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// cachedResult returns the cached result for key, relabelled for filePath.
+func (s *Scanner) cachedResult(key, filePath string) (ScanResult, bool) {
+	dir, err := ResultCacheDir()
+	if err != nil {
+		return ScanResult{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return ScanResult{}, false
+	}
+	var result ScanResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return ScanResult{}, false
+	}
+	result.Cached = true
+	return s.relabel(result, filePath), true
+}
+
+// cacheResult saves the result scanned for key. Failures only cost a scan
+// next time, so they are logged and otherwise ignored.
+func (s *Scanner) cacheResult(key string, result ScanResult) {
+	result.Usage = ollama.TokenUsage{}
+	result.Streamed = false
+	data, err := json.Marshal(result)
+	if err == nil {
+		var dir string
+		if dir, err = ResultCacheDir(); err == nil {
+			if err = os.MkdirAll(dir, 0700); err == nil {
+				err = os.WriteFile(filepath.Join(dir, key+".json"), data, 0600)
+			}
+		}
+	}
+	if err != nil {
+		s.logDebug("RESULT CACHE WRITE FAILED", err.Error())
+	}
+}
+
+// relabel returns result as the result of filePath, which has the same
+// content: findings get the ownership and blame of filePath's own lines.
+func (s *Scanner) relabel(result ScanResult, filePath string) ScanResult {
+	result.FilePath = filePath
+	result.Usage = ollama.TokenUsage{}
+	result.Streamed = false
+	issues := make([]SecurityIssue, len(result.Issues))
+	copy(issues, result.Issues)
+	for i := range issues {
+		issues[i].Author, issues[i].Commit, issues[i].Owner = "", "", ""
+		if s.codeOwners != nil {
+			issues[i].Owner = s.codeOwners.OwnerOf(filePath)
+		}
+	}
+	if s.blame {
+		annotateBlame(filePath, issues)
+	}
+	result.Issues = issues
+	return result
+}
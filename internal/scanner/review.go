@@ -0,0 +1,499 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pefman/sidekick/internal/audit"
+	"github.com/pefman/sidekick/internal/backup"
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/history"
+	"github.com/pefman/sidekick/internal/knowledge"
+	"github.com/pefman/sidekick/internal/ollama"
+	"github.com/pefman/sidekick/internal/ui"
+)
+
+// reviewItem is one finding under review and the file it belongs to.
+type reviewItem struct {
+	file  string
+	issue SecurityIssue
+}
+
+// ReviewFindings implements interactive review mode for security findings
+func ReviewFindings(findings []SecurityIssue, filePath string, client *ollama.Client, modelName string) error {
+	// Sort findings by line_start in DESCENDING order
+	// This way we apply fixes from bottom to top, preventing line number shifts
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].LineStart > findings[j].LineStart
+	})
+
+	items := make([]reviewItem, len(findings))
+	for i, f := range findings {
+		items[i] = reviewItem{file: filePath, issue: f}
+	}
+	return reviewItems(items)
+}
+
+// ReviewSession reviews every finding of a scan in one session, highest
+// score first, switching file as it goes. Fixes that change a file's length
+// shift the remaining findings in that file accordingly.
+func ReviewSession(results []ScanResult) error {
+	var items []reviewItem
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			file := issue.File
+			if file == "" {
+				file = result.FilePath
+			}
+			items = append(items, reviewItem{file: file, issue: issue})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		si, sj := items[i].issue.Score(), items[j].issue.Score()
+		if si != sj {
+			return si > sj
+		}
+		if items[i].file != items[j].file {
+			return items[i].file < items[j].file
+		}
+		return items[i].issue.LineStart > items[j].issue.LineStart
+	})
+	return reviewItems(items)
+}
+
+func reviewItems(items []reviewItem) error {
+	if len(items) == 0 {
+		fmt.Println("No findings to review.")
+		return nil
+	}
+
+	keys := reviewKeymap()
+	recheckDays := config.DefaultRecheckDays
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		recheckDays = cfg.Recheck()
+	}
+	input := newReviewInput(keys)
+	defer input.Close()
+	currentIdx := 0
+	appliedFixes := make(map[int]bool)
+	backups := backup.NewSession()
+	backedUp := make(map[string]bool)
+	auditLog := audit.New(backups.ID)
+	decisions := make(map[int]string)
+	auditPath := ""
+	contents := make(map[string][]byte)
+
+	// Read each file once, on first use
+	load := func(filePath string) ([]byte, error) {
+		if content, ok := contents[filePath]; ok {
+			return content, nil
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		contents[filePath] = content
+		return content, nil
+	}
+
+	pause := input.pause
+
+	// Back up each file before its first change
+	backupFile := func(filePath string, content []byte) bool {
+		if backedUp[filePath] {
+			return true
+		}
+		backupPath, err := backups.Save(filePath, content)
+		if err != nil {
+			fmt.Printf("\n\033[38;5;203m✗ Failed to create backup: %v\033[0m\n", err)
+			pause()
+			return false
+		}
+		backedUp[filePath] = true
+		fmt.Printf("\n\033[38;5;82m✓ Backup saved: %s (undo with: sidekick restore %s)\033[0m\n", backupPath, backups.ID)
+		return true
+	}
+
+	// Log every decision for the audit trail
+	record := func(idx int, decision string) {
+		decisions[idx] = decision
+		item := items[idx]
+		d := audit.Decision{
+			Decision:    decision,
+			Fingerprint: item.issue.Fingerprint(item.file),
+			File:        item.file,
+			LineStart:   item.issue.LineStart,
+			LineEnd:     item.issue.LineEnd,
+			Severity:    item.issue.Severity,
+			Title:       item.issue.Title,
+			IssueID:     item.issue.IssueID,
+		}
+		if backedUp[item.file] {
+			d.Backup = backups.ID
+		}
+		path, err := auditLog.Record(d)
+		if err != nil {
+			fmt.Printf("\n\033[38;5;203m⚠ %v\033[0m\n", err)
+			return
+		}
+		auditPath = path
+	}
+	defer func() {
+		if auditPath != "" {
+			fmt.Printf("📝 Review decisions logged to %s\n", auditPath)
+		}
+	}()
+
+	for {
+		item := &items[currentIdx]
+		issue := item.issue
+		filePath := item.file
+
+		content, err := load(filePath)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(content), "\n")
+
+		// Clear screen
+		fmt.Print("\033[H\033[2J")
+
+		// Header
+		fmt.Printf("\n\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m\n")
+		fmt.Printf("\033[38;5;208m📋 Review Mode\033[0m - Finding %d of %d\n", currentIdx+1, len(items))
+		fmt.Printf("\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m\n\n")
+
+		// Display issue details
+		severityColor := getSeverityColor(issue.Severity)
+		fmt.Printf("%s %s: %s\033[0m\n", severityColor, issue.Severity, issue.Title)
+		fmt.Printf("📁 File: \033[36m%s\033[0m\n", ui.Hyperlink(ui.EditorURL(filePath, issue.LineStart), displayPath(filePath)))
+		fmt.Printf("📍 Lines: \033[36m%d-%d\033[0m", issue.LineStart, issue.LineEnd)
+		if issue.Confidence != "" {
+			fmt.Printf(" | Confidence: %s", issue.Confidence)
+		}
+		if issue.IssueID != "" {
+			fmt.Printf(" | %s", issue.IssueID)
+		}
+		if issue.CVSSVector != "" {
+			fmt.Printf(" | CVSS %.1f", issue.CVSSScore)
+		}
+		fmt.Print("\n")
+		if ref, ok := knowledge.Lookup(issue.IssueID); ok {
+			fmt.Printf("📚 Reference: %s\n", referenceLine(ref))
+		}
+		fmt.Print("\n")
+		if issue.Resurfaced {
+			fmt.Printf("⏰ Marked false positive earlier; the suppression expired, so check it again\n\n")
+		}
+		if issue.Generated {
+			fmt.Printf("🏭 Generated code: a fix here is overwritten when the file is regenerated\n\n")
+		}
+
+		fmt.Printf("📝 Description:\n%s\n\n", wrapText(issue.Description, 70))
+		fmt.Printf("💡 Recommendation:\n%s\n\n", wrapText(issue.Recommendation, 70))
+
+		// Show code context
+		fmt.Printf("\033[38;5;208m━━━ Current Code ━━━\033[0m\n")
+		showCodeContext(lines, issue.LineStart, issue.LineEnd)
+
+		// Show fix status and diff
+		if decisions[currentIdx] == audit.Edited {
+			fmt.Printf("\n\033[38;5;82m✓ Edited by hand\033[0m\n")
+		} else if appliedFixes[currentIdx] {
+			fmt.Printf("\n\033[38;5;82m✓ Fix already applied to this issue\033[0m\n")
+		} else if decisions[currentIdx] == audit.FalsePositive {
+			fmt.Printf("\n\033[38;5;203m✗ Marked as false positive\033[0m\n")
+		} else if issue.FixAvailable {
+			// Show diff by default if reasonable size
+			if patch, err := fixPatch(lines, prepareFix(issue)); err != nil {
+				fmt.Printf("\n\033[38;5;203m⚠ Suggested fix can't be applied: %v\033[0m\n", err)
+			} else if diff := patch.String(); strings.Count(diff, "\n") <= 100 {
+				fmt.Printf("\n\033[38;5;82m✓ Suggested fix available\033[0m\n")
+				showPatch(diff)
+			} else {
+				fmt.Printf("\n\033[38;5;82m✓ Suggested fix available\033[0m\n")
+				fmt.Printf("\n\033[38;5;203m(Diff too large - use [%s] to show)\033[0m\n", keys.Diff[0])
+			}
+		} else {
+			fmt.Printf("\n\033[38;5;203m⚠ No automatic fix available - manual review required\033[0m\n")
+		}
+
+		// Action menu
+		fmt.Printf("\n\033[38;5;208m━━━ Actions ━━━\033[0m\n")
+		if issue.FixAvailable && !appliedFixes[currentIdx] && SafeMode() {
+			fmt.Printf("  🔒 Safe mode: fixes are not applied in untrusted workspaces\n")
+			fmt.Printf("  [%s] Show diff\n", keys.Diff[0])
+		} else if issue.FixAvailable && !appliedFixes[currentIdx] {
+			fmt.Printf("  [%s] Apply fix\n", keys.Apply[0])
+			fmt.Printf("  [%s] Show diff\n", keys.Diff[0])
+		}
+		if !appliedFixes[currentIdx] {
+			fmt.Printf("  [%s] Edit the file yourself\n", keys.Edit[0])
+		}
+		fmt.Printf("  [%s] Ignore (skip this finding)\n", keys.Ignore[0])
+		fmt.Printf("  [%s] Mark as false positive\n", keys.FalsePositive[0])
+		if currentIdx < len(items)-1 {
+			fmt.Printf("  [%s/→] Next finding\n", keys.Next[0])
+		}
+		if currentIdx > 0 {
+			fmt.Printf("  [%s/←] Previous finding\n", keys.Previous[0])
+		}
+		fmt.Printf("  [%s] Quit review mode\n", keys.Quit[0])
+		if len(items) > 1 {
+			fmt.Printf("  [1-%d] Jump to finding (then Enter)\n", len(items))
+		}
+		fmt.Printf("\n\033[38;5;208mChoice:\033[0m ")
+
+		choice, jump, err := input.command()
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+
+		switch choice {
+		case "a":
+			if SafeMode() {
+				fmt.Printf("\n\033[38;5;203m⚠ %v\033[0m\n", ErrSafeMode)
+				pause()
+				continue
+			}
+			if !issue.FixAvailable {
+				fmt.Println("\n\033[38;5;203m⚠ No fix available for this issue\033[0m")
+				pause()
+				continue
+			}
+			if appliedFixes[currentIdx] {
+				fmt.Println("\n\033[38;5;203m⚠ Fix already applied\033[0m")
+				pause()
+				continue
+			}
+
+			// The fix is a patch against the code shown; it is refused if
+			// the file no longer matches
+			issue = prepareFix(issue)
+			patch, err := fixPatch(lines, issue)
+			if err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ Failed to apply fix: %v\033[0m\n", err)
+				pause()
+				continue
+			}
+
+			if !backupFile(filePath, content) {
+				continue
+			}
+
+			// Apply the fix to the file
+			if err := applyPatch(filePath, patch); err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ Failed to apply fix: %v\033[0m\n", err)
+				pause()
+				continue
+			}
+
+			// Mark as applied and reload content for next fixes
+			appliedFixes[currentIdx] = true
+			record(currentIdx, audit.Applied)
+			delete(contents, filePath)
+			newContent, err := load(filePath)
+			if err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ Failed to reload file: %v\033[0m\n", err)
+				return err
+			}
+
+			// Keep later findings in this file pointing at the same code
+			delta := len(strings.Split(string(newContent), "\n")) - len(lines)
+			shiftFindings(items, filePath, issue.LineEnd, delta, appliedFixes)
+
+			fmt.Printf("\n\033[38;5;82m✓ Fix applied successfully!\033[0m\n")
+
+			// Auto-advance to next finding
+			if currentIdx < len(items)-1 {
+				currentIdx++
+				fmt.Println("\033[38;5;82mMoving to next finding...\033[0m")
+				time.Sleep(800 * time.Millisecond)
+			} else {
+				fmt.Println("\n\033[38;5;82mAll findings reviewed!\033[0m")
+				return nil
+			}
+
+		case "s":
+			if !issue.FixAvailable {
+				fmt.Println("\n\033[38;5;203m⚠ No fix available to show\033[0m")
+				pause()
+				continue
+			}
+
+			patch, err := fixPatch(lines, prepareFix(issue))
+			if err != nil {
+				fmt.Printf("\n\033[38;5;203m⚠ %v\033[0m\n", err)
+			} else {
+				showPatch(patch.String())
+			}
+			fmt.Println()
+			pause()
+
+		case "e":
+			if appliedFixes[currentIdx] {
+				fmt.Println("\n\033[38;5;203m⚠ Fix already applied\033[0m")
+				pause()
+				continue
+			}
+			if !backupFile(filePath, content) {
+				continue
+			}
+			input.suspend()
+			err := openEditor(filePath, issue.LineStart)
+			input.resume()
+			if err != nil {
+				fmt.Printf("\n\033[38;5;203m✗ %v\033[0m\n", err)
+				pause()
+				continue
+			}
+
+			delete(contents, filePath)
+			newContent, err := load(filePath)
+			if err != nil {
+				return err
+			}
+			if string(newContent) == string(content) {
+				fmt.Println("\n\033[38;5;203m⚠ File unchanged\033[0m")
+				pause()
+				continue
+			}
+			appliedFixes[currentIdx] = true
+			record(currentIdx, audit.Edited)
+			delta := len(strings.Split(string(newContent), "\n")) - len(lines)
+			shiftFindings(items, filePath, issue.LineEnd, delta, appliedFixes)
+
+		case "f":
+			fmt.Printf("\nRe-check in how many days? (Enter for %d, 0 = never): ", recheckDays)
+			days := input.number(recheckDays)
+			record(currentIdx, audit.FalsePositive)
+			if err := suppress(*item, days); err != nil {
+				fmt.Printf("\n\033[38;5;203m⚠ %v\033[0m\n", err)
+				pause()
+			} else if days > 0 {
+				fmt.Printf("\n\033[38;5;82m✓ Marked as false positive; hidden from scans until %s\033[0m\n", time.Now().AddDate(0, 0, days).Format("2006-01-02"))
+			} else {
+				fmt.Printf("\n\033[38;5;82m✓ Marked as false positive; hidden from scans\033[0m\n")
+			}
+			if currentIdx < len(items)-1 {
+				currentIdx++
+			} else {
+				fmt.Println("No more findings. Exiting review mode.")
+				return nil
+			}
+
+		case "i":
+			record(currentIdx, audit.Ignored)
+			fmt.Printf("\n\033[38;5;82m✓ Ignoring this finding\033[0m\n")
+			if currentIdx < len(items)-1 {
+				currentIdx++
+			} else {
+				fmt.Println("No more findings. Exiting review mode.")
+				return nil
+			}
+
+		case "n":
+			if currentIdx < len(items)-1 {
+				currentIdx++
+			} else {
+				fmt.Println("\nAlready at last finding")
+				pause()
+			}
+
+		case "p":
+			if currentIdx > 0 {
+				currentIdx--
+			} else {
+				fmt.Println("\nAlready at first finding")
+				pause()
+			}
+
+		case "g":
+			if jump < 1 || jump > len(items) {
+				fmt.Printf("\n\033[38;5;203m⚠ No finding %d (1-%d)\033[0m\n", jump, len(items))
+				pause()
+				continue
+			}
+			currentIdx = jump - 1
+
+		case "q":
+			fmt.Println("\n\033[38;5;208m👋 Exiting review mode\033[0m")
+			return nil
+
+		default:
+			fmt.Println("\n\033[38;5;203m⚠ Invalid choice\033[0m")
+			pause()
+		}
+	}
+}
+
+// openEditor opens filePath at line in $VISUAL or $EDITOR (vi by default).
+func openEditor(filePath string, line int) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	args := append(fields[1:], fmt.Sprintf("+%d", line), filePath)
+	cmd := exec.Command(fields[0], args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	return nil
+}
+
+// suppress hides item's finding from later scans as a false positive, for
+// days (forever when 0).
+func suppress(item reviewItem, days int) error {
+	now := time.Now()
+	s := history.Suppression{
+		Fingerprint: item.issue.Fingerprint(item.file),
+		File:        item.file,
+		Title:       item.issue.Title,
+		Severity:    item.issue.Severity,
+		IssueID:     item.issue.IssueID,
+		Reason:      audit.FalsePositive,
+		Created:     now,
+	}
+	if days > 0 {
+		expires := now.AddDate(0, 0, days)
+		s.Expires = &expires
+	}
+	return history.Suppress(s)
+}
+
+// shiftFindings moves unapplied findings in filePath that start after line
+// by delta lines.
+func shiftFindings(items []reviewItem, filePath string, line, delta int, applied map[int]bool) {
+	if delta == 0 {
+		return
+	}
+	for i := range items {
+		if applied[i] || items[i].file != filePath || items[i].issue.LineStart <= line {
+			continue
+		}
+		items[i].issue.LineStart += delta
+		items[i].issue.LineEnd += delta
+	}
+}
+
+// displayPath shows filePath relative to the working directory when shorter.
+func displayPath(filePath string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return filePath
+}
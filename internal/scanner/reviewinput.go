@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eiannone/keyboard"
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// reviewInput reads review mode commands. On a terminal every command is a
+// single key press, like the interactive menus; when stdin isn't a terminal
+// it falls back to reading a line per command.
+type reviewInput struct {
+	keys     config.ReviewKeymap
+	keyboard bool
+	reader   *bufio.Reader
+}
+
+func newReviewInput(keys config.ReviewKeymap) *reviewInput {
+	in := &reviewInput{keys: keys}
+	if err := keyboard.Open(); err == nil {
+		in.keyboard = true
+	} else {
+		in.reader = bufio.NewReader(os.Stdin)
+	}
+	return in
+}
+
+// Close restores the terminal.
+func (in *reviewInput) Close() {
+	if in.keyboard {
+		keyboard.Close()
+	}
+}
+
+// suspend hands the terminal back, e.g. to an editor, until resume.
+func (in *reviewInput) suspend() {
+	if in.keyboard {
+		keyboard.Close()
+	}
+}
+
+// resume takes the terminal back after suspend, falling back to line input
+// if that fails.
+func (in *reviewInput) resume() {
+	if !in.keyboard {
+		return
+	}
+	if err := keyboard.Open(); err != nil {
+		in.keyboard = false
+		in.reader = bufio.NewReader(os.Stdin)
+	}
+}
+
+// command reads the next command: one of the review command letters
+// (a, s, e, i, f, n, p, q), or "g" with the 1-based finding number to jump to.
+// Arrow keys move between findings and Esc quits; typing digits followed by
+// Enter jumps to that finding.
+func (in *reviewInput) command() (string, int, error) {
+	if !in.keyboard {
+		line, err := in.reader.ReadString('\n')
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read input: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if n, err := strconv.Atoi(line); err == nil {
+			return "g", n, nil
+		}
+		return reviewCommand(line, in.keys), 0, nil
+	}
+
+	digits := ""
+	for {
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		switch {
+		case char >= '0' && char <= '9':
+			digits += string(char)
+			fmt.Print(string(char))
+			continue
+		case key == keyboard.KeyBackspace || key == keyboard.KeyBackspace2:
+			if digits != "" {
+				digits = digits[:len(digits)-1]
+				fmt.Print("\b \b")
+			}
+			continue
+		case key == keyboard.KeyEnter:
+			if digits == "" {
+				continue
+			}
+			n, _ := strconv.Atoi(digits)
+			return "g", n, nil
+		}
+
+		// Any other key abandons a half-typed number
+		if digits != "" {
+			fmt.Print(strings.Repeat("\b \b", len(digits)))
+			digits = ""
+		}
+		switch key {
+		case keyboard.KeyArrowRight, keyboard.KeyArrowDown:
+			return "n", 0, nil
+		case keyboard.KeyArrowLeft, keyboard.KeyArrowUp:
+			return "p", 0, nil
+		case keyboard.KeyEsc, keyboard.KeyCtrlC:
+			return "q", 0, nil
+		}
+		if char != 0 {
+			return reviewCommand(string(char), in.keys), 0, nil
+		}
+	}
+}
+
+// number reads a non-negative number confirmed with Enter. Enter alone, or
+// input that isn't a number, returns def.
+func (in *reviewInput) number(def int) int {
+	if !in.keyboard {
+		line, _ := in.reader.ReadString('\n')
+		if n, err := strconv.Atoi(strings.TrimSpace(line)); err == nil && n >= 0 {
+			return n
+		}
+		return def
+	}
+
+	digits := ""
+	for {
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return def
+		}
+		switch {
+		case char >= '0' && char <= '9':
+			digits += string(char)
+			fmt.Print(string(char))
+		case (key == keyboard.KeyBackspace || key == keyboard.KeyBackspace2) && digits != "":
+			digits = digits[:len(digits)-1]
+			fmt.Print("\b \b")
+		case key == keyboard.KeyEnter:
+			fmt.Println()
+			if n, err := strconv.Atoi(digits); err == nil {
+				return n
+			}
+			return def
+		}
+	}
+}
+
+// pause waits for any key, or for Enter without a terminal.
+func (in *reviewInput) pause() {
+	if !in.keyboard {
+		fmt.Print("Press Enter to continue...")
+		in.reader.ReadString('\n')
+		return
+	}
+	fmt.Print("Press any key to continue...")
+	keyboard.GetKey()
+	fmt.Println()
+}
@@ -0,0 +1,23 @@
+package scanner
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrSafeMode is returned by attempts to modify files in safe mode.
+var ErrSafeMode = errors.New("safe mode: files in untrusted workspaces are not modified (trust the workspace with --trust or \"sidekick trust\")")
+
+var safeMode atomic.Bool
+
+// SetSafeMode enables or disables safe mode, used for workspaces the user
+// hasn't trusted: fixes are never applied, and the debug log is written to
+// ~/.sidekick instead of the working directory.
+func SetSafeMode(enabled bool) {
+	safeMode.Store(enabled)
+}
+
+// SafeMode reports whether safe mode is enabled.
+func SafeMode() bool {
+	return safeMode.Load()
+}
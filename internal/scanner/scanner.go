@@ -2,6 +2,8 @@ package scanner
 
 import (
 	"bufio"
+	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,158 +11,342 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/debuglog"
+	"github.com/pefman/sidekick/internal/knowledge"
 	"github.com/pefman/sidekick/internal/ollama"
 	"github.com/pefman/sidekick/internal/ui"
 )
 
+// maxFileSize is the largest file (in bytes) that will be sent to the model.
+const maxFileSize = 100000
+
+// maxTriadContext bounds the shared context passed to each triad role.
+const maxTriadContext = 16000
+
+// maxTriadFindings bounds the static findings in the triad context, leaving
+// the rest of it for code.
+const maxTriadFindings = maxTriadContext / 2
+
 type Scanner struct {
 	client       *ollama.Client
 	modelName    string
 	debug        bool
 	debugFile    *os.File
+	debugLog     *debuglog.Log
 	scanType     string
 	customPrompt string
+
+	severityOverrides []config.SeverityOverride
+	blame             bool
+	codeOwners        *CodeOwners
+	diff              *DiffChanges
+	diffFunctions     bool
+	jsonRetries       int
+	staticGate        string
+	gateModel         string
+	gateSeverity      string
+	structuredOutput  bool
+	contextCache      bool
+	resultCache       bool
+	quarantine        bool
+	includeGenerated  bool
+	fast              bool
+	chunkSize         int
+	chunkOverlap      int
+	orgContext        map[string]string
+	samples           int
+	ensembleModels    []string
+	ensembleMode      string
+
+	usageMu   sync.Mutex
+	fileUsage map[string]ollama.TokenUsage
+
+	ctx         context.Context // Of the running ScanFiles call
+	fileTimeout time.Duration
+	ctxMu       sync.Mutex
+	fileCtx     map[string]context.Context // Per-file deadlines of files in flight
+	stopped     atomic.Bool                // Stop was called; no new files start
+	cacheHits   atomic.Int32               // Files of the running scan found in the result cache
+
+	triadBudget triadBudget
+
+	policyRoot string
+	policies   []config.Policy
+
+	projectLicenses sync.Map // Workspace root -> its license, for license scans
+
+	dedupKeys map[string]string // File -> its dedupKey, from the last ScanFiles
+
+	projectContext *ProjectContext
+
+	failedMu sync.Mutex
+	failed   map[string]string // Files that failed to scan -> why
+
+	stream      func(filePath, token string)
+	concurrency int
 }
 
 type ScanResult struct {
 	FilePath    string
-	RawFindings string // Only used for custom prompts (unstructured)
+	RawFindings string // Answer of custom prompts and triad reports; structured results render Issues instead
 	HasIssues   bool
 	Issues      []SecurityIssue // Primary data structure for security scans
+	Table       *Table          // Structured custom-prompt answer, when fields were requested
+	Streamed    bool            // RawFindings was already shown as it streamed
+	Context     *FileContext    // Language and frameworks from the security scan's context analysis
+	Usage       ollama.TokenUsage
+	DuplicateOf string       // Identical file whose scan this result reuses
+	Skipped     string       // Why the file wasn't scanned, e.g. "encoding: binary data ..."
+	Cached      bool         // Reused from the result cache of an earlier scan
+	Quarantined []int        // Lines with text addressed to the model, neutralized before scanning
+	Layer       string       // Container image layer the file came from, when scanning an image
+	Generated   bool         // Generated code, scanned because generated files were included
+	License     *FileLicense // License inventory and verdict of a license scan
 }
 
 type SecurityIssue struct {
-	Severity       string `json:"severity"`
-	Title          string `json:"title"`
-	Description    string `json:"description"`
-	LineStart      int    `json:"line_start"`
-	LineEnd        int    `json:"line_end"`
-	Recommendation string `json:"recommendation"`
-	Confidence     string `json:"confidence,omitempty"`    // HIGH, MEDIUM, LOW
-	IssueID        string `json:"issue_id,omitempty"`      // e.g., "CWE-89", "OWASP-A03"
-	SuggestedFix   string `json:"suggested_fix,omitempty"` // Code to replace vulnerable code
-	FixAvailable   bool   `json:"fix_available,omitempty"` // Whether LLM provided a fix
+	File           string   `json:"file,omitempty"` // Set when a result spans multiple files (triad)
+	Severity       string   `json:"severity"`
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	LineStart      int      `json:"line_start"`
+	LineEnd        int      `json:"line_end"`
+	Recommendation string   `json:"recommendation"`
+	Confidence     string   `json:"confidence,omitempty"`    // HIGH, MEDIUM, LOW
+	IssueID        string   `json:"issue_id,omitempty"`      // e.g., "CWE-89", "OWASP-A03"
+	SuggestedFix   string   `json:"suggested_fix,omitempty"` // Code to replace vulnerable code
+	FixAvailable   bool     `json:"fix_available,omitempty"` // Whether LLM provided a fix
+	Evidence       string   `json:"evidence,omitempty"`      // Vulnerable code quoted by the LLM, used to verify line numbers
+	CodeSnippet    string   `json:"code_snippet,omitempty"`  // Flagged lines as read from the file, with line numbers
+	Author         string   `json:"author,omitempty"`        // Last author of the flagged lines (git blame)
+	Commit         string   `json:"commit,omitempty"`        // Last commit touching the flagged lines (git blame)
+	Owner          string   `json:"owner,omitempty"`         // Owning team from CODEOWNERS
+	Models         []string `json:"models,omitempty"`        // Models that reported this finding (ensemble scans)
+	ChangedLines   string   `json:"changed_lines,omitempty"` // Changed lines the finding overlaps (--diff), e.g. "12-14, 20"
+	Resurfaced     bool     `json:"resurfaced,omitempty"`    // Marked false positive, but the suppression expired
+	Generated      bool     `json:"generated,omitempty"`     // In generated code: fix the generator or its input, not the file
+	CVSSVector     string   `json:"cvss_vector,omitempty"`   // CVSS v3 base vector given by the model, validated and canonical
+	CVSSScore      float64  `json:"cvss_score,omitempty"`    // Base score computed from CVSSVector
 }
 
 func NewScanner(client *ollama.Client, modelName string, debug bool, scanType, customPrompt string) *Scanner {
 	var debugFile *os.File
+	var debugLog *debuglog.Log
 	if debug {
 		// Create debug file with timestamp
 		timestamp := time.Now().Format("20060102-150405")
 		debugPath := fmt.Sprintf("sidekick-debug-%s.log", timestamp)
+		if SafeMode() {
+			// Keep artifacts out of untrusted workspaces
+			if dir, err := config.GetDebugDir(); err == nil && os.MkdirAll(dir, 0700) == nil {
+				debugPath = filepath.Join(dir, debugPath)
+			}
+		}
 		f, err := os.Create(debugPath)
 		if err == nil {
 			debugFile = f
 			fmt.Printf("\n🔍 Debug logging enabled: %s\n\n", debugPath)
 		}
+		// The structured log read back by "sidekick debug last"
+		if l, err := debuglog.Create(); err == nil {
+			debugLog = l
+		}
 	}
 
 	return &Scanner{
-		client:       client,
-		modelName:    modelName,
-		debug:        debug,
-		debugFile:    debugFile,
-		scanType:     scanType,
-		customPrompt: customPrompt,
+		client:           client,
+		modelName:        modelName,
+		debug:            debug,
+		debugFile:        debugFile,
+		debugLog:         debugLog,
+		scanType:         scanType,
+		customPrompt:     customPrompt,
+		jsonRetries:      DefaultJSONRetries,
+		structuredOutput: true,
+		quarantine:       true,
+		fileUsage:        make(map[string]ollama.TokenUsage),
+		fileCtx:          make(map[string]context.Context),
 	}
 }
 
-func (s *Scanner) logDebug(title, content string) {
-	if s.debug && s.debugFile != nil {
-		fmt.Fprintf(s.debugFile, "\n%s\n%s\n%s\n%s\n",
-			strings.Repeat("=", 80),
-			title,
-			strings.Repeat("=", 80),
-			content)
-	}
+// generate calls the model and attributes its token usage to filePath.
+// stage names the step of the scan making the call in the debug log.
+func (s *Scanner) generate(filePath, stage, model, prompt string, opts *ollama.Options) (string, error) {
+	start := time.Now()
+	response, usage, err := s.client.GenerateDetailed(s.contextFor(filePath), model, prompt, opts)
+	s.addUsage(filePath, usage)
+	s.recordCall(filePath, stage, model, prompt, response, usage, err, time.Since(start))
+	return response, err
 }
 
-func (s *Scanner) ScanFiles(files []string) ([]ScanResult, error) {
-	if s.scanType == "triad" {
-		result, err := s.scanTriadFiles(files)
-		if err != nil {
-			return nil, err
-		}
-		return []ScanResult{result}, nil
+// generateStream is generate, passing the response to the stream callback
+// as it arrives.
+func (s *Scanner) generateStream(filePath, stage, model, prompt string, opts *ollama.Options) (string, error) {
+	start := time.Now()
+	response, usage, err := s.client.GenerateStreamDetailed(s.contextFor(filePath), model, prompt, opts, func(token string) {
+		s.stream(filePath, token)
+	})
+	s.addUsage(filePath, usage)
+	s.recordCall(filePath, stage, model, prompt, response, usage, err, time.Since(start))
+	return response, err
+}
+
+// recordCall writes a model call to the structured debug log.
+func (s *Scanner) recordCall(filePath, stage, model, prompt, response string, usage ollama.TokenUsage, err error, elapsed time.Duration) {
+	if s.debugLog == nil {
+		return
+	}
+	entry := debuglog.Entry{
+		Time:             time.Now(),
+		File:             filePath,
+		Stage:            stage,
+		Model:            model,
+		Prompt:           prompt,
+		Response:         response,
+		DurationMS:       elapsed.Milliseconds(),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
 	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.debugLog.Append(entry)
+}
 
-	results := make([]ScanResult, 0)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+// SetStream makes custom-prompt scans stream free-text answers to fn as
+// they are generated. Files are then scanned one at a time so answers don't
+// interleave, and the progress spinner is not shown.
+func (s *Scanner) SetStream(fn func(filePath, token string)) {
+	s.stream = fn
+}
 
-	// Worker pool - limit concurrent scans to 3
-	workers := 3
-	jobs := make(chan int, len(files))
+func (s *Scanner) addUsage(filePath string, usage ollama.TokenUsage) {
+	s.usageMu.Lock()
+	u := s.fileUsage[filePath]
+	u.Add(usage)
+	s.fileUsage[filePath] = u
+	s.usageMu.Unlock()
+}
 
-	// Progress tracking with single spinner
-	var completed int
-	var progressMu sync.Mutex
-	spinner := ui.NewSpinner("")
+// takeUsage returns and clears the token usage recorded for filePath.
+func (s *Scanner) takeUsage(filePath string) ollama.TokenUsage {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	u := s.fileUsage[filePath]
+	delete(s.fileUsage, filePath)
+	return u
+}
 
-	// Helper to update spinner safely
-	updateSpinner := func(msg string) {
-		progressMu.Lock()
-		spinner.UpdateMessage(msg)
-		progressMu.Unlock()
-	}
+// SetCodeOwners enables CODEOWNERS-based ownership of findings.
+func (s *Scanner) SetCodeOwners(co *CodeOwners) {
+	s.codeOwners = co
+}
 
-	// Start workers
-	for w := 0; w < workers; w++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for i := range jobs {
-				file := files[i]
+// SetOrgContext sets the organization context variables added to every
+// scan prompt, see config.Config.Context.
+func (s *Scanner) SetOrgContext(vars map[string]string) {
+	s.orgContext = vars
+}
 
-				// Update progress
-				progressMu.Lock()
-				completed++
-				current := completed
-				if current == 1 {
-					spinner.Start()
-				}
-				progressMu.Unlock()
+// SetPolicies applies per-directory policies, with paths relative to root.
+// The scanner enforces each policy's minimum severity; excludes and scan
+// types are applied when choosing which files to scan and how.
+func (s *Scanner) SetPolicies(root string, policies []config.Policy) {
+	s.policyRoot = root
+	s.policies = policies
+}
 
-				// Calculate total stages (security = 3 stages: read, context, scan; custom = 2 stages: read, analysis)
-				stagesPerFile := 2
-				if s.scanType == "security" {
-					stagesPerFile = 3
-				}
-				totalStages := len(files) * stagesPerFile
-				startStage := (current - 1) * stagesPerFile
-
-				// Pass spinner update function to scanFile
-				result, err := s.scanFileWithProgress(file, startStage, totalStages, stagesPerFile, updateSpinner)
-
-				if err != nil {
-					progressMu.Lock()
-					spinner.Stop()
-					fmt.Fprintf(os.Stderr, "⚠️  Failed to scan %s: %v\n", file, err)
-					spinner.Start()
-					progressMu.Unlock()
-					continue
-				}
+// SetScanType switches the engine used by later ScanFiles calls.
+func (s *Scanner) SetScanType(scanType string) {
+	s.scanType = scanType
+}
 
-				// Always append results (even with no issues)
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
+// annotateIssues applies post-parse policy and metadata to issues found in
+// filePath and returns the issues that pass any per-directory minimum
+// severity. Issues carrying their own File (triad) use that path instead.
+func (s *Scanner) annotateIssues(filePath string, issues []SecurityIssue) []SecurityIssue {
+	for i := range issues {
+		if issues[i].IssueID != "" {
+			issues[i].IssueID = knowledge.Normalize(issues[i].IssueID)
+		}
+	}
+	s.applyScores(filePath, issues)
+	s.applySeverityOverrides(filePath, issues)
+	issues = s.applyPolicySeverity(filePath, issues)
+	attachCodeSnippets(filePath, issues)
+	if s.blame {
+		annotateBlame(filePath, issues)
+	}
+	if s.diff != nil {
+		annotateDiff(s.diff, filePath, issues)
+	}
+	if s.codeOwners != nil {
+		for i := range issues {
+			path := filePath
+			if issues[i].File != "" {
+				path = issues[i].File
 			}
-		}()
+			issues[i].Owner = s.codeOwners.OwnerOf(path)
+		}
 	}
+	SortIssues(issues)
+	return issues
+}
 
-	// Queue all files
-	for i := range files {
-		jobs <- i
+func (s *Scanner) logDebug(title, content string) {
+	if s.debug && s.debugFile != nil {
+		fmt.Fprintf(s.debugFile, "\n%s\n%s\n%s\n%s\n",
+			strings.Repeat("=", 80),
+			title,
+			strings.Repeat("=", 80),
+			content)
 	}
-	close(jobs)
+}
 
-	// Wait for completion
-	wg.Wait()
-	spinner.Stop()
+// ScanFiles scans files with the scanner's engine. Cancelling ctx stops
+// queued files from starting and aborts the model calls in flight; the
+// results of files finished by then are returned with ctx's error. After
+// Stop, the files in flight finish and ErrStopped is returned with them.
+func (s *Scanner) ScanFiles(ctx context.Context, files []string) ([]ScanResult, error) {
+	s.ctx = ctx
+	defer func() { s.ctx = nil }()
+
+	engine, err := s.engine()
+	if err != nil {
+		return nil, err
+	}
+	if batch, ok := engine.(BatchEngine); ok {
+		results, err := batch.ScanAll(s, files)
+		if err == nil {
+			err = ctx.Err()
+		}
+		return results, err
+	}
+	fileEngine, ok := engine.(FileEngine)
+	if !ok {
+		return nil, fmt.Errorf("scan type %q cannot scan files", engine.Name())
+	}
 
+	unique, dups := s.dedupFiles(files)
+	if n := len(files) - len(unique); n > 0 {
+		ui.Printf("♻️  %d file(s) are identical to others and reuse their findings\n", n)
+	}
+	s.cacheHits.Store(0)
+	results := s.expandDuplicates(s.scanPipelined(fileEngine, unique), dups)
+	if n := s.cacheHits.Load(); n > 0 {
+		ui.Printf("⚡ %d file(s) are unchanged since an earlier scan and reuse its findings\n", n)
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	if s.stopped.Load() {
+		return results, ErrStopped
+	}
 	return results, nil
 }
 
@@ -168,113 +354,416 @@ func (s *Scanner) Close() {
 	if s.debugFile != nil {
 		s.debugFile.Close()
 	}
+	if s.debugLog != nil {
+		s.debugLog.Close()
+	}
+}
+
+func (s *Scanner) scanFileWithProgress(engine FileEngine, filePath string, startStage, totalStages int, updateStatus func(string)) (ScanResult, error) {
+	progress := &Progress{stage: startStage, total: totalStages, update: updateStatus}
+	content, result, err := s.readScanFile(filePath, progress)
+	if err != nil || content == nil {
+		return result, err
+	}
+
+	generated := result.Generated
+	result, err = engine.ScanFile(s, filePath, content, progress)
+	if err != nil {
+		return result, err
+	}
+	result.Generated = generated
+	tagGenerated(&result)
+	s.recordUsage(&result)
+	return result, nil
 }
 
-func (s *Scanner) scanFileWithProgress(filePath string, startStage, totalStages, stagesPerFile int, updateStatus func(string)) (ScanResult, error) {
+// readScanFile reads a file for scanning as the first progress stage. It
+// returns nil content, with an empty result, for files that are skipped
+// because they are empty or too large even to chunk.
+func (s *Scanner) readScanFile(filePath string, progress *Progress) ([]byte, ScanResult, error) {
 	result := ScanResult{
 		FilePath: filePath,
 		Issues:   make([]SecurityIssue, 0),
 	}
 
-	fileName := filepath.Base(filePath)
-	currentStage := startStage + 1
-
 	// Reading file
-	updateStatus(fmt.Sprintf("[%d/%d] Reading %s", currentStage, totalStages, fileName))
-	content, err := os.ReadFile(filePath)
+	progress.Stage("Reading %s", filepath.Base(filePath))
+
+	// Lockfiles and generator output are skipped unless asked for, before
+	// they are read: lockfiles are often large
+	if reason := GeneratedName(filePath); reason != "" {
+		if !s.includeGenerated {
+			result.Skipped = "generated: " + reason
+			return nil, result, nil
+		}
+		result.Generated = true
+	}
+
+	// Skip empty or very large files before loading them into memory
+	info, err := os.Stat(filePath)
 	if err != nil {
-		return result, fmt.Errorf("failed to read file: %w", err)
+		return nil, result, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil, result, nil
+	}
+	if info.Size() > maxScanFileSize {
+		ui.Eprintf("⚠️  Skipping %s: %d KB is over the %d KB limit\n", filePath, info.Size()>>10, maxScanFileSize>>10)
+		return nil, result, nil
 	}
 
-	// Skip empty or very large files
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, result, fmt.Errorf("failed to read file: %w", err)
+	}
 	if len(content) == 0 {
-		return result, nil
+		return nil, result, nil
 	}
-	if len(content) > 100000 { // Skip files larger than 100KB
-		return result, nil
+	text, encoding, err := decodeSource(content)
+	if err != nil {
+		result.Skipped = "encoding: " + err.Error()
+		ui.Eprintf("⚠️  Skipping %s: %v\n", filePath, err)
+		return nil, result, nil
+	}
+	if encoding != "" {
+		s.logDebug("TRANSCODED "+encoding, filePath)
+	}
+	if marker := GeneratedHeader(text); marker != "" && !result.Generated {
+		if !s.includeGenerated {
+			result.Skipped = "generated: " + marker
+			return nil, result, nil
+		}
+		result.Generated = true
+	}
+	text, result.Quarantined = s.quarantineContent(text)
+	if len(result.Quarantined) > 0 {
+		s.logDebug("QUARANTINED", fmt.Sprintf("%s: lines %v", filePath, result.Quarantined))
 	}
+	return text, result, nil
+}
 
-	if s.scanType == "security" {
-		// Stage 1: Context Analysis
-		currentStage++
-		updateStatus(fmt.Sprintf("[%d/%d] Identifying language/frameworks in %s", currentStage, totalStages, fileName))
-		// Add line numbers to code for precise references
-		numberedContent := addLineNumbers(string(content))
-		contextAnalysis, err := s.analyzeContext(filePath, numberedContent)
-		if err != nil {
-			return result, fmt.Errorf("context analysis failed: %w", err)
+// securityScanFile runs the two-stage LLM security scan on one file.
+func (s *Scanner) securityScanFile(filePath string, content []byte, progress *Progress) (ScanResult, error) {
+	ctx, err := s.securityContext(filePath, content, progress)
+	if err != nil {
+		return ScanResult{FilePath: filePath, Issues: make([]SecurityIssue, 0)}, err
+	}
+	return s.securityFindings(filePath, content, ctx, progress)
+}
+
+// securityContextResult is the output of Stage 1, handed to Stage 2.
+type securityContextResult struct {
+	chunks   []chunk // The whole file when it fits in one chunk, or the changed functions
+	analysis string
+	context  *FileContext
+	model    string // The scan model, or the static gate's fast model
+	gated    bool   // Skipped by the static gate; there is no analysis
+}
+
+// securityContext runs Stage 1 of the security scan: identifying the
+// file's language, frameworks and trust boundaries.
+func (s *Scanner) securityContext(filePath string, content []byte, progress *Progress) (*securityContextResult, error) {
+	model := s.modelName
+	if s.staticGate != "" && !s.passesStaticGate(filePath, content) {
+		if s.staticGate == StaticGateSkip {
+			s.logDebug("STATIC GATE: SKIPPED", filePath)
+			return &securityContextResult{gated: true}, nil
+		}
+		model = s.gateModel
+		s.logDebug("STATIC GATE: FAST MODEL", fmt.Sprintf("%s with %s", filePath, model))
+	}
+
+	// Large files are scanned in chunks; the first one is enough to tell
+	// the language and frameworks
+	size, overlap := s.chunking()
+	chunks := splitChunks(string(content), size, overlap)
+
+	// With --diff, Stage 2 only needs the changed functions
+	if s.diff != nil && s.diffFunctions {
+		if changed, ok := s.diff.Changed(filePath); ok && changed != nil {
+			if fc := changedFunctionChunks(filePath, content, changed, size, overlap); fc != nil {
+				chunks = fc
+				var parts []string
+				for _, c := range fc {
+					parts = append(parts, LineRange{Start: c.startLine, End: c.startLine + strings.Count(c.content, "\n")}.String())
+				}
+				s.logDebug("DIFF: CHANGED FUNCTIONS", fmt.Sprintf("%s lines %s", filePath, strings.Join(parts, ", ")))
+			}
 		}
+	}
+
+	// Fast scans go straight to Stage 2
+	if s.fast {
+		return &securityContextResult{chunks: chunks, model: model}, nil
+	}
+
+	// Stage 1: Context Analysis
+	progress.Stage("Identifying language/frameworks in %s", filepath.Base(filePath))
+	// Add line numbers to code for precise references
+	numberedContent := addLineNumbers(chunks[0].content)
+	contextAnalysis, err := s.analyzeContext(model, filePath, numberedContent)
+	if err != nil {
+		return nil, fmt.Errorf("context analysis failed: %w", err)
+	}
+
+	s.logDebug("STAGE 1: CONTEXT ANALYSIS PROMPT", s.getContextPrompt(model, filePath, numberedContent))
+	s.logDebug("STAGE 1: CONTEXT ANALYSIS RESPONSE", contextAnalysis)
+
+	// Strip markdown if present and validate JSON (optional - we pass raw to Stage 2)
+	analysis := stripMarkdownCodeFences(contextAnalysis)
+	return &securityContextResult{
+		chunks:   chunks,
+		analysis: analysis,
+		context:  parseFileContext(analysis),
+		model:    model,
+	}, nil
+}
+
+// securityFindings runs Stage 2 of the security scan using the context
+// from Stage 1.
+func (s *Scanner) securityFindings(filePath string, content []byte, ctx *securityContextResult, progress *Progress) (ScanResult, error) {
+	result := ScanResult{
+		FilePath: filePath,
+		Issues:   make([]SecurityIssue, 0),
+		Context:  ctx.context,
+	}
+	if ctx.gated {
+		result.Skipped = "static gate: no suspicious patterns"
+		return result, nil
+	}
+	fileName := filepath.Base(filePath)
 
-		s.logDebug("STAGE 1: CONTEXT ANALYSIS PROMPT", s.getContextPrompt(filePath, numberedContent))
-		s.logDebug("STAGE 1: CONTEXT ANALYSIS RESPONSE", contextAnalysis)
+	// Stage 2: Targeted Scan
+	progress.Stage("Checking for vulnerabilities in %s", fileName)
 
-		// Strip markdown if present and validate JSON (optional - we pass raw to Stage 2)
-		contextAnalysis = stripMarkdownCodeFences(contextAnalysis)
+	var jsonResponse struct {
+		Findings []SecurityIssue `json:"findings"`
+	}
 
-		// Stage 2: Targeted Scan
-		currentStage++
-		updateStatus(fmt.Sprintf("[%d/%d] Checking for vulnerabilities in %s", currentStage, totalStages, fileName))
-		// Use numbered content so LLM can reference exact lines
-		findings, err := s.scanWithContext(filePath, numberedContent, contextAnalysis)
+	if len(ctx.chunks) == 1 {
+		issues, err := s.scanChunk(filePath, ctx.model, ctx.chunks[0].content, ctx.analysis, progress, "")
 		if err != nil {
-			return result, fmt.Errorf("security scan failed: %w", err)
+			return result, err
 		}
+		offsetIssues(issues, ctx.chunks[0]) // A changed function, with --diff
+		jsonResponse.Findings = issues
+	} else {
+		// Scan each chunk, then map findings back to the file and merge
+		// the ones reported twice from an overlap or split by a cut
+		var all []SecurityIssue
+		var lastErr error
+		for i, c := range ctx.chunks {
+			label := fmt.Sprintf(" (chunk %d/%d, lines %d+)", i+1, len(ctx.chunks), c.startLine)
+			issues, err := s.scanChunk(filePath, ctx.model, c.content, ctx.analysis, progress, label)
+			if err != nil {
+				s.logDebug(fmt.Sprintf("STAGE 2: CHUNK %d FAILED", i+1), err.Error())
+				lastErr = err
+				continue
+			}
+			offsetIssues(issues, c)
+			all = append(all, issues...)
+		}
+		if all == nil && lastErr != nil {
+			return result, lastErr
+		}
+		jsonResponse.Findings = mergeChunkFindings(all)
+	}
 
-		s.logDebug("STAGE 2: SECURITY SCAN PROMPT", s.getScanPrompt(filePath, string(content), contextAnalysis))
-		s.logDebug("STAGE 2: SECURITY SCAN RESPONSE", findings)
+	jsonResponse.Findings = s.annotateIssues(filePath, jsonResponse.Findings)
 
-		// Strip markdown code fences if present
-		findings = stripMarkdownCodeFences(findings)
+	result.Issues = jsonResponse.Findings
+	result.HasIssues = len(jsonResponse.Findings) > 0
 
-		// Fix JSON string escaping issues (newlines in string values)
-		findings = fixJSONStringEscaping(findings)
+	return result, nil
+}
+
+// scanChunk runs Stage 2 on content, a whole file or one chunk of it, with
+// every configured model and sample. label is appended to status messages.
+func (s *Scanner) scanChunk(filePath, model, content, contextAnalysis string, progress *Progress, label string) ([]SecurityIssue, error) {
+	fileName := filepath.Base(filePath)
+	numberedContent := addLineNumbers(content)
 
-		// Parse JSON response
-		var jsonResponse struct {
-			Findings []SecurityIssue `json:"findings"`
+	if s.fast {
+		if label != "" {
+			progress.Status(fmt.Sprintf("Checking for vulnerabilities in %s%s", fileName, label))
 		}
+		return s.runFastScan(model, filePath, content, numberedContent)
+	}
 
-		if err := json.Unmarshal([]byte(findings), &jsonResponse); err != nil {
-			return result, fmt.Errorf("failed to parse JSON response: %w. Raw output: %s", err, findings)
+	// Files sent to the static gate's fast model skip the ensemble
+	if len(s.ensembleModels) > 1 && model == s.modelName {
+		// Ensemble: scan with every model and merge with per-model attribution
+		perModel := make(map[string][]SecurityIssue)
+		var lastErr error
+		for _, model := range s.ensembleModels {
+			status := func(suffix string) {
+				progress.Status(fmt.Sprintf("Checking for vulnerabilities in %s [%s]%s%s", fileName, model, label, suffix))
+			}
+			if label != "" {
+				status("")
+			}
+			issues, err := s.sampledSecurityScan(model, filePath, content, numberedContent, contextAnalysis, status)
+			if err != nil {
+				s.logDebug(fmt.Sprintf("STAGE 2: MODEL %s FAILED", model), err.Error())
+				lastErr = err
+				continue
+			}
+			perModel[model] = issues
+		}
+		if len(perModel) == 0 {
+			return nil, lastErr
 		}
+		return mergeEnsemble(s.ensembleModels, perModel, s.ensembleMode), nil
+	}
 
-		result.Issues = jsonResponse.Findings
-		result.HasIssues = len(jsonResponse.Findings) > 0
+	status := func(suffix string) {
+		progress.Status(fmt.Sprintf("Checking for vulnerabilities in %s%s%s", fileName, label, suffix))
+	}
+	if label != "" {
+		status("")
+	}
+	return s.sampledSecurityScan(model, filePath, content, numberedContent, contextAnalysis, status)
+}
 
-		// Render findings to text for display
-		result.RawFindings = s.renderFindings(jsonResponse.Findings)
+// customScanFile runs the user's custom prompt against one file.
+func (s *Scanner) customScanFile(filePath string, content []byte, progress *Progress) (ScanResult, error) {
+	result := ScanResult{
+		FilePath: filePath,
+		Issues:   []SecurityIssue{}, // Keep empty for custom prompts
+	}
 
-		return result, nil
-	} else {
-		// Custom prompt - simpler flow
-		prompt := s.createCustomPrompt(filePath, string(content))
+	progress.Stage("Running custom analysis on %s", filepath.Base(filePath))
+	fields := parseCustomPrompt(s.customPrompt).fields
+
+	// Large files are asked about one chunk at a time
+	size, overlap := s.chunking()
+	chunks := splitChunks(string(content), size, overlap)
+	var responses []string
+	for i, c := range chunks {
+		name := filePath
+		if len(chunks) > 1 {
+			lineCount := strings.Count(c.content, "\n") + 1
+			name = fmt.Sprintf("%s (lines %d-%d)", filePath, c.startLine, c.startLine+lineCount-1)
+			progress.Status(fmt.Sprintf("Running custom analysis on %s (chunk %d/%d)", filepath.Base(filePath), i+1, len(chunks)))
+		}
+		prompt := s.createCustomPrompt(name, c.content)
 
 		s.logDebug("CUSTOM PROMPT", prompt)
 
-		currentStage++
-		updateStatus(fmt.Sprintf("[%d/%d] Running custom analysis on %s", currentStage, totalStages, fileName))
-		response, err := s.client.Generate(s.modelName, prompt)
+		var response string
+		var err error
+		if s.stream != nil && len(fields) == 0 {
+			// Structured answers are JSON, so only free text is streamed
+			if len(chunks) > 1 {
+				s.stream(filePath, fmt.Sprintf("\n── %s ──\n", strings.TrimPrefix(name, filePath+" ")))
+			}
+			response, err = s.generateStream(filePath, "custom", s.modelName, prompt, s.client.Options())
+			result.Streamed = true
+		} else {
+			response, err = s.generate(filePath, "custom", s.modelName, prompt, s.client.Options())
+		}
 		if err != nil {
 			return result, fmt.Errorf("analysis failed: %w", err)
 		}
 
 		s.logDebug("CUSTOM RESPONSE", response)
+		if len(chunks) > 1 && len(fields) == 0 {
+			response = fmt.Sprintf("── %s ──\n%s", strings.TrimPrefix(name, filePath+" "), response)
+		}
+		responses = append(responses, response)
+	}
+
+	result.RawFindings = strings.Join(responses, "\n\n")
+	result.HasIssues = strings.TrimSpace(result.RawFindings) != ""
 
-		result.RawFindings = response
-		result.HasIssues = strings.TrimSpace(response) != ""
-		result.Issues = []SecurityIssue{} // Keep empty for custom prompts
+	// Structured answers are rendered as a table; fall back to the raw text
+	if len(fields) > 0 {
+		table := &Table{Columns: fields, Rows: make([][]string, 0)}
+		for _, response := range responses {
+			t, err := parseTable(response, fields)
+			if err != nil {
+				s.logDebug("CUSTOM STRUCTURED PARSE ERROR", err.Error())
+				return result, nil
+			}
+			table.Rows = append(table.Rows, t.Rows...)
+		}
+		result.Table = table
+		result.HasIssues = len(table.Rows) > 0
+		result.RawFindings = renderTable(table)
 	}
 
 	return result, nil
 }
 
+// recordUsage moves the file's token usage onto result and logs it in debug mode.
+func (s *Scanner) recordUsage(result *ScanResult) {
+	result.Usage = s.takeUsage(result.FilePath)
+	s.logDebug("TOKEN USAGE", fmt.Sprintf("%s: %d prompt + %d completion = %d tokens",
+		result.FilePath, result.Usage.PromptTokens, result.Usage.CompletionTokens, result.Usage.Total()))
+}
+
+// sampledSecurityScan runs Stage 2 with the given model, sampling it
+// s.samples times and keeping majority findings when more than one.
+func (s *Scanner) sampledSecurityScan(model, filePath, content, numberedContent, contextAnalysis string, status func(string)) ([]SecurityIssue, error) {
+	if s.samples <= 1 {
+		return s.runSecurityScan(model, filePath, content, numberedContent, contextAnalysis, s.client.Options())
+	}
+
+	// Self-consistency: keep only findings reported by a majority of samples
+	var samples [][]SecurityIssue
+	var lastErr error
+	for i := 1; i <= s.samples; i++ {
+		status(fmt.Sprintf(" (sample %d/%d)", i, s.samples))
+		issues, err := s.runSecurityScan(model, filePath, content, numberedContent, contextAnalysis, sampleOptions(s.client.Options(), i))
+		if err != nil {
+			s.logDebug(fmt.Sprintf("STAGE 2: SAMPLE %d FAILED", i), err.Error())
+			lastErr = err
+			continue
+		}
+		samples = append(samples, issues)
+	}
+	if len(samples) == 0 {
+		return nil, lastErr
+	}
+	return majorityFindings(samples), nil
+}
+
+// runSecurityScan performs Stage 2 for one file and parses the model's findings.
+func (s *Scanner) runSecurityScan(model, filePath, content, numberedContent, contextAnalysis string, opts *ollama.Options) ([]SecurityIssue, error) {
+	// Use numbered content so LLM can reference exact lines
+	findings, err := s.scanWithContext(model, filePath, numberedContent, contextAnalysis, opts)
+	if err != nil {
+		return nil, fmt.Errorf("security scan failed: %w", err)
+	}
+
+	s.logDebug("STAGE 2: SECURITY SCAN PROMPT", s.getScanPrompt(model, filePath, content, contextAnalysis))
+	s.logDebug("STAGE 2: SECURITY SCAN RESPONSE", findings)
+
+	// Parse JSON response, asking the model to correct it if needed
+	var jsonResponse struct {
+		Findings []SecurityIssue `json:"findings"`
+	}
+
+	if err := s.decodeWithRepair(filePath, model, findings, findingsSchema, opts, &jsonResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w. Raw output: %s", err, stripMarkdownCodeFences(findings))
+	}
+
+	verifyLineNumbers(content, jsonResponse.Findings)
+
+	return jsonResponse.Findings, nil
+}
+
 func (s *Scanner) scanFile(filePath string) (ScanResult, error) {
 	// Legacy method - calls new method with no-op progress
-	stagesPerFile := 2
-	if s.scanType == "security" {
-		stagesPerFile = 3
+	engine, err := s.engine()
+	if err != nil {
+		return ScanResult{FilePath: filePath}, err
 	}
-	return s.scanFileWithProgress(filePath, 0, stagesPerFile, stagesPerFile, func(string) {})
+	fileEngine, ok := engine.(FileEngine)
+	if !ok {
+		return ScanResult{FilePath: filePath}, fmt.Errorf("scan type %q cannot scan single files", engine.Name())
+	}
+	return s.scanFileWithProgress(fileEngine, filePath, 0, fileEngine.Stages(), func(string) {})
 }
 
 type triadStaticFinding struct {
@@ -288,8 +777,12 @@ type triadVulnerability struct {
 	Type           string `json:"type"`
 	File           string `json:"file"`
 	Line           int    `json:"line"`
+	LineEnd        int    `json:"line_end,omitempty"`
 	Evidence       string `json:"evidence"`
 	Recommendation string `json:"recommendation"`
+	FixAvailable   bool   `json:"fix_available,omitempty"`
+	SuggestedFix   string `json:"suggested_fix,omitempty"`
+	CVSSVector     string `json:"cvss_vector,omitempty"`
 }
 
 type triadReport struct {
@@ -297,6 +790,7 @@ type triadReport struct {
 	Confidence      string               `json:"confidence"`
 	Vulnerabilities []triadVulnerability `json:"vulnerabilities"`
 	Summary         string               `json:"summary,omitempty"`
+	StoppedEarly    string               `json:"stopped_early,omitempty"` // Why the budget ended the debate, if it did
 }
 
 func (s *Scanner) scanTriadFiles(files []string) (ScanResult, error) {
@@ -311,59 +805,96 @@ func (s *Scanner) scanTriadFiles(files []string) (ScanResult, error) {
 		return result, nil
 	}
 
-	codeByFile := make(map[string]string)
+	// Only paths and static findings are kept in memory; file contents are
+	// streamed for analysis and re-read on demand when building the context.
+	var triadFiles []string
+	var staticFindings []triadStaticFinding
 	for _, filePath := range files {
-		content, err := os.ReadFile(filePath)
+		info, err := os.Stat(filePath)
 		if err != nil {
-			return result, fmt.Errorf("failed to read file: %w", err)
+			return result, fmt.Errorf("failed to stat file: %w", err)
 		}
-		if len(content) == 0 || len(content) > 100000 {
+		if info.Size() == 0 || info.Size() > maxFileSize {
 			continue
 		}
-		codeByFile[filePath] = string(content)
+		findings, err := runTriadStaticAnalysis(filePath)
+		if err != nil {
+			return result, fmt.Errorf("failed to read file: %w", err)
+		}
+		triadFiles = append(triadFiles, filePath)
+		staticFindings = append(staticFindings, findings...)
 	}
 
-	if len(codeByFile) == 0 {
+	if len(triadFiles) == 0 {
 		return result, nil
 	}
 
-	staticFindings := runTriadStaticAnalysis(codeByFile)
-	sharedContext := s.buildTriadSharedContext(codeByFile, staticFindings)
+	sharedContext := s.buildTriadSharedContext(triadFiles, staticFindings)
 
 	var lastReport triadReport
 	var summary string
+	started := time.Now()
+	haveReport := false
+	stopped := ""
+
+	// overBudget reports whether to stop now. The first round always
+	// completes so there is an auditor report to fall back on.
+	overBudget := func(round int) bool {
+		if !haveReport {
+			return false
+		}
+		if reason := s.triadBudget.exceeded(s.usageSoFar(result.FilePath), started); reason != "" {
+			stopped = fmt.Sprintf("%s during round %d", reason, round)
+			return true
+		}
+		return false
+	}
 
-	for round := 1; round <= 3; round++ {
+	for round := 1; round <= s.triadBudget.rounds(); round++ {
+		if overBudget(round) {
+			break
+		}
 		attackerPrompt := s.getTriadAttackerPrompt(sharedContext, summary, round)
-		attackerResp, err := s.client.Generate(s.modelName, attackerPrompt)
+		attackerResp, err := s.generate(result.FilePath, "triad-attacker", s.modelName, attackerPrompt, s.client.Options())
 		if err != nil {
 			return result, fmt.Errorf("attacker pass failed: %w", err)
 		}
 		s.logDebug(fmt.Sprintf("TRIAD ROUND %d: ATTACKER PROMPT", round), attackerPrompt)
 		s.logDebug(fmt.Sprintf("TRIAD ROUND %d: ATTACKER RESPONSE", round), attackerResp)
 
+		if overBudget(round) {
+			break
+		}
 		defenderPrompt := s.getTriadDefenderPrompt(sharedContext, summary, attackerResp, round)
-		defenderResp, err := s.client.Generate(s.modelName, defenderPrompt)
+		defenderResp, err := s.generate(result.FilePath, "triad-defender", s.modelName, defenderPrompt, s.client.Options())
 		if err != nil {
 			return result, fmt.Errorf("defender pass failed: %w", err)
 		}
 		s.logDebug(fmt.Sprintf("TRIAD ROUND %d: DEFENDER PROMPT", round), defenderPrompt)
 		s.logDebug(fmt.Sprintf("TRIAD ROUND %d: DEFENDER RESPONSE", round), defenderResp)
 
+		if overBudget(round) {
+			break
+		}
 		auditorPrompt := s.getTriadAuditorPrompt(sharedContext, summary, attackerResp, defenderResp, round)
-		auditorResp, err := s.client.Generate(s.modelName, auditorPrompt)
+		auditorResp, err := s.generate(result.FilePath, "triad-auditor", s.modelName, auditorPrompt, s.client.Options())
 		if err != nil {
 			return result, fmt.Errorf("auditor pass failed: %w", err)
 		}
 		s.logDebug(fmt.Sprintf("TRIAD ROUND %d: AUDITOR PROMPT", round), auditorPrompt)
 		s.logDebug(fmt.Sprintf("TRIAD ROUND %d: AUDITOR RESPONSE", round), auditorResp)
 
-		auditorResp = stripMarkdownCodeFences(auditorResp)
-		auditorResp = fixJSONStringEscaping(auditorResp)
-
-		if err := json.Unmarshal([]byte(auditorResp), &lastReport); err != nil {
-			return result, fmt.Errorf("auditor response parse failed: %w. Raw output: %s", err, auditorResp)
+		var report triadReport
+		if err := s.decodeWithRepair(result.FilePath, s.modelName, auditorResp, nil, s.client.Options(), &report); err != nil {
+			if haveReport {
+				// Keep the previous round's verdict rather than failing the scan
+				s.logDebug(fmt.Sprintf("TRIAD ROUND %d: AUDITOR PARSE ERROR", round), err.Error())
+				break
+			}
+			return result, fmt.Errorf("auditor response parse failed: %w. Raw output: %s", err, stripMarkdownCodeFences(auditorResp))
 		}
+		lastReport = report
+		haveReport = true
 
 		summary = strings.TrimSpace(lastReport.Summary)
 		if summary == "" {
@@ -375,41 +906,152 @@ func (s *Scanner) scanTriadFiles(files []string) (ScanResult, error) {
 		}
 	}
 
+	if stopped != "" {
+		// Fix generation costs more tokens; skip it once the budget is spent
+		ui.Eprintf("⚠️  Triad stopped early: %s; using the last auditor report\n", stopped)
+		s.logDebug("TRIAD BUDGET", stopped)
+		lastReport.StoppedEarly = stopped
+	} else {
+		s.generateTriadFixes(&lastReport)
+	}
+
 	finalJSON, err := json.MarshalIndent(lastReport, "", "  ")
 	if err != nil {
 		return result, fmt.Errorf("failed to serialize final report: %w", err)
 	}
 
 	result.RawFindings = string(finalJSON)
-	result.HasIssues = len(lastReport.Vulnerabilities) > 0
+	result.Issues = s.annotateIssues("", triadIssues(lastReport))
+	result.HasIssues = len(result.Issues) > 0
+	s.recordUsage(&result)
 	return result, nil
 }
 
-func runTriadStaticAnalysis(codeByFile map[string]string) []triadStaticFinding {
-	var findings []triadStaticFinding
+// generateTriadFixes asks the model for a code fix for every vulnerability the
+// auditor left without one. Failures are logged and leave the entry unchanged.
+func (s *Scanner) generateTriadFixes(report *triadReport) {
+	for i := range report.Vulnerabilities {
+		vuln := &report.Vulnerabilities[i]
+		if vuln.FixAvailable && strings.TrimSpace(vuln.SuggestedFix) != "" {
+			continue
+		}
+		if vuln.File == "" || vuln.Line < 1 {
+			continue
+		}
 
-	for filePath, content := range codeByFile {
-		lines := strings.Split(content, "\n")
-		for i, line := range lines {
-			lineNum := i + 1
-			trimmed := strings.TrimSpace(line)
+		content, err := os.ReadFile(vuln.File)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		start := maxInt(1, vuln.Line-10)
+		end := minInt(len(lines), maxInt(vuln.Line, vuln.LineEnd)+10)
+		var snippet strings.Builder
+		for n := start; n <= end; n++ {
+			snippet.WriteString(fmt.Sprintf("%4d | %s\n", n, lines[n-1]))
+		}
 
-			if strings.Contains(trimmed, "exec.Command") {
-				findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "exec.Command", Severity: "High"})
-			}
-			if strings.Contains(trimmed, "tls.Config") && strings.Contains(trimmed, "InsecureSkipVerify") && strings.Contains(trimmed, "true") {
-				findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "tls.Config{InsecureSkipVerify: true}", Severity: "High"})
-			}
-			if looksLikeSQLConcat(trimmed) {
-				findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "SQL string concatenation", Severity: "High"})
-			}
-			if strings.Contains(trimmed, "http.ListenAndServe") {
-				findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "http.ListenAndServe", Severity: "Medium"})
-			}
-			if looksLikeFmtSprintfUserInput(trimmed) {
-				findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "fmt.Sprintf with user input", Severity: "Medium"})
-			}
+		prompt := s.getTriadFixPrompt(*vuln, snippet.String())
+		resp, err := s.generate("triad:multi", "triad-fix", s.modelName, prompt, s.client.Options())
+		if err != nil {
+			s.logDebug("TRIAD FIX ERROR", err.Error())
+			continue
+		}
+		s.logDebug("TRIAD FIX PROMPT", prompt)
+		s.logDebug("TRIAD FIX RESPONSE", resp)
+
+		resp = fixJSONStringEscaping(stripMarkdownCodeFences(resp))
+		var fix struct {
+			FixAvailable bool   `json:"fix_available"`
+			LineStart    int    `json:"line_start"`
+			LineEnd      int    `json:"line_end"`
+			SuggestedFix string `json:"suggested_fix"`
+		}
+		if err := json.Unmarshal([]byte(resp), &fix); err != nil {
+			s.logDebug("TRIAD FIX PARSE ERROR", err.Error())
+			continue
+		}
+		if !fix.FixAvailable || strings.TrimSpace(fix.SuggestedFix) == "" {
+			continue
+		}
+
+		if fix.LineStart >= 1 {
+			vuln.Line = fix.LineStart
+		}
+		if fix.LineEnd >= vuln.Line {
+			vuln.LineEnd = fix.LineEnd
+		}
+		vuln.FixAvailable = true
+		vuln.SuggestedFix = fix.SuggestedFix
+	}
+}
+
+// triadIssues converts the auditor's report into SecurityIssues so triad
+// results can be reviewed and fixed like regular security findings.
+func triadIssues(report triadReport) []SecurityIssue {
+	issues := make([]SecurityIssue, 0, len(report.Vulnerabilities))
+	for _, vuln := range report.Vulnerabilities {
+		lineEnd := vuln.LineEnd
+		if lineEnd < vuln.Line {
+			lineEnd = vuln.Line
 		}
+		issues = append(issues, SecurityIssue{
+			File:           vuln.File,
+			Severity:       strings.ToUpper(report.FinalSeverity),
+			Title:          vuln.Type,
+			Description:    vuln.Evidence,
+			LineStart:      vuln.Line,
+			LineEnd:        lineEnd,
+			Recommendation: vuln.Recommendation,
+			Confidence:     strings.ToUpper(report.Confidence),
+			SuggestedFix:   vuln.SuggestedFix,
+			FixAvailable:   vuln.FixAvailable && vuln.SuggestedFix != "",
+			CVSSVector:     vuln.CVSSVector,
+		})
+	}
+	return issues
+}
+
+// runTriadStaticAnalysis streams a file line by line and records pattern matches.
+func runTriadStaticAnalysis(filePath string) ([]triadStaticFinding, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var findings []triadStaticFinding
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), maxFileSize+1)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		findings = append(findings, matchTriadPatterns(filePath, lineNum, strings.TrimSpace(sc.Text()))...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return findings, nil
+}
+
+func matchTriadPatterns(filePath string, lineNum int, trimmed string) []triadStaticFinding {
+	var findings []triadStaticFinding
+
+	if strings.Contains(trimmed, "exec.Command") {
+		findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "exec.Command", Severity: "High"})
+	}
+	if strings.Contains(trimmed, "tls.Config") && strings.Contains(trimmed, "InsecureSkipVerify") && strings.Contains(trimmed, "true") {
+		findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "tls.Config{InsecureSkipVerify: true}", Severity: "High"})
+	}
+	if looksLikeSQLConcat(trimmed) {
+		findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "SQL string concatenation", Severity: "High"})
+	}
+	if strings.Contains(trimmed, "http.ListenAndServe") {
+		findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "http.ListenAndServe", Severity: "Medium"})
+	}
+	if looksLikeFmtSprintfUserInput(trimmed) {
+		findings = append(findings, triadStaticFinding{File: filePath, Line: lineNum, Pattern: "fmt.Sprintf with user input", Severity: "Medium"})
 	}
 
 	return findings
@@ -436,89 +1078,298 @@ func looksLikeFmtSprintfUserInput(line string) bool {
 	return false
 }
 
-func (s *Scanner) buildTriadSharedContext(codeByFile map[string]string, findings []triadStaticFinding) string {
+func (s *Scanner) buildTriadSharedContext(files []string, findings []triadStaticFinding) string {
+	findingsJSON := triadFindingsJSON(findings, maxTriadFindings)
+	assumptions := "Assume code runs as a network service. User input may be untrusted. External dependencies may be attacker-controlled."
+
+	// Reserve room for findings and assumptions so they are never truncated away
+	tail := fmt.Sprintf("\nSTATIC_FINDINGS:\n%s\nASSUMPTIONS:\n%s\n", findingsJSON, assumptions)
+	codeBudget := max(maxTriadContext-len("CODE:\n")-len(tail), 0)
+
+	findingLines := make(map[string][]int)
+	for _, f := range findings {
+		findingLines[f.File] = append(findingLines[f.File], f.Line)
+	}
+
 	var codeBuilder strings.Builder
-	for filePath, content := range codeByFile {
-		codeBuilder.WriteString(fmt.Sprintf("FILE: %s\n", filePath))
-		codeBuilder.WriteString(addLineNumbers(content))
-		codeBuilder.WriteString("\n")
+	var omitted []string
+	for _, filePath := range prioritizeTriadFiles(files, findings) {
+		remaining := codeBudget - codeBuilder.Len()
+		if remaining <= 0 {
+			omitted = append(omitted, filePath)
+			continue
+		}
+		raw, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		content, _, err := decodeSource(raw)
+		if err != nil {
+			continue
+		}
+		content, quarantined := s.quarantineContent(content)
+		if len(quarantined) > 0 {
+			ui.Eprintf("🛡️  %s: possible prompt injection on line(s) %s\n", filePath, FormatLines(quarantined))
+		}
+
+		header := fmt.Sprintf("FILE: %s\n", filePath)
+		section := header + addLineNumbers(string(content)) + "\n"
+		if len(section) > remaining {
+			// Whole file doesn't fit: keep only the functions around static findings
+			lines, ok := findingLines[filePath]
+			if !ok {
+				omitted = append(omitted, filePath)
+				continue
+			}
+			section = header + excerptAroundLines(string(content), lines) + "\n"
+			if len(section) > remaining {
+				section = truncateText(section, remaining)
+			}
+		}
+		codeBuilder.WriteString(section)
 	}
 
-	findingsJSON, _ := json.MarshalIndent(findings, "", "  ")
-	assumptions := "Assume code runs as a network service. User input may be untrusted. External dependencies may be attacker-controlled."
+	if len(omitted) > 0 {
+		note := fmt.Sprintf("OMITTED (context budget): %s\n", strings.Join(omitted, ", "))
+		if codeBuilder.Len()+len(note) <= codeBudget {
+			codeBuilder.WriteString(note)
+		}
+	}
+
+	context := "CODE:\n" + codeBuilder.String() + tail
+	return truncateText(context, maxTriadContext)
+}
+
+// triadFindingsJSON renders findings as JSON of at most budget bytes. When
+// they do not all fit, the most severe are kept and a note counts the rest.
+func triadFindingsJSON(findings []triadStaticFinding, budget int) string {
+	data, _ := json.MarshalIndent(findings, "", "  ")
+	if len(data) <= budget {
+		return string(data)
+	}
+
+	ordered := make([]triadStaticFinding, len(findings))
+	copy(ordered, findings)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return severityFloor[strings.ToUpper(ordered[i].Severity)] > severityFloor[strings.ToUpper(ordered[j].Severity)]
+	})
+	render := func(n int) string {
+		data, _ := json.MarshalIndent(ordered[:n], "", "  ")
+		return fmt.Sprintf("%s\n(%d more finding(s) omitted for the context budget)", data, len(ordered)-n)
+	}
+	// The largest prefix that fits; render grows with n
+	n := sort.Search(len(ordered)+1, func(n int) bool { return len(render(n)) > budget }) - 1
+	return render(max(n, 0))
+}
 
-	context := fmt.Sprintf("CODE:\n%s\nSTATIC_FINDINGS:\n%s\nASSUMPTIONS:\n%s\n", codeBuilder.String(), string(findingsJSON), assumptions)
-	return truncateText(context, 16000)
+// prioritizeTriadFiles orders files so that those with the most severe and
+// most numerous static findings come first; files without findings keep
+// their original order at the end.
+func prioritizeTriadFiles(files []string, findings []triadStaticFinding) []string {
+	score := make(map[string]int)
+	for _, f := range findings {
+		switch strings.ToUpper(f.Severity) {
+		case "CRITICAL":
+			score[f.File] += 8
+		case "HIGH":
+			score[f.File] += 4
+		case "MEDIUM":
+			score[f.File] += 2
+		default:
+			score[f.File]++
+		}
+	}
+
+	ordered := make([]string, len(files))
+	copy(ordered, files)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return score[ordered[i]] > score[ordered[j]]
+	})
+	return ordered
 }
 
+// excerptAroundLines returns the numbered source of the functions enclosing
+// the given lines, falling back to a fixed window when no function is found.
+func excerptAroundLines(content string, lineNums []int) string {
+	lines := strings.Split(content, "\n")
+	const window = 10
+	const maxFuncLines = 80
+
+	type span struct{ start, end int }
+	var spans []span
+	for _, ln := range lineNums {
+		if ln < 1 || ln > len(lines) {
+			continue
+		}
+		start := maxInt(1, ln-window)
+		end := minInt(len(lines), ln+window)
+
+		// Walk back to the enclosing function declaration
+		for i := ln; i >= 1 && ln-i < maxFuncLines; i-- {
+			if strings.HasPrefix(lines[i-1], "func ") {
+				start = i
+				break
+			}
+		}
+		// Walk forward to the closing brace at column zero
+		for i := ln; i <= len(lines) && i-ln < maxFuncLines; i++ {
+			if strings.HasPrefix(lines[i-1], "}") {
+				end = i
+				break
+			}
+		}
+		spans = append(spans, span{start, end})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var merged []span
+	for _, sp := range spans {
+		if len(merged) > 0 && sp.start <= merged[len(merged)-1].end+1 {
+			if sp.end > merged[len(merged)-1].end {
+				merged[len(merged)-1].end = sp.end
+			}
+			continue
+		}
+		merged = append(merged, sp)
+	}
+
+	var out strings.Builder
+	for i, sp := range merged {
+		if i > 0 {
+			out.WriteString("     ...\n")
+		}
+		for n := sp.start; n <= sp.end; n++ {
+			out.WriteString(fmt.Sprintf("%4d | %s\n", n, lines[n-1]))
+		}
+	}
+	return out.String()
+}
+
+// truncateText cuts text to at most maxLen bytes without splitting a rune.
 func truncateText(text string, maxLen int) string {
 	if len(text) <= maxLen {
 		return text
 	}
+	for maxLen > 0 && !utf8.RuneStart(text[maxLen]) {
+		maxLen--
+	}
 	return text[:maxLen]
 }
 
-// renderFindings converts structured SecurityIssue data to formatted text output
-func (s *Scanner) renderFindings(issues []SecurityIssue) string {
+// referenceLine renders a knowledge base entry as "CWE-89 SQL Injection
+// (url)" for the terminal.
+func referenceLine(ref knowledge.Entry) string {
+	label := ref.ID
+	if ref.Name != "" {
+		label += " " + ref.Name
+	}
+	return label + " (" + ref.URL + ")"
+}
+
+// Findings returns the result as text for display: the model's answer for
+// custom prompts and triad reports, otherwise its issues rendered on demand,
+// so scans don't keep a rendered report of every file.
+func (r ScanResult) Findings() string {
+	if r.RawFindings != "" || len(r.Issues) == 0 {
+		return r.RawFindings
+	}
+	return renderFindings(r.FilePath, r.Issues)
+}
+
+// renderFindings converts structured SecurityIssue data to formatted text
+// output. Line numbers link to the findings in filePath (or their own File)
+// on terminals that support hyperlinks.
+func renderFindings(filePath string, issues []SecurityIssue) string {
 	if len(issues) == 0 {
 		return "No security issues found."
 	}
 
 	var output strings.Builder
 
-	// Severity emoji mapping
-	severityEmoji := map[string]string{
-		"CRITICAL": "🔴",
-		"HIGH":     "🟠",
-		"MEDIUM":   "🟡",
-		"LOW":      "🟢",
-	}
-
 	output.WriteString("===================================\n")
 	output.WriteString("Security Analysis Report\n")
 	output.WriteString("===================================\n\n")
 
-	// Group by severity
+	// Display by score, highest first
+	sorted := append([]SecurityIssue(nil), issues...)
+	SortIssues(sorted)
 	bySeverity := make(map[string][]SecurityIssue)
-	for _, issue := range issues {
+	for _, issue := range sorted {
 		bySeverity[issue.Severity] = append(bySeverity[issue.Severity], issue)
 	}
 
-	// Display in severity order
-	for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
-		if items, ok := bySeverity[sev]; ok && len(items) > 0 {
-			for _, issue := range items {
-				emoji := severityEmoji[sev]
-				output.WriteString(fmt.Sprintf("%s %s: %s\n", emoji, sev, issue.Title))
+	for _, issue := range sorted {
+		sev := issue.Severity
+		emoji := ui.SeverityEmoji(sev)
+		output.WriteString(fmt.Sprintf("%s %s: %s\n", emoji, sev, issue.Title))
 
-				lineInfo := fmt.Sprintf("Line: %d", issue.LineStart)
-				if issue.LineEnd != issue.LineStart {
-					lineInfo = fmt.Sprintf("Lines: %d-%d", issue.LineStart, issue.LineEnd)
-				}
-				output.WriteString(fmt.Sprintf("   %s", lineInfo))
+		lineInfo := fmt.Sprintf("Line: %d", issue.LineStart)
+		if issue.LineEnd != issue.LineStart {
+			lineInfo = fmt.Sprintf("Lines: %d-%d", issue.LineStart, issue.LineEnd)
+		}
+		if path := cmp.Or(issue.File, filePath); path != "" {
+			lineInfo = ui.Hyperlink(ui.EditorURL(path, issue.LineStart), lineInfo)
+		}
+		output.WriteString(fmt.Sprintf("   %s", lineInfo))
 
-				// Add confidence if present
-				if issue.Confidence != "" {
-					output.WriteString(fmt.Sprintf(" | Confidence: %s", issue.Confidence))
-				}
-				// Add issue ID if present
-				if issue.IssueID != "" {
-					output.WriteString(fmt.Sprintf(" | %s", issue.IssueID))
-				}
-				output.WriteString("\n\n")
+		// Add confidence if present
+		if issue.Confidence != "" {
+			output.WriteString(fmt.Sprintf(" | Confidence: %s", issue.Confidence))
+		}
+		// Add issue ID if present
+		if issue.IssueID != "" {
+			output.WriteString(fmt.Sprintf(" | %s", issue.IssueID))
+		}
+		if issue.CVSSVector != "" {
+			output.WriteString(fmt.Sprintf(" | CVSS %.1f", issue.CVSSScore))
+		}
+		// Add blame attribution if present
+		if issue.Author != "" {
+			output.WriteString(fmt.Sprintf(" | Last change: %s (%s)", issue.Author, issue.Commit))
+		}
+		if issue.Owner != "" {
+			output.WriteString(fmt.Sprintf(" | Owner: %s", issue.Owner))
+		}
+		if len(issue.Models) > 0 {
+			output.WriteString(fmt.Sprintf(" | Models: %s", strings.Join(issue.Models, ", ")))
+		}
+		if issue.ChangedLines != "" {
+			output.WriteString(fmt.Sprintf(" | Changed: %s", issue.ChangedLines))
+		}
+		if issue.Resurfaced {
+			output.WriteString(" | Re-review: false positive suppression expired")
+		}
+		if issue.Generated {
+			output.WriteString(" | generated-code")
+		}
+		output.WriteString("\n")
+		if ref, ok := knowledge.Lookup(issue.IssueID); ok {
+			output.WriteString(fmt.Sprintf("   Reference: %s\n", referenceLine(ref)))
+		}
+		if issue.CVSSVector != "" {
+			output.WriteString(fmt.Sprintf("   CVSS: %s\n", issue.CVSSVector))
+		}
+		output.WriteString("\n")
 
-				output.WriteString(fmt.Sprintf("   Description:\n   %s\n\n", issue.Description))
-				output.WriteString(fmt.Sprintf("   Recommendation:\n   %s\n\n", issue.Recommendation))
-				output.WriteString("-----------------------------------\n\n")
+		if issue.CodeSnippet != "" {
+			output.WriteString("   Code:\n")
+			for _, line := range strings.Split(strings.TrimRight(issue.CodeSnippet, "\n"), "\n") {
+				output.WriteString("   " + line + "\n")
 			}
+			output.WriteString("\n")
 		}
+
+		output.WriteString(fmt.Sprintf("   Description:\n   %s\n\n", issue.Description))
+		output.WriteString(fmt.Sprintf("   Recommendation:\n   %s\n\n", issue.Recommendation))
+		output.WriteString("-----------------------------------\n\n")
 	}
 
 	// Summary
 	output.WriteString("Summary:\n")
 	for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
 		if items, ok := bySeverity[sev]; ok && len(items) > 0 {
-			emoji := severityEmoji[sev]
+			emoji := ui.SeverityEmoji(sev)
 			output.WriteString(fmt.Sprintf("  %s %s: %d\n", emoji, sev, len(items)))
 		}
 	}
@@ -536,9 +1387,15 @@ func addLineNumbers(content string) string {
 	return numbered.String()
 }
 
-// stripMarkdownCodeFences removes ```json and ``` wrappers if present
+// stripMarkdownCodeFences removes ```json and ``` wrappers if present, along
+// with any <think>...</think> reasoning block emitted by reasoning models
 func stripMarkdownCodeFences(s string) string {
 	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "<think>") {
+		if end := strings.Index(s, "</think>"); end >= 0 {
+			s = strings.TrimSpace(s[end+len("</think>"):])
+		}
+	}
 	// Remove ```json or ``` at start
 	if strings.HasPrefix(s, "```json") {
 		s = strings.TrimPrefix(s, "```json")
@@ -612,244 +1469,17 @@ func countLines(content string, pos int) int {
 	return strings.Count(content[:pos], "\n") + 1
 }
 
-// ReviewFindings implements interactive review mode for security findings
-func ReviewFindings(findings []SecurityIssue, filePath string, client *ollama.Client, modelName string) error {
-	if len(findings) == 0 {
-		fmt.Println("No findings to review.")
-		return nil
-	}
-
-	// Sort findings by line_start in DESCENDING order
-	// This way we apply fixes from bottom to top, preventing line number shifts
-	sort.Slice(findings, func(i, j int) bool {
-		return findings[i].LineStart > findings[j].LineStart
-	})
-
-	reader := bufio.NewReader(os.Stdin)
-	currentIdx := 0
-	appliedFixes := make(map[int]bool)
-	backupCreated := false
-
-	// Read file content once
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-	lines := strings.Split(string(content), "\n")
-
-	for {
-		issue := findings[currentIdx]
-
-		// Clear screen
-		fmt.Print("\033[H\033[2J")
-
-		// Header
-		fmt.Printf("\n\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m\n")
-		fmt.Printf("\033[38;5;208m📋 Review Mode\033[0m - Finding %d of %d\n", currentIdx+1, len(findings))
-		fmt.Printf("\033[38;5;208m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m\n\n")
-
-		// Display issue details
-		severityColor := getSeverityColor(issue.Severity)
-		fmt.Printf("%s %s: %s\033[0m\n", severityColor, issue.Severity, issue.Title)
-		fmt.Printf("📁 File: \033[36m%s\033[0m\n", filepath.Base(filePath))
-		fmt.Printf("📍 Lines: \033[36m%d-%d\033[0m", issue.LineStart, issue.LineEnd)
-		if issue.Confidence != "" {
-			fmt.Printf(" | Confidence: %s", issue.Confidence)
-		}
-		if issue.IssueID != "" {
-			fmt.Printf(" | %s", issue.IssueID)
-		}
-		fmt.Println("\n")
-
-		fmt.Printf("📝 Description:\n%s\n\n", wrapText(issue.Description, 70))
-		fmt.Printf("💡 Recommendation:\n%s\n\n", wrapText(issue.Recommendation, 70))
-
-		// Show code context
-		fmt.Printf("\033[38;5;208m━━━ Current Code ━━━\033[0m\n")
-		showCodeContext(lines, issue.LineStart, issue.LineEnd)
-
-		// Show fix status and diff
-		if appliedFixes[currentIdx] {
-			fmt.Printf("\n\033[38;5;82m✓ Fix already applied to this issue\033[0m\n")
-		} else if issue.FixAvailable {
-			fmt.Printf("\n\033[38;5;82m✓ Suggested fix available\033[0m\n")
-
-			// Show diff by default if reasonable size
-			original := string(content)
-			diffLines := len(strings.Split(original, "\n")) + len(strings.Split(issue.SuggestedFix, "\n"))
-			if diffLines <= 100 {
-				showDiff(original, issue.SuggestedFix)
-			} else {
-				fmt.Printf("\n\033[38;5;203m(Diff too large - use [s] to show)\033[0m\n")
-			}
-		} else {
-			fmt.Printf("\n\033[38;5;203m⚠ No automatic fix available - manual review required\033[0m\n")
-		}
-
-		// Action menu
-		fmt.Printf("\n\033[38;5;208m━━━ Actions ━━━\033[0m\n")
-		if issue.FixAvailable && !appliedFixes[currentIdx] {
-			fmt.Println("  [a] Apply fix")
-			fmt.Println("  [s] Show diff")
-		}
-		fmt.Println("  [i] Ignore (skip this finding)")
-		if currentIdx < len(findings)-1 {
-			fmt.Println("  [n] Next finding")
-		}
-		if currentIdx > 0 {
-			fmt.Println("  [p] Previous finding")
-		}
-		fmt.Println("  [q] Quit review mode")
-		fmt.Printf("\n\033[38;5;208mChoice:\033[0m ")
-
-		// Read input
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("failed to read input: %w", err)
-		}
-		choice := strings.ToLower(strings.TrimSpace(input))
-
-		switch choice {
-		case "a":
-			if !issue.FixAvailable {
-				fmt.Println("\n\033[38;5;203m⚠ No fix available for this issue\033[0m")
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-				continue
-			}
-			if appliedFixes[currentIdx] {
-				fmt.Println("\n\033[38;5;203m⚠ Fix already applied\033[0m")
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-				continue
-			}
-
-			// Create backup on first fix
-			if !backupCreated {
-				backupPath := filePath + ".backup"
-				if err := os.WriteFile(backupPath, content, 0644); err != nil {
-					fmt.Printf("\n\033[38;5;203m✗ Failed to create backup: %v\033[0m\n", err)
-					fmt.Print("Press Enter to continue...")
-					reader.ReadString('\n')
-					continue
-				}
-				backupCreated = true
-				fmt.Printf("\n\033[38;5;82m✓ Backup created: %s\033[0m\n", backupPath)
-			}
-
-			// Use the suggested fix directly (no validation)
-			issue.SuggestedFix = extractCodeFromResponse(issue.SuggestedFix)
-
-			// Count lines in the fix and adjust line_end if needed
-			fixLineCount := len(strings.Split(strings.TrimSpace(issue.SuggestedFix), "\n"))
-			originalLineCount := issue.LineEnd - issue.LineStart + 1
-
-			// If fix has more lines than original, expand the range to match
-			// This handles cases where LLM initially identified single line but fix spans multiple
-			if fixLineCount > originalLineCount {
-				issue.LineEnd = issue.LineStart + fixLineCount - 1
-				// Make sure we don't go past end of file
-				if issue.LineEnd > len(lines) {
-					issue.LineEnd = len(lines)
-				}
-			}
-
-			// Apply the fix to the file
-			if err := applyFix(filePath, issue); err != nil {
-				fmt.Printf("\n\033[38;5;203m✗ Failed to apply fix: %v\033[0m\n", err)
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-				continue
-			}
-
-			// Mark as applied and reload content for next fixes
-			appliedFixes[currentIdx] = true
-			content, err = os.ReadFile(filePath)
-			if err != nil {
-				fmt.Printf("\n\033[38;5;203m✗ Failed to reload file: %v\033[0m\n", err)
-				return err
-			}
-			lines = strings.Split(string(content), "\n")
-
-			fmt.Printf("\n\033[38;5;82m✓ Fix applied successfully!\033[0m\n")
-
-			// Auto-advance to next finding
-			if currentIdx < len(findings)-1 {
-				currentIdx++
-				fmt.Println("\033[38;5;82mMoving to next finding...\033[0m")
-				time.Sleep(800 * time.Millisecond)
-			} else {
-				fmt.Println("\n\033[38;5;82mAll findings reviewed!\033[0m")
-				return nil
-			}
-
-		case "s":
-			if !issue.FixAvailable {
-				fmt.Println("\n\033[38;5;203m⚠ No fix available to show\033[0m")
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-				continue
-			}
-
-			// Extract original code
-			original := extractLines(lines, issue.LineStart, issue.LineEnd)
-			showDiff(original, issue.SuggestedFix)
-			fmt.Print("\nPress Enter to continue...")
-			reader.ReadString('\n')
-
-		case "i":
-			fmt.Printf("\n\033[38;5;82m✓ Ignoring this finding\033[0m\n")
-			if currentIdx < len(findings)-1 {
-				currentIdx++
-			} else {
-				fmt.Println("No more findings. Exiting review mode.")
-				return nil
-			}
-
-		case "n":
-			if currentIdx < len(findings)-1 {
-				currentIdx++
-			} else {
-				fmt.Println("\nAlready at last finding")
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-			}
-
-		case "p":
-			if currentIdx > 0 {
-				currentIdx--
-			} else {
-				fmt.Println("\nAlready at first finding")
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-			}
-
-		case "q":
-			fmt.Println("\n\033[38;5;208m👋 Exiting review mode\033[0m")
-			return nil
-
-		default:
-			fmt.Println("\n\033[38;5;203m⚠ Invalid choice\033[0m")
-			fmt.Print("Press Enter to continue...")
-			reader.ReadString('\n')
-		}
-	}
+// prepareFix cleans up issue's suggested fix as returned by the model.
+// Which lines it replaces is worked out by fixChange.
+func prepareFix(issue SecurityIssue) SecurityIssue {
+	issue.SuggestedFix = extractCodeFromResponse(issue.SuggestedFix)
+	return issue
 }
 
-// applyFix applies the suggested fix to the file
-func applyFix(filePath string, issue SecurityIssue) error {
-	if !issue.FixAvailable || issue.SuggestedFix == "" {
-		return fmt.Errorf("no fix available")
-	}
-
-	// Read file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-
+// FixEdit returns the lines of a file that issue's suggested fix replaces,
+// start to end (1-based, inclusive), and the fix re-indented to match them.
+// Line numbers out of range are clamped to the file.
+func FixEdit(lines []string, issue SecurityIssue) (start, end int, fixLines []string) {
 	// Validate and clamp line numbers (LLM sometimes gives inaccurate line numbers)
 	if issue.LineStart < 1 {
 		issue.LineStart = 1
@@ -879,7 +1509,7 @@ func applyFix(filePath string, issue SecurityIssue) error {
 	}
 
 	// Replace lines
-	fixLines := strings.Split(strings.TrimSuffix(issue.SuggestedFix, "\n"), "\n")
+	fixLines = strings.Split(strings.TrimSuffix(issue.SuggestedFix, "\n"), "\n")
 
 	// Find minimum indentation in the fix (to detect relative indentation)
 	minFixIndent := -1
@@ -943,56 +1573,13 @@ func applyFix(filePath string, issue SecurityIssue) error {
 		fixLines[i] = newIndent + trimmed
 	}
 
-	// Build new content
-	var newLines []string
-	newLines = append(newLines, lines[:issue.LineStart-1]...) // Lines before issue
-	newLines = append(newLines, fixLines...)                  // Fixed code
-	if issue.LineEnd < len(lines) {
-		newLines = append(newLines, lines[issue.LineEnd:]...) // Lines after issue
-	}
-
-	newContent := strings.Join(newLines, "\n")
-
-	// Write fixed content
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
-}
-
-// showDiff displays before/after with color coding
-func showDiff(original, fixed string) {
-	fmt.Printf("\n\033[38;5;208m━━━ Diff Preview ━━━\033[0m\n\n")
-
-	fmt.Printf("\033[38;5;203m--- Original ---\033[0m\n")
-	originalLines := strings.Split(strings.TrimSpace(original), "\n")
-	for _, line := range originalLines {
-		fmt.Printf("\033[38;5;203m- %s\033[0m\n", line)
-	}
-
-	fmt.Printf("\n\033[38;5;82m+++ Fixed ---\033[0m\n")
-	fixedLines := strings.Split(strings.TrimSpace(fixed), "\n")
-	for _, line := range fixedLines {
-		fmt.Printf("\033[38;5;82m+ %s\033[0m\n", line)
-	}
+	return issue.LineStart, issue.LineEnd, fixLines
 }
 
 // Helper functions
 
 func getSeverityColor(severity string) string {
-	switch severity {
-	case "CRITICAL":
-		return "\033[38;5;196m🔴" // Bright red
-	case "HIGH":
-		return "\033[38;5;208m🟠" // Orange
-	case "MEDIUM":
-		return "\033[38;5;226m🟡" // Yellow
-	case "LOW":
-		return "\033[38;5;82m🟢" // Green
-	default:
-		return "\033[0m"
-	}
+	return ui.SeverityColor(severity) + ui.SeverityEmoji(severity)
 }
 
 func showCodeContext(lines []string, lineStart, lineEnd int) {
@@ -1016,49 +1603,9 @@ func showCodeContext(lines []string, lineStart, lineEnd int) {
 	}
 }
 
-func extractLines(lines []string, lineStart, lineEnd int) string {
-	if lineStart < 1 || lineStart > len(lines) {
-		return ""
-	}
-	if lineEnd < lineStart || lineEnd > len(lines) {
-		lineEnd = len(lines)
-	}
-
-	extracted := lines[lineStart-1 : lineEnd]
-	return strings.Join(extracted, "\n")
-}
-
+// wrapText wraps text to width terminal columns.
 func wrapText(text string, width int) string {
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return text
-	}
-
-	var result strings.Builder
-	lineLen := 0
-
-	for i, word := range words {
-		wordLen := len(word)
-
-		if lineLen+wordLen+1 > width && lineLen > 0 {
-			result.WriteString("\n")
-			lineLen = 0
-		}
-
-		if lineLen > 0 {
-			result.WriteString(" ")
-			lineLen++
-		}
-
-		result.WriteString(word)
-		lineLen += wordLen
-
-		if i < len(words)-1 && lineLen > 0 {
-			// Continue
-		}
-	}
-
-	return result.String()
+	return ui.Wrap(text, width)
 }
 
 func maxInt(a, b int) int {
@@ -1099,15 +1646,28 @@ func extractCodeFromResponse(response string) string {
 
 	// If no CODE: marker found, strip markdown fences from entire response
 	if len(codeLines) == 0 {
-		result := strings.TrimSpace(response)
+		result := response
 		// Remove markdown code fences
 		result = strings.ReplaceAll(result, "```go", "")
 		result = strings.ReplaceAll(result, "```python", "")
 		result = strings.ReplaceAll(result, "```java", "")
 		result = strings.ReplaceAll(result, "```javascript", "")
 		result = strings.ReplaceAll(result, "```", "")
-		return strings.TrimSpace(result)
+		return trimBlankLines(result)
 	}
 
-	return strings.TrimSpace(strings.Join(codeLines, "\n"))
+	return trimBlankLines(strings.Join(codeLines, "\n"))
+}
+
+// trimBlankLines removes blank lines around code, keeping the indentation
+// of its first line, which the fix's relative indentation depends on.
+func trimBlankLines(code string) string {
+	lines := strings.Split(code, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
 }
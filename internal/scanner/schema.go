@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/pefman/sidekick/internal/ollama"
+)
+
+// findingsSchema is the JSON schema of security scan answers, as asked for
+// in getScanPrompt. Sent as Ollama's format parameter, it constrains the
+// model to valid JSON of this shape.
+var findingsSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"findings": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"severity":       map[string]interface{}{"type": "string", "enum": []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}},
+					"title":          map[string]interface{}{"type": "string"},
+					"description":    map[string]interface{}{"type": "string"},
+					"line_start":     map[string]interface{}{"type": "integer"},
+					"line_end":       map[string]interface{}{"type": "integer"},
+					"evidence":       map[string]interface{}{"type": "string"},
+					"recommendation": map[string]interface{}{"type": "string"},
+					"confidence":     map[string]interface{}{"type": "string", "enum": []string{"HIGH", "MEDIUM", "LOW"}},
+					"issue_id":       map[string]interface{}{"type": "string"},
+					"fix_available":  map[string]interface{}{"type": "boolean"},
+					"suggested_fix":  map[string]interface{}{"type": "string"},
+					"cvss_vector":    map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"severity", "title", "description", "line_start", "line_end", "evidence", "recommendation", "confidence", "fix_available"},
+			},
+		},
+	},
+	"required": []string{"findings"},
+}
+
+// SetStructuredOutput makes security scans send the findings schema with
+// each request, so Ollama only lets the model produce JSON of that shape.
+// Servers older than Ollama 0.5 reject schemas; disable it for them.
+func (s *Scanner) SetStructuredOutput(enabled bool) {
+	s.structuredOutput = enabled
+}
+
+// generateSchema is generate with the response constrained to schema when
+// structured output is enabled.
+func (s *Scanner) generateSchema(filePath, stage, model, prompt string, schema interface{}, opts *ollama.Options) (string, error) {
+	if !s.structuredOutput {
+		return s.generate(filePath, stage, model, prompt, opts)
+	}
+	start := time.Now()
+	response, usage, err := s.client.GenerateWithSchemaDetailed(s.contextFor(filePath), model, prompt, schema, opts)
+	s.addUsage(filePath, usage)
+	s.recordCall(filePath, stage, model, prompt, response, usage, err, time.Since(start))
+	return response, err
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pefman/sidekick/internal/cvss"
+)
+
+// Scorer turns the severity vectors models give with findings into scores.
+// The first scorer accepting a vector scores it.
+type Scorer interface {
+	Name() string
+	// Accepts reports whether vector is of the scorer's scheme.
+	Accepts(vector string) bool
+	// Score validates vector and returns it in canonical form with its
+	// score, from 0.0 to 10.0, and that score's severity: CRITICAL, HIGH,
+	// MEDIUM, LOW, or NONE.
+	Score(vector string) (canonical string, score float64, severity string, err error)
+}
+
+var (
+	scorersMu sync.RWMutex
+	scorers   = make(map[string]Scorer)
+)
+
+// RegisterScorer makes a scoring scheme available for finding vectors.
+// Registering the same name twice panics.
+func RegisterScorer(sc Scorer) {
+	scorersMu.Lock()
+	defer scorersMu.Unlock()
+	if _, dup := scorers[sc.Name()]; dup {
+		panic(fmt.Sprintf("scanner: scorer %q registered twice", sc.Name()))
+	}
+	scorers[sc.Name()] = sc
+}
+
+// scorerFor returns the scorer accepting vector, trying scorers by name.
+func scorerFor(vector string) (Scorer, bool) {
+	scorersMu.RLock()
+	defer scorersMu.RUnlock()
+	names := make([]string, 0, len(scorers))
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if scorers[name].Accepts(vector) {
+			return scorers[name], true
+		}
+	}
+	return nil, false
+}
+
+type cvssScorer struct{}
+
+func (cvssScorer) Name() string { return "cvss" }
+
+func (cvssScorer) Accepts(vector string) bool { return strings.HasPrefix(vector, "CVSS:3.") }
+
+func (cvssScorer) Score(vector string) (string, float64, string, error) {
+	v, err := cvss.Parse(vector)
+	if err != nil {
+		return "", 0, "", err
+	}
+	score := v.BaseScore()
+	return v.String(), score, cvss.Rating(score), nil
+}
+
+func init() {
+	RegisterScorer(cvssScorer{})
+}
+
+// severityFloor is the lowest CVSS score of each severity, the score
+// findings without a vector sort by.
+var severityFloor = map[string]float64{"CRITICAL": 9.0, "HIGH": 7.0, "MEDIUM": 4.0, "LOW": 0.1}
+
+// applyScores validates the CVSS vectors of issues and sets their score and,
+// from it, their severity; the model's label only stands for findings
+// without a vector. Invalid vectors are dropped.
+func (s *Scanner) applyScores(filePath string, issues []SecurityIssue) {
+	for i := range issues {
+		issue := &issues[i]
+		issue.CVSSScore = 0
+		vector := strings.TrimSpace(issue.CVSSVector)
+		issue.CVSSVector = ""
+		if vector == "" {
+			continue
+		}
+		sc, ok := scorerFor(vector)
+		if !ok {
+			s.logDebug(fmt.Sprintf("DROPPED VECTOR - %s:%d", filePath, issue.LineStart), fmt.Sprintf("No scorer accepts %q", vector))
+			continue
+		}
+		canonical, score, severity, err := sc.Score(vector)
+		if err != nil {
+			s.logDebug(fmt.Sprintf("DROPPED VECTOR - %s:%d", filePath, issue.LineStart), err.Error())
+			continue
+		}
+		issue.CVSSVector, issue.CVSSScore = canonical, score
+		if severity == "NONE" {
+			severity = "LOW"
+		}
+		issue.Severity = severity
+	}
+}
+
+// Score is the issue's CVSS score, for sorting. Findings without a vector,
+// or whose severity was overridden since scoring, rank at the lowest score
+// of their severity.
+func (i SecurityIssue) Score() float64 {
+	if i.CVSSVector != "" {
+		rating := cvss.Rating(i.CVSSScore)
+		if rating == "NONE" {
+			rating = "LOW"
+		}
+		if strings.EqualFold(rating, i.Severity) {
+			return i.CVSSScore
+		}
+	}
+	return severityFloor[strings.ToUpper(i.Severity)]
+}
+
+// SortIssues orders issues by score, highest first, and then by line.
+func SortIssues(issues []SecurityIssue) {
+	sort.SliceStable(issues, func(a, b int) bool {
+		if sa, sb := issues[a].Score(), issues[b].Score(); sa != sb {
+			return sa > sb
+		}
+		return issues[a].LineStart < issues[b].LineStart
+	})
+}
@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// migrationDirs are directory names that conventionally hold schema
+// migrations (Rails, Django, Alembic, Flyway, Liquibase, golang-migrate...).
+var migrationDirs = []string{"migrations", "migrate", "migration", "alembic", "flyway", "liquibase", "changelog"}
+
+// isSQLFile reports whether path is SQL or a schema migration.
+func isSQLFile(path string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		return true
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		for _, m := range migrationDirs {
+			if strings.EqualFold(dir, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sqlScanFocus returns extra Stage 2 instructions for SQL and migration
+// files, or "" for anything else.
+func sqlScanFocus(filename string) string {
+	if !isSQLFile(filename) {
+		return ""
+	}
+	return `
+This is a SQL or schema migration file. In addition to general issues, check for:
+- Dynamic SQL: EXECUTE/EXEC/sp_executesql/PREPARE built by concatenating parameters or variables
+- Excessive grants: GRANT ALL, grants to PUBLIC, WITH GRANT OPTION, SUPERUSER/DBA roles for application users
+- Unsafe defaults: default or hardcoded passwords, credentials or tokens stored in plaintext columns, row-level security disabled, SECURITY DEFINER functions without a fixed search_path
+- Destructive or risky migrations: DROP/TRUNCATE or data-rewriting UPDATE/DELETE without a WHERE clause
+- Sensitive data (PII, secrets) inserted as seed data
+`
+}
@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// staticEngine reports the triad's static pattern matches on their own,
+// without calling the model.
+type staticEngine struct{}
+
+func (staticEngine) Name() string        { return "static" }
+func (staticEngine) Description() string { return "Pattern-based static checks only (no model calls)" }
+func (staticEngine) Stages() int         { return 2 }
+func (staticEngine) Offline()            {}
+func (staticEngine) ScanFile(s *Scanner, filePath string, content []byte, progress *Progress) (ScanResult, error) {
+	progress.Stage("Matching static patterns in %s", filepath.Base(filePath))
+
+	var issues []SecurityIssue
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), maxFileSize+1)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		for _, f := range matchTriadPatterns(filePath, lineNum, strings.TrimSpace(sc.Text())) {
+			issues = append(issues, SecurityIssue{
+				Severity:       strings.ToUpper(f.Severity),
+				Title:          f.Pattern,
+				Description:    "Matched the static pattern \"" + f.Pattern + "\". Static matches are not verified and may be false positives.",
+				LineStart:      f.Line,
+				LineEnd:        f.Line,
+				Recommendation: "Review this call site; run a security or triad scan for an LLM assessment.",
+				Confidence:     "LOW",
+			})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return ScanResult{FilePath: filePath}, err
+	}
+
+	return s.staticResult(filePath, issues), nil
+}
+
+// staticResult annotates issues found without the model.
+func (s *Scanner) staticResult(filePath string, issues []SecurityIssue) ScanResult {
+	if issues == nil {
+		issues = []SecurityIssue{}
+	}
+	issues = s.annotateIssues(filePath, issues)
+	return ScanResult{
+		FilePath:  filePath,
+		Issues:    issues,
+		HasIssues: len(issues) > 0,
+	}
+}
+
+// secretRule is a pattern for a credential committed to source.
+type secretRule struct {
+	title    string
+	severity string
+	pattern  *regexp.Regexp
+}
+
+var secretRules = []secretRule{
+	{"Private Key", "CRITICAL", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS Access Key ID", "CRITICAL", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub Token", "CRITICAL", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack Token", "HIGH", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API Key", "HIGH", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe Secret Key", "CRITICAL", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{16,}\b`)},
+	{"Hardcoded Credential", "HIGH", regexp.MustCompile(`(?i)(?:password|passwd|secret|api_?key|access_?token|auth_?token)["']?\s*[:=]\s*["']([^"'\s]{8,})["']`)},
+}
+
+// secretsEngine finds hardcoded credentials with regular expressions.
+type secretsEngine struct{}
+
+func (secretsEngine) Name() string { return "secrets" }
+func (secretsEngine) Description() string {
+	return "Detect hardcoded keys, tokens and passwords (no model calls)"
+}
+func (secretsEngine) Stages() int { return 2 }
+func (secretsEngine) Offline()    {}
+func (secretsEngine) ScanFile(s *Scanner, filePath string, content []byte, progress *Progress) (ScanResult, error) {
+	progress.Stage("Looking for secrets in %s", filepath.Base(filePath))
+
+	var issues []SecurityIssue
+	secrets := make(map[int]string) // Line number -> matched secret
+	for i, line := range strings.Split(string(content), "\n") {
+		for _, rule := range secretRules {
+			m := rule.pattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			secret := m[len(m)-1]
+			issues = append(issues, SecurityIssue{
+				Severity:       rule.severity,
+				Title:          rule.title,
+				Description:    "A " + strings.ToLower(rule.title) + " appears to be committed in source code.",
+				LineStart:      i + 1,
+				LineEnd:        i + 1,
+				Recommendation: "Revoke and rotate the credential, then load it from the environment or a secret manager.",
+				Confidence:     "MEDIUM",
+				IssueID:        "CWE-798",
+			})
+			secrets[i+1] = secret
+			break
+		}
+	}
+
+	result := s.staticResult(filePath, issues)
+	// Never echo the secret itself into reports
+	for i := range result.Issues {
+		secret := secrets[result.Issues[i].LineStart]
+		result.Issues[i].CodeSnippet = strings.ReplaceAll(result.Issues[i].CodeSnippet, secret, redactSecret(secret))
+	}
+	return result, nil
+}
+
+// redactSecret keeps only the first four characters of a secret.
+func redactSecret(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return secret[:4] + strings.Repeat("*", 8)
+}
+
+func init() {
+	RegisterEngine(staticEngine{})
+	RegisterEngine(secretsEngine{})
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/pefman/sidekick/internal/history"
+)
+
+// ApplySuppressions drops the findings of results suppressed at now and
+// marks those whose suppression has expired as resurfaced, for re-review.
+// results itself is not modified. It returns the results and the number of
+// findings dropped.
+func ApplySuppressions(results []ScanResult, list []history.Suppression, now time.Time) ([]ScanResult, int) {
+	if len(list) == 0 {
+		return results, 0
+	}
+	byFingerprint := make(map[string]history.Suppression, len(list))
+	for _, s := range list {
+		byFingerprint[s.Fingerprint] = s
+	}
+
+	suppressed := 0
+	out := make([]ScanResult, len(results))
+	for i, r := range results {
+		out[i] = r
+		changed := false
+		kept := make([]SecurityIssue, 0, len(r.Issues))
+		for _, issue := range r.Issues {
+			s, ok := byFingerprint[issue.Fingerprint(r.FilePath)]
+			switch {
+			case !ok:
+			case s.Expired(now):
+				issue.Resurfaced = true
+				changed = true
+			default:
+				suppressed++
+				changed = true
+				continue
+			}
+			kept = append(kept, issue)
+		}
+		if !changed {
+			continue
+		}
+		out[i].Issues = kept
+		out[i].HasIssues = len(kept) > 0
+		out[i].RawFindings = ""
+	}
+	return out, suppressed
+}
+
+// Resurfaced counts the findings of results whose suppression expired.
+func Resurfaced(results []ScanResult) int {
+	n := 0
+	for _, r := range results {
+		for _, issue := range r.Issues {
+			if issue.Resurfaced {
+				n++
+			}
+		}
+	}
+	return n
+}
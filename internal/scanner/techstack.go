@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+)
+
+// FileContext is the Stage 1 context analysis of a file: what it is written
+// in and what it does.
+type FileContext struct {
+	Language   string   `json:"language"`
+	Version    string   `json:"version,omitempty"`
+	Frameworks []string `json:"frameworks,omitempty"`
+	Libraries  []string `json:"libraries,omitempty"`
+	Purpose    string   `json:"purpose,omitempty"`
+}
+
+// parseFileContext reads the fields of a context analysis response that
+// describe the file. It returns nil when the response isn't usable JSON.
+func parseFileContext(analysis string) *FileContext {
+	var fc FileContext
+	if err := json.Unmarshal([]byte(analysis), &fc); err != nil {
+		if err := json.Unmarshal([]byte(fixJSONStringEscaping(analysis)), &fc); err != nil {
+			return nil
+		}
+	}
+	fc.Language = strings.TrimSpace(fc.Language)
+	if fc.Language == "" || strings.EqualFold(fc.Language, "unknown") {
+		return nil
+	}
+	if strings.EqualFold(fc.Version, "unknown") {
+		fc.Version = ""
+	}
+	return &fc
+}
+
+// SetContextCache enables reusing Stage 1 context analyses saved by earlier
+// scans of identical content with the same model and prompt. Leave it off
+// for mock, recorded or replayed sessions.
+func (s *Scanner) SetContextCache(enabled bool) {
+	s.contextCache = enabled
+}
+
+// contextCachePath returns where the analysis for a prompt is cached.
+func contextCachePath(model, prompt string) (string, error) {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(model + "\x00" + prompt))
+	return filepath.Join(dir, "context", hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// cachedContext returns a cached analysis for prompt, if there is one.
+func (s *Scanner) cachedContext(model, prompt string) (string, bool) {
+	if !s.contextCache {
+		return "", false
+	}
+	path, err := contextCachePath(model, prompt)
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// cacheContext saves an analysis for prompt. Failures only cost a model
+// call next time, so they are logged and otherwise ignored.
+func (s *Scanner) cacheContext(model, prompt, analysis string) {
+	if !s.contextCache || parseFileContext(analysis) == nil {
+		return
+	}
+	path, err := contextCachePath(model, prompt)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			err = os.WriteFile(path, []byte(analysis), 0600)
+		}
+	}
+	if err != nil {
+		s.logDebug("CONTEXT CACHE WRITE FAILED", err.Error())
+	}
+}
+
+// StackItem counts the files using a language, framework or library.
+type StackItem struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+}
+
+// TechStack aggregates the context analyses of a scan.
+type TechStack struct {
+	Languages  []StackItem `json:"languages"`
+	Frameworks []StackItem `json:"frameworks,omitempty"`
+	Libraries  []StackItem `json:"libraries,omitempty"`
+}
+
+// SummarizeTechStack counts languages, frameworks and libraries across
+// results, most used first. It returns nil when no result has a context.
+func SummarizeTechStack(results []ScanResult) *TechStack {
+	languages := newStackCounter()
+	frameworks := newStackCounter()
+	libraries := newStackCounter()
+	for _, r := range results {
+		if r.Context == nil {
+			continue
+		}
+		languages.add(r.Context.Language)
+		for _, f := range r.Context.Frameworks {
+			frameworks.add(f)
+		}
+		for _, l := range r.Context.Libraries {
+			libraries.add(l)
+		}
+	}
+	if len(languages.counts) == 0 {
+		return nil
+	}
+	return &TechStack{
+		Languages:  languages.items(),
+		Frameworks: frameworks.items(),
+		Libraries:  libraries.items(),
+	}
+}
+
+// stackCounter counts names case-insensitively, keeping the first spelling.
+type stackCounter struct {
+	counts map[string]int
+	names  map[string]string
+}
+
+func newStackCounter() *stackCounter {
+	return &stackCounter{counts: make(map[string]int), names: make(map[string]string)}
+}
+
+func (c *stackCounter) add(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	key := strings.ToLower(name)
+	if _, ok := c.names[key]; !ok {
+		c.names[key] = name
+	}
+	c.counts[key]++
+}
+
+func (c *stackCounter) items() []StackItem {
+	items := make([]StackItem, 0, len(c.counts))
+	for key, n := range c.counts {
+		items = append(items, StackItem{Name: c.names[key], Files: n})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Files != items[j].Files {
+			return items[i].Files > items[j].Files
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
@@ -0,0 +1,38 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// testDirs are directory names that hold tests or test fixtures.
+var testDirs = map[string]bool{
+	"test": true, "tests": true, "__tests__": true, "spec": true, "specs": true, "testdata": true,
+}
+
+// IsTestDir reports whether a directory name conventionally holds tests.
+func IsTestDir(name string) bool {
+	return testDirs[strings.ToLower(name)]
+}
+
+// IsTestFile reports whether a file name looks like a test or spec file
+// (foo_test.go, test_foo.py, foo.spec.ts, FooTest.java, ...).
+func IsTestFile(name string) bool {
+	base := filepath.Base(name)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	lower := strings.ToLower(stem)
+
+	switch {
+	case strings.HasSuffix(lower, "_test"), strings.HasSuffix(lower, "_spec"):
+		return true
+	case strings.HasSuffix(lower, ".test"), strings.HasSuffix(lower, ".spec"):
+		return true
+	case ext == ".py" && strings.HasPrefix(lower, "test_"):
+		return true
+	case (ext == ".java" || ext == ".kt" || ext == ".cs" || ext == ".php") &&
+		(strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests")):
+		return true
+	}
+	return false
+}
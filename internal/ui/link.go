@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// editorPresets are editor URL templates selectable by name.
+var editorPresets = map[string]string{
+	"file":            "",
+	"vscode":          "vscode://file/{path}:{line}",
+	"vscode-insiders": "vscode-insiders://file/{path}:{line}",
+	"cursor":          "cursor://file/{path}:{line}",
+	"idea":            "idea://open?file={path}&line={line}",
+	"sublime":         "subl://open?url=file://{path}&line={line}",
+}
+
+var (
+	editorURL  string // Template, "" for file:// links
+	hyperlinks = true
+)
+
+// CheckEditorURL reports whether s is an editor preset name (file, vscode,
+// vscode-insiders, cursor, idea, sublime) or a URL template containing
+// {path}.
+func CheckEditorURL(s string) error {
+	if _, ok := editorPresets[s]; ok || s == "" || strings.Contains(s, "{path}") {
+		return nil
+	}
+	names := make([]string, 0, len(editorPresets))
+	for name := range editorPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("must be one of %s or a URL template containing {path}, e.g. \"vscode://file/{path}:{line}\"", strings.Join(names, ", "))
+}
+
+// SetEditorURL sets how finding links open files: an editor preset name or
+// a URL template in which {path} is replaced by the absolute file path and
+// {line} by the line number. Empty means file:// links.
+func SetEditorURL(s string) error {
+	if err := CheckEditorURL(s); err != nil {
+		return err
+	}
+	if preset, ok := editorPresets[s]; ok {
+		s = preset
+	}
+	editorURL = s
+	return nil
+}
+
+// SetHyperlinks enables or disables terminal hyperlinks, e.g. for files
+// that only exist for the duration of a scan. On by default.
+func SetHyperlinks(enabled bool) {
+	hyperlinks = enabled
+}
+
+// EditorURL returns the link that opens path at line (1 when unknown) in
+// the configured editor.
+func EditorURL(path string, line int) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if editorURL == "" {
+		u := url.URL{Scheme: "file", Path: path}
+		if line > 0 {
+			u.Fragment = fmt.Sprintf("L%d", line)
+		}
+		return u.String()
+	}
+	if line < 1 {
+		line = 1
+	}
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	// "vscode://file/{path}" already has the slash that starts the path;
+	// "file://{path}" does not
+	if i := strings.Index(editorURL, "{path}"); i > 0 && editorURL[i-1] == '/' && !strings.HasSuffix(editorURL[:i], "//") {
+		escaped = strings.TrimPrefix(escaped, "/")
+	}
+	return strings.NewReplacer("{path}", escaped, "{line}", strconv.Itoa(line)).Replace(editorURL)
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal, checked once.
+var stdoutIsTerminal = sync.OnceValue(func() bool {
+	info, err := Stdout().Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+})
+
+// Hyperlink makes text a clickable link to target (OSC 8) when stdout is
+// a terminal that may support it, and returns text unchanged otherwise.
+func Hyperlink(target, text string) string {
+	if !hyperlinks || plain || !stdoutIsTerminal() || os.Getenv("TERM") == "dumb" {
+		return text
+	}
+	return "\033]8;;" + target + "\033\\" + text + "\033]8;;\033\\"
+}
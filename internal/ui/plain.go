@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	plain     bool
+	plainMu   sync.Mutex
+	plainDone []chan struct{}
+	plainPipe []*os.File
+	plainOrig []*os.File
+)
+
+// ansiEscape matches terminal control sequences (colors, cursor movement,
+// screen clearing, hyperlinks).
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// SetPlain enables or disables screen-reader-friendly plain output.
+func SetPlain(enabled bool) {
+	plain = enabled
+}
+
+// Plain reports whether plain output is enabled.
+func Plain() bool {
+	return plain
+}
+
+// PlainText strips control sequences, emoji, spinner frames and
+// box-drawing characters from s, leaving linear text.
+func PlainText(s string) string {
+	s = ansiEscape.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r", "")
+
+	var b strings.Builder
+	dropped := false
+	for _, r := range s {
+		switch {
+		case r == '▸' || r == '›':
+			b.WriteRune('>')
+		case r == '•' || r == '●':
+			b.WriteRune('-')
+		case isDecoration(r):
+			dropped = true
+			continue
+		case r == ' ' && dropped:
+			// Drop the spaces that separated a removed symbol from the text
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		dropped = false
+	}
+	return b.String()
+}
+
+func isDecoration(r rune) bool {
+	switch {
+	case r >= 0x2500 && r <= 0x257F: // Box drawing
+	case r >= 0x2580 && r <= 0x259F: // Block elements
+	case r >= 0x2190 && r <= 0x21FF: // Arrows
+	case r >= 0x2600 && r <= 0x27BF: // Misc symbols, dingbats (✓ ✗ ⚠)
+	case r >= 0x2800 && r <= 0x28FF: // Braille (spinner frames)
+	case r >= 0x2B00 && r <= 0x2BFF: // Misc symbols and arrows
+	case r >= 0x1F000 && r <= 0x1FAFF: // Emoji
+	case r == 0xFE0F || r == 0x200D: // Emoji variation selector, joiner
+	default:
+		return false
+	}
+	return true
+}
+
+// StartPlainOutput routes os.Stdout and os.Stderr through PlainText so that
+// every part of the CLI and TUI produces plain output; Stdout and Stderr
+// still reach the originals. Call StopPlainOutput before exiting to flush
+// it.
+func StartPlainOutput() error {
+	plainMu.Lock()
+	defer plainMu.Unlock()
+
+	for _, target := range []**os.File{&os.Stdout, &os.Stderr} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		orig := *target
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			buf := make([]byte, 4096)
+			var pending []byte
+			for {
+				n, err := r.Read(buf)
+				if n > 0 {
+					data := append(pending, buf[:n]...)
+					// Hold back a multi-byte character split across reads
+					cut := len(data)
+					for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+						if utf8.RuneStart(data[i]) {
+							if !utf8.FullRune(data[i:]) {
+								cut = i
+							}
+							break
+						}
+					}
+					pending = append([]byte(nil), data[cut:]...)
+					io.WriteString(orig, PlainText(string(data[:cut])))
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+		*target = w
+		plainPipe = append(plainPipe, w)
+		plainDone = append(plainDone, done)
+		plainOrig = append(plainOrig, orig)
+	}
+	return nil
+}
+
+// Stdout returns standard output as the process got it, bypassing the
+// plain output filter: for output whose bytes must not change, such as
+// reports and the LSP stream, and for child processes that need the
+// terminal.
+func Stdout() *os.File {
+	return unfiltered(0, os.Stdout)
+}
+
+// Stderr is Stdout for standard error.
+func Stderr() *os.File {
+	return unfiltered(1, os.Stderr)
+}
+
+func unfiltered(i int, current *os.File) *os.File {
+	plainMu.Lock()
+	defer plainMu.Unlock()
+	if i < len(plainOrig) {
+		return plainOrig[i]
+	}
+	return current
+}
+
+// StopPlainOutput flushes and closes the filters set up by StartPlainOutput.
+func StopPlainOutput() {
+	plainMu.Lock()
+	defer plainMu.Unlock()
+
+	targets := []**os.File{&os.Stdout, &os.Stderr}
+	for i, w := range plainPipe {
+		w.Close()
+		<-plainDone[i]
+		*targets[i] = plainOrig[i]
+	}
+	plainPipe = nil
+	plainDone = nil
+	plainOrig = nil
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// severityStyle is how a severity is shown in the terminal, review mode and
+// reports.
+type severityStyle struct {
+	emoji string
+	color string // Hex, e.g. "#ff8700"
+}
+
+// severityStyles are the defaults: the terminal's traditional colors.
+var severityStyles = map[string]severityStyle{
+	"CRITICAL": {"🔴", "#ff0000"},
+	"HIGH":     {"🟠", "#ff8700"},
+	"MEDIUM":   {"🟡", "#ffff00"},
+	"LOW":      {"🟢", "#5fff00"},
+}
+
+// unknownSeverityColor is used for severities without a style.
+const unknownSeverityColor = "#9f9f9f"
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// SetSeverityStyle overrides the emoji and color of a severity. Empty
+// values, and colors that aren't "#rrggbb", keep the default.
+func SetSeverityStyle(severity, emoji, color string) {
+	severity = strings.ToUpper(severity)
+	style := severityStyles[severity]
+	if emoji != "" {
+		style.emoji = emoji
+	}
+	if hexColor.MatchString(color) {
+		style.color = strings.ToLower(color)
+	}
+	severityStyles[severity] = style
+}
+
+// SeverityEmoji returns the emoji for a severity, or "" if it has none.
+func SeverityEmoji(severity string) string {
+	return severityStyles[strings.ToUpper(severity)].emoji
+}
+
+// SeverityHex returns the "#rrggbb" color of a severity.
+func SeverityHex(severity string) string {
+	if style, ok := severityStyles[strings.ToUpper(severity)]; ok && style.color != "" {
+		return style.color
+	}
+	return unknownSeverityColor
+}
+
+// SeverityColor returns the terminal escape sequence selecting the color of
+// a severity, using the nearest of the 256 standard terminal colors.
+func SeverityColor(severity string) string {
+	hex := SeverityHex(severity)
+	var rgb [3]int
+	for i := range rgb {
+		v, _ := strconv.ParseUint(hex[1+2*i:3+2*i], 16, 8)
+		rgb[i] = cubeLevel(int(v))
+	}
+	return fmt.Sprintf("\033[38;5;%dm", 16+36*rgb[0]+6*rgb[1]+rgb[2])
+}
+
+// cubeLevel maps a color component to the nearest of the six levels of the
+// 256-color cube (0, 95, 135, 175, 215, 255).
+func cubeLevel(v int) int {
+	if v < 48 {
+		return 0
+	}
+	if v < 115 {
+		return 1
+	}
+	return (v - 35) / 40
+}
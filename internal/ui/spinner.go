@@ -31,21 +31,31 @@ func (s *Spinner) Start() {
 	s.active = true
 	s.mu.Unlock()
 
+	if plain {
+		// No animation: print the status once, and again whenever it changes
+		if s.message != "" {
+			Printf("%s\n", s.message)
+		}
+		return
+	}
+
 	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
 		i := 0
 		for {
+			s.mu.Lock()
+			frame := s.frames[i%len(s.frames)]
+			msg := s.message
+			s.mu.Unlock()
+
+			term.setStatus(fmt.Sprintf("\033[38;5;208m%s\033[0m %s", frame, msg))
+			i++
+
 			select {
 			case <-s.done:
 				return
-			default:
-				s.mu.Lock()
-				frame := s.frames[i%len(s.frames)]
-				msg := s.message
-				s.mu.Unlock()
-
-				fmt.Printf("\r\033[K\033[38;5;208m%s\033[0m %s", frame, msg)
-				i++
-				time.Sleep(80 * time.Millisecond)
+			case <-ticker.C:
 			}
 		}
 	}()
@@ -53,8 +63,14 @@ func (s *Spinner) Start() {
 
 func (s *Spinner) UpdateMessage(message string) {
 	s.mu.Lock()
+	changed := s.message != message
 	s.message = message
+	active := s.active
 	s.mu.Unlock()
+
+	if plain && active && changed {
+		Printf("%s\n", message)
+	}
 }
 
 func (s *Spinner) Stop() {
@@ -66,6 +82,10 @@ func (s *Spinner) Stop() {
 	s.active = false
 	s.mu.Unlock()
 
+	if plain {
+		return
+	}
+
 	s.done <- true
-	fmt.Print("\r\033[K") // Clear line
+	term.clearStatus()
 }
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval is how often the spinner redraws by default.
+const DefaultRefreshInterval = 80 * time.Millisecond
+
+var refreshInterval = DefaultRefreshInterval
+
+// SetRefreshInterval sets how often the spinner and status line redraw.
+// Values below 10ms are raised to 10ms.
+func SetRefreshInterval(d time.Duration) {
+	if d < 10*time.Millisecond {
+		d = 10 * time.Millisecond
+	}
+	refreshInterval = d
+}
+
+// terminal serializes everything written while a status line may be on
+// screen. Messages clear the status line first and redraw it afterwards,
+// under the same lock as spinner frames, so a worker's error can never land
+// in the middle of a frame.
+type terminal struct {
+	mu     sync.Mutex
+	status string // Current status line, "" when none is shown
+}
+
+var term terminal
+
+// setStatus draws line as the status line, replacing the previous one.
+func (t *terminal) setStatus(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status = line
+	os.Stdout.WriteString("\r\033[K" + line)
+}
+
+// clearStatus removes the status line from the screen.
+func (t *terminal) clearStatus() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status == "" {
+		return
+	}
+	t.status = ""
+	os.Stdout.WriteString("\r\033[K")
+}
+
+// write prints msg to f above the status line.
+func (t *terminal) write(f *os.File, msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.status == "" {
+		f.WriteString(msg)
+		return
+	}
+	if f == os.Stdout {
+		// One write: clear, message, redraw
+		var buf bytes.Buffer
+		buf.WriteString("\r\033[K")
+		buf.WriteString(msg)
+		buf.WriteString(t.status)
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	os.Stdout.WriteString("\r\033[K")
+	f.WriteString(msg)
+	os.Stdout.WriteString(t.status)
+}
+
+// Printf writes to stdout without garbling an active spinner.
+func Printf(format string, args ...interface{}) {
+	term.write(os.Stdout, fmt.Sprintf(format, args...))
+}
+
+// Eprintf writes to stderr without garbling an active spinner.
+func Eprintf(format string, args ...interface{}) {
+	term.write(os.Stderr, fmt.Sprintf(format, args...))
+}
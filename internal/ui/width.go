@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text is measured in terminal columns, not bytes or runes: CJK ideographs
+// and most emoji take two columns, combining marks and zero-width joiners
+// none. Findings written by the model in Japanese or with emoji would
+// otherwise overflow wrapped paragraphs and push table columns out of line.
+
+// wideRanges are the East Asian Wide and Fullwidth blocks and the emoji
+// blocks terminals draw two columns wide.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo initials
+	{0x231A, 0x231B},   // Watch, hourglass
+	{0x2329, 0x232A},   // Angle brackets
+	{0x23E9, 0x23EC},   // Media controls
+	{0x23F0, 0x23F0},   // Alarm clock
+	{0x23F3, 0x23F3},   // Hourglass flowing
+	{0x25FD, 0x25FE},   // Small squares
+	{0x2614, 0x2615},   // Umbrella, hot beverage
+	{0x2648, 0x2653},   // Zodiac
+	{0x267F, 0x267F},   // Wheelchair
+	{0x2693, 0x2693},   // Anchor
+	{0x26A1, 0x26A1},   // High voltage
+	{0x26AA, 0x26AB},   // Circles
+	{0x26BD, 0x26BE},   // Balls
+	{0x26C4, 0x26C5},   // Snowman, sun
+	{0x26CE, 0x26CE},   // Ophiuchus
+	{0x26D4, 0x26D4},   // No entry
+	{0x26EA, 0x26EA},   // Church
+	{0x26F2, 0x26F5},   // Fountain .. sailboat
+	{0x26FA, 0x26FA},   // Tent
+	{0x26FD, 0x26FD},   // Fuel pump
+	{0x2705, 0x2705},   // Check mark button
+	{0x270A, 0x270B},   // Fists
+	{0x2728, 0x2728},   // Sparkles
+	{0x274C, 0x274C},   // Cross mark
+	{0x274E, 0x274E},   // Cross mark button
+	{0x2753, 0x2755},   // Question marks
+	{0x2757, 0x2757},   // Exclamation mark
+	{0x2795, 0x2797},   // Plus, minus, divide
+	{0x27B0, 0x27B0},   // Curly loop
+	{0x27BF, 0x27BF},   // Double curly loop
+	{0x2B1B, 0x2B1C},   // Large squares
+	{0x2B50, 0x2B50},   // Star
+	{0x2B55, 0x2B55},   // Circle
+	{0x2E80, 0x303E},   // CJK radicals .. CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana .. CJK compatibility
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // Vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x16FE0, 0x18CFF}, // Tangut and friends
+	{0x1B000, 0x1B2FF}, // Kana supplements
+	{0x1F004, 0x1F004}, // Mahjong tile
+	{0x1F0CF, 0x1F0CF}, // Joker
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // Squared words
+	{0x1F200, 0x1F2FF}, // Enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // Pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // Transport and map
+	{0x1F7E0, 0x1F7EB}, // Colored circles and squares
+	{0x1F90C, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // Symbols and pictographs extended A
+	{0x20000, 0x3FFFD}, // CJK extensions B and beyond
+}
+
+// ambiguousRanges are East Asian Ambiguous characters (Greek, Cyrillic,
+// box drawing, circled digits, ...) that CJK terminals draw two columns wide.
+var ambiguousRanges = []struct{ lo, hi rune }{
+	{0x00A1, 0x00A1}, {0x00A4, 0x00A4}, {0x00A7, 0x00A8}, {0x00AA, 0x00AA},
+	{0x00AD, 0x00AE}, {0x00B0, 0x00B4}, {0x00B6, 0x00BA}, {0x00BC, 0x00BF},
+	{0x00D7, 0x00D7}, {0x00F7, 0x00F7},
+	{0x0391, 0x03A9}, {0x03B1, 0x03C9}, {0x0401, 0x0401}, {0x0410, 0x044F},
+	{0x0451, 0x0451},
+	{0x2010, 0x2010}, {0x2013, 0x2016}, {0x2018, 0x2019}, {0x201C, 0x201D},
+	{0x2020, 0x2022}, {0x2024, 0x2027}, {0x2030, 0x2030}, {0x2032, 0x2033},
+	{0x2035, 0x2035}, {0x203B, 0x203B}, {0x2103, 0x2103}, {0x2116, 0x2116},
+	{0x2121, 0x2122}, {0x2160, 0x216B}, {0x2170, 0x2179}, {0x2190, 0x2199},
+	{0x21D2, 0x21D2}, {0x21D4, 0x21D4}, {0x2200, 0x22FF}, {0x2460, 0x24E9},
+	{0x2500, 0x257F}, {0x2580, 0x258F}, {0x25A0, 0x25A1}, {0x25B2, 0x25B3},
+	{0x25BC, 0x25BD}, {0x25C6, 0x25C8}, {0x25CB, 0x25CB}, {0x25CE, 0x25D1},
+	{0x2605, 0x2606}, {0x2609, 0x2609}, {0x260E, 0x260F}, {0x2640, 0x2640},
+	{0x2642, 0x2642}, {0x2660, 0x2665}, {0x2667, 0x266A}, {0x266C, 0x266D},
+	{0x266F, 0x266F},
+}
+
+// eastAsian is whether ambiguous-width characters count as two columns. It
+// follows the locale, as CJK terminals do.
+var eastAsian = localeIsEastAsian()
+
+// localeIsEastAsian reports whether the locale's language is Chinese,
+// Japanese or Korean.
+func localeIsEastAsian() bool {
+	switch Language() {
+	case "zh", "ja", "ko":
+		return true
+	}
+	return false
+}
+
+// Language returns the two-letter language of the user's locale, taken from
+// LC_ALL, LC_MESSAGES or LANG, or "" when unset or "C"/"POSIX".
+func Language() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		if v == "C" || v == "POSIX" || strings.HasPrefix(v, "C.") {
+			return ""
+		}
+		lang, _, _ := strings.Cut(v, ".")
+		lang, _, _ = strings.Cut(lang, "_")
+		lang, _, _ = strings.Cut(lang, "@")
+		return strings.ToLower(lang)
+	}
+	return ""
+}
+
+func inRanges(r rune, ranges []struct{ lo, hi rune }) bool {
+	for _, rg := range ranges {
+		if r < rg.lo {
+			return false
+		}
+		if r <= rg.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// RuneWidth returns the number of terminal columns r occupies: 0, 1 or 2.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7F:
+		return 0
+	case r < 0x7F:
+		return 1
+	case r == 0x200B || r == 0x200C || r == 0x200D || r == 0x2060 || r == 0xFEFF:
+		return 0 // Zero-width spaces and joiners
+	case r >= 0xFE00 && r <= 0xFE0F:
+		return 0 // Variation selectors
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		return 0 // Skin tone modifiers, drawn on the preceding emoji
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case inRanges(r, wideRanges):
+		return 2
+	case eastAsian && inRanges(r, ambiguousRanges):
+		return 2
+	}
+	return 1
+}
+
+// Width returns the number of terminal columns s occupies. ANSI color
+// sequences and hyperlink markers take none.
+func Width(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := ansiLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w += RuneWidth(r)
+		i += size
+	}
+	return w
+}
+
+// ansiLen returns the length of the CSI or OSC escape sequence at the
+// start of s, or 0 if s does not start with one.
+func ansiLen(s string) int {
+	if len(s) < 2 || s[0] != '\033' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7E {
+				return i + 1
+			}
+		}
+	case ']':
+		// Ended by BEL or ST (ESC \), as in OSC 8 hyperlinks
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\033' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 0
+	}
+	return len(s)
+}
+
+// PadRight pads s with spaces to width columns, like "%-*s" would for ASCII.
+func PadRight(s string, width int) string {
+	if w := Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// Truncate shortens s to at most width columns, ending it with "…" when cut.
+// It never splits a rune.
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := RuneWidth(r)
+		if w+rw > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String() + "…"
+}
+
+// Wrap breaks text into lines of at most width columns. Words are kept
+// whole where they fit; words wider than a line, and runs of CJK text,
+// which has no spaces, are broken between characters.
+func Wrap(text string, width int) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return text
+	}
+
+	var result strings.Builder
+	lineLen := 0
+	newline := func() {
+		result.WriteString("\n")
+		lineLen = 0
+	}
+
+	for _, word := range words {
+		wordLen := Width(word)
+		if lineLen > 0 && lineLen+1+wordLen > width {
+			if !breakable(word) {
+				newline()
+			}
+		}
+		if lineLen > 0 {
+			if lineLen+1 >= width {
+				newline()
+			} else {
+				result.WriteString(" ")
+				lineLen++
+			}
+		}
+		if lineLen+wordLen <= width {
+			result.WriteString(word)
+			lineLen += wordLen
+			continue
+		}
+		for _, r := range word {
+			rw := RuneWidth(r)
+			if lineLen > 0 && lineLen+rw > width {
+				newline()
+			}
+			result.WriteRune(r)
+			lineLen += rw
+		}
+	}
+
+	return result.String()
+}
+
+// breakable reports whether word may be split at any character rather than
+// moved to the next line whole: true for words that start with a wide
+// (CJK) character.
+func breakable(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return RuneWidth(r) == 2
+}
@@ -0,0 +1,162 @@
+package walker
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFiles are read in every directory, in this order, so a
+// .sidekickignore can re-include (!pattern) what .gitignore excludes.
+var ignoreFiles = []string{".gitignore", ".sidekickignore"}
+
+// rule is one gitignore-style pattern, matched against paths relative to
+// the directory holding the file it came from.
+type rule struct {
+	base    string // Absolute directory the pattern is relative to
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ruleSet holds rules in the order they apply; the last match wins.
+type ruleSet []rule
+
+// match reports whether the last rule matching path ignores it.
+func (rs ruleSet) match(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range rs {
+		rel, err := filepath.Rel(r.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(filepath.ToSlash(rel)) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchUnder reports whether path, or one of its directories below root,
+// matches, so a directory pattern such as "src" or "src/" covers the files
+// under it.
+func (rs ruleSet) matchUnder(root, path string) bool {
+	if rs.match(path, false) {
+		return true
+	}
+	for dir := filepath.Dir(path); len(dir) > len(root); dir = filepath.Dir(dir) {
+		if rs.match(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadIgnoreFiles returns the rules from the ignore files in dir. Missing
+// or unreadable files are skipped.
+func loadIgnoreFiles(dir string) ruleSet {
+	var rules ruleSet
+	for _, name := range ignoreFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if r, ok := parseRule(dir, sc.Text()); ok {
+				rules = append(rules, r)
+			}
+		}
+		f.Close()
+	}
+	return rules
+}
+
+// parseRule parses one line of an ignore file, or an --include/--exclude
+// pattern, relative to base.
+func parseRule(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // "\#" and "\!" are literal
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	re, err := regexp.Compile(globPattern(line))
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globPattern converts a gitignore glob to a regular expression over
+// slash-separated relative paths. Patterns without a slash match at any
+// depth; others are anchored to the base directory.
+func globPattern(glob string) string {
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; ch {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				switch {
+				case i+2 < len(glob) && glob[i+2] == '/':
+					expr.WriteString("(.*/)?") // "**/": any number of directories
+					i += 2
+				default:
+					expr.WriteString(".*")
+					i++
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	prefix := "^"
+	if !anchored {
+		prefix = "^(.*/)?"
+	}
+	return prefix + expr.String() + "$"
+}
@@ -0,0 +1,119 @@
+package walker
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LanguageStats counts the collected files of one language and their lines.
+type LanguageStats struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Lines    int    `json:"lines"`
+}
+
+// languageExts maps lower-case file extensions to language names.
+var languageExts = map[string]string{
+	".go": "Go", ".py": "Python", ".pyw": "Python", ".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".jsx": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript", ".mts": "TypeScript", ".java": "Java",
+	".kt": "Kotlin", ".kts": "Kotlin", ".scala": "Scala", ".groovy": "Groovy", ".rb": "Ruby", ".php": "PHP",
+	".cs": "C#", ".vb": "Visual Basic", ".fs": "F#", ".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++",
+	".cxx": "C++", ".hpp": "C++", ".hh": "C++", ".m": "Objective-C", ".mm": "Objective-C", ".swift": "Swift",
+	".rs": "Rust", ".dart": "Dart", ".lua": "Lua", ".pl": "Perl", ".pm": "Perl", ".r": "R", ".jl": "Julia",
+	".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang", ".hs": "Haskell", ".clj": "Clojure", ".zig": "Zig",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".ps1": "PowerShell", ".sql": "SQL",
+	".html": "HTML", ".htm": "HTML", ".css": "CSS", ".scss": "CSS", ".sass": "CSS", ".less": "CSS",
+	".vue": "Vue", ".svelte": "Svelte", ".tf": "Terraform", ".hcl": "Terraform", ".yaml": "YAML", ".yml": "YAML",
+	".json": "JSON", ".xml": "XML", ".toml": "TOML", ".ini": "INI", ".proto": "Protocol Buffers",
+	".md": "Markdown", ".gradle": "Gradle", ".sol": "Solidity",
+}
+
+// languageNames maps file names without a telling extension to languages.
+var languageNames = map[string]string{
+	"dockerfile": "Dockerfile", "containerfile": "Dockerfile", "makefile": "Makefile", "gnumakefile": "Makefile",
+	"jenkinsfile": "Groovy", "gemfile": "Ruby", "rakefile": "Ruby", "vagrantfile": "Ruby",
+}
+
+// LanguageOf returns the language of a file by its name, or "Other".
+func LanguageOf(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	if lang, ok := languageNames[name]; ok {
+		return lang
+	}
+	if strings.HasPrefix(name, "dockerfile.") || strings.HasSuffix(name, ".dockerfile") {
+		return "Dockerfile"
+	}
+	if lang, ok := languageExts[filepath.Ext(name)]; ok {
+		return lang
+	}
+	return "Other"
+}
+
+// Languages counts files and lines per language, most lines first, for
+// reports to show what a scan covered. Files that can't be read or hold
+// binary data count as files but add no lines.
+func Languages(files []string) []LanguageStats {
+	byLang := make(map[string]*LanguageStats)
+	for _, f := range files {
+		lang := LanguageOf(f)
+		st, ok := byLang[lang]
+		if !ok {
+			st = &LanguageStats{Language: lang}
+			byLang[lang] = st
+		}
+		st.Files++
+		st.Lines += countLines(f)
+	}
+
+	stats := make([]LanguageStats, 0, len(byLang))
+	for _, st := range byLang {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Lines != stats[j].Lines {
+			return stats[i].Lines > stats[j].Lines
+		}
+		if stats[i].Files != stats[j].Files {
+			return stats[i].Files > stats[j].Files
+		}
+		return stats[i].Language < stats[j].Language
+	})
+	return stats
+}
+
+// countLines returns the number of lines of a text file, counting a last
+// line without a newline; binary files have none.
+func countLines(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	buf := make([]byte, 32*1024)
+	lines, last := 0, byte('\n')
+	for first := true; ; first = false {
+		n, err := f.Read(buf)
+		if first && bytes.IndexByte(buf[:n], 0) >= 0 {
+			return 0
+		}
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines
+}
@@ -0,0 +1,167 @@
+package walker
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pefman/sidekick/internal/config"
+	"github.com/pefman/sidekick/internal/scanner"
+)
+
+// Options controls which files Collect returns.
+type Options struct {
+	IncludeTests bool     // Also return test files and directories
+	Include      []string // If set, only files matching one of these globs or under a directory that does
+	Exclude      []string // Skip files and directories matching any of these globs
+
+	// Ignore holds project-wide gitignore-style patterns relative to
+	// IgnoreBase, e.g. from .sidekick.yaml. They apply before the ignore
+	// files under root, which can re-include what they exclude.
+	Ignore     []string
+	IgnoreBase string
+}
+
+// skipDirs are never descended into, whatever the ignore files say.
+var skipDirs = map[string]bool{"node_modules": true, "vendor": true, "dist": true, "build": true}
+
+// sensitiveFiles are never sent to a model: exact names or suffixes.
+var sensitiveFiles = []string{".env", ".env.local", ".env.production", "id_rsa", "id_ed25519", ".pem", ".key", ".pfx", ".p12"}
+
+// Collect returns the files under root that a scan should read. It skips
+// hidden and dependency directories, sensitive files, test files unless
+// asked for, and anything matched by .gitignore or .sidekickignore files in
+// root, its subdirectories and its parents up to the repository root.
+// Include and Exclude globs use the same syntax, relative to root. A root
+// that is a file is returned as is.
+func Collect(root string, opts Options) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	rules := parentRules(root)
+	for _, p := range opts.Ignore {
+		if r, ok := parseRule(opts.IgnoreBase, p); ok {
+			rules = append(rules, r)
+		}
+	}
+	var include, exclude ruleSet
+	for _, p := range opts.Include {
+		if r, ok := parseRule(root, p); ok {
+			include = append(include, r)
+		}
+	}
+	for _, p := range opts.Exclude {
+		if r, ok := parseRule(root, p); ok {
+			exclude = append(exclude, r)
+		}
+	}
+
+	// Each directory's ignore rules apply to its subtree only: scopes
+	// records where they start in rules, so they are dropped once the
+	// walk leaves the directory.
+	type scope struct {
+		dir   string
+		start int
+	}
+	var scopes []scope
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		for len(scopes) > 0 && !config.Within(scopes[len(scopes)-1].dir, path) {
+			rules = rules[:scopes[len(scopes)-1].start]
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		if d.IsDir() {
+			if path != root {
+				if strings.HasPrefix(name, ".") || skipDirs[name] {
+					return filepath.SkipDir
+				}
+				if !opts.IncludeTests && scanner.IsTestDir(name) {
+					return filepath.SkipDir
+				}
+				if rules.match(path, true) {
+					return filepath.SkipDir
+				}
+				if exclude.match(path, true) {
+					return filepath.SkipDir
+				}
+			}
+			if own := loadIgnoreFiles(path); len(own) > 0 {
+				scopes = append(scopes, scope{dir: path, start: len(rules)})
+				rules = append(rules, own...)
+			}
+			return nil
+		}
+
+		if !opts.IncludeTests && scanner.IsTestFile(name) {
+			return nil
+		}
+		for _, sensitive := range sensitiveFiles {
+			if name == sensitive || strings.HasSuffix(name, sensitive) {
+				return nil
+			}
+		}
+		if rules.match(path, false) {
+			return nil
+		}
+		if exclude.match(path, false) {
+			return nil
+		}
+		if len(include) > 0 {
+			if !include.matchUnder(root, path) {
+				return nil
+			}
+		}
+
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// parentRules loads the ignore files of root's parents, outermost first,
+// stopping at the repository root (the directory holding .git). Outside a
+// repository no parent applies.
+func parentRules(root string) ruleSet {
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		return nil // root is the repository root
+	}
+
+	var parents []string
+	found := false
+	for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+		parents = append(parents, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			found = true
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var rules ruleSet
+	for i := len(parents) - 1; i >= 0; i-- {
+		rules = append(rules, loadIgnoreFiles(parents[i])...)
+	}
+	return rules
+}